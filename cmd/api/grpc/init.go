@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/wazadio/coupon-system/cmd"
+	grpcHandler "github.com/wazadio/coupon-system/internal/handlers/grpc"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// Init builds the gRPC server. It authenticates callers with the same keys as the REST API
+// and rate limits them with the same rules and buckets.
+func Init(deps *cmd.Deps) *grpc.Server {
+	if deps.Verifier == nil {
+		logger.Log.Warn("Authentication is disabled, every call is treated as an anonymous admin")
+	}
+
+	return grpcHandler.NewServer(deps.CouponService, deps.Verifier, deps.APIKeyService, deps.RateLimitStore, deps.RateLimitConfig)
+}
+
+// StartServer serves srv until ctx is cancelled, on SIGINT or SIGTERM. It returns early if
+// the server cannot listen or stops serving.
+func StartServer(ctx context.Context, srv *grpc.Server, deps *cmd.Deps) error {
+	port := deps.Config.GRPC.Port
+
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(listener)
+	}()
+
+	logger.Log.Info("Server is listening", zap.String("port", port))
+
+	// Graceful shutdown
+	select {
+	case err := <-served:
+		return fmt.Errorf("server stopped: %v", err)
+	case <-ctx.Done():
+	}
+
+	// Let in-flight calls finish, but do not wait on streams longer than the shutdown timeout
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(deps.Config.ShutdownTimeout):
+		srv.Stop()
+	}
+	return nil
+}
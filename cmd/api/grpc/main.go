@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/wazadio/coupon-system/cmd"
+	"github.com/wazadio/coupon-system/internal/config"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func main() {
+	if err := run(); err != nil {
+		exit(err)
+	}
+}
+
+// run starts the server and blocks until it stops, releasing resources in the reverse
+// order they were acquired
+func run() error {
+	// Cancelled on SIGINT or SIGTERM, which also abandons waiting for the database
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	// Initialize logger
+	if err := logger.InitWithConfig(&cfg.Log); err != nil {
+		return fmt.Errorf("failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	shutdownTracing, err := cmd.InitTracing(ctx, &cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing()
+
+	deps, err := cmd.Init(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %v", err)
+	}
+	defer deps.Close()
+
+	if err := StartServer(ctx, Init(deps), deps); err != nil {
+		return err
+	}
+
+	logger.Log.Info("Server is shutting down...")
+	logger.Log.Info("Goodbye!")
+	return nil
+}
+
+// exit reports the error that stopped the server, through the logger once it is set up,
+// and exits with status 1
+func exit(err error) {
+	if logger.Log != nil {
+		logger.Log.Error("Server failed", zap.Error(err))
+		logger.Sync()
+	} else {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(1)
+}
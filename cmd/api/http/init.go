@@ -2,16 +2,18 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/wazadio/coupon-system/cmd"
+	"github.com/wazadio/coupon-system/internal/handlers/graphql"
 	"github.com/wazadio/coupon-system/internal/handlers/middleware"
 	"github.com/wazadio/coupon-system/internal/handlers/rest"
+	"github.com/wazadio/coupon-system/internal/metrics"
 	"github.com/wazadio/coupon-system/pkg/logger"
 	"go.uber.org/zap"
 )
@@ -20,61 +22,155 @@ type handler interface {
 	SetupRouter(*mux.Router)
 }
 
-func Init() *mux.Router {
+// Init builds the handler serving deps
+func Init(deps *cmd.Deps) http.Handler {
+	router := NewRouter(deps)
+
+	// The middleware wraps the whole router, not just the routes it matches, so requests
+	// answered by NotFound and MethodNotAllowed are traced, logged and counted too. Tracing
+	// runs first so the logs carry the trace ID of the request span.
+	var handler http.Handler = router
+	handler = middleware.MetricsMiddleware(handler)
+	handler = middleware.LoggingMiddleware(deps.AccessLogConfig)(handler)
+	handler = middleware.TracingMiddleware(handler)
+	return middleware.MatchRoute(router)(handler)
+}
+
+// NewRouter registers every route of the API on a new router. TestOpenAPI_CoversEveryRoute
+// checks openapi.json documents each of them.
+func NewRouter(deps *cmd.Deps) *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
+	router.NotFoundHandler = http.HandlerFunc(rest.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(rest.MethodNotAllowed)
 
-	deps, err := cmd.Init()
-	if err != nil {
-		panic(err)
-	}
+	// Prometheus scrape endpoint, outside /api so it is never rate limited or authenticated
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Kubernetes probes, outside /api for the same reason
+	rest.NewProbeHandler(deps.Health).SetupRouter(router)
 
 	// API subrouter with /api prefix
 	api := router.PathPrefix("/api").Subrouter()
 
+	// Public routes
+	(&rest.BaseHandler{}).SetupRouter(api)
+	(&rest.DocsHandler{}).SetupRouter(api)
+
+	// Routes requiring a bearer token
+	protected := api.NewRoute().Subrouter()
+	if deps.Verifier != nil {
+		protected.Use(middleware.AuthMiddleware(deps.Verifier, deps.APIKeyService))
+	} else {
+		logger.Log.Warn("Authentication is disabled, every request is treated as an anonymous admin")
+		protected.Use(middleware.DevIdentityMiddleware)
+	}
+
+	// Rate limits run after authentication so they can be keyed by the caller
+	if deps.RateLimitConfig != nil {
+		protected.Use(middleware.RateLimitMiddleware(deps.RateLimitStore, deps.RateLimitConfig))
+	}
+
 	// Initialize and setup routers for different handlers
 	var handlers []handler
 
 	handlers = append(handlers, rest.NewCouponHandler(deps.CouponService))
-	handlers = append(handlers, &rest.BaseHandler{})
+	handlers = append(handlers, rest.NewCodeHandler(deps.CouponService))
+	handlers = append(handlers, rest.NewAccessListHandler(deps.AccessListService))
+	handlers = append(handlers, rest.NewAPIKeyHandler(deps.APIKeyService))
+	handlers = append(handlers, rest.NewQueueHandler(deps.QueueService))
+	handlers = append(handlers, rest.NewLotteryHandler(deps.LotteryService))
+	handlers = append(handlers, &rest.LogLevelHandler{})
+	handlers = append(handlers, graphql.NewGraphQLHandler(deps.CouponService, deps.RateLimitStore, deps.RateLimitConfig))
 
 	for _, handler := range handlers {
-		handler.SetupRouter(api)
+		handler.SetupRouter(protected)
 	}
 
-	router.Use(middleware.LoggingMiddleware)
-
 	return router
 }
 
-func StartServer(ctx context.Context, router *mux.Router) error {
-	port := "8080"
-	if p := os.Getenv("SERVER_PORT"); p != "" {
-		port = p
+// StartQueueWorkers drains the claim queue in the background. The returned function stops
+// the workers and waits for the claims they have under way.
+func StartQueueWorkers(deps *cmd.Deps) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < deps.QueueConfig.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deps.QueueService.Run(ctx, *deps.QueueConfig)
+		}()
 	}
 
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// StartEventRelay relays the coupon_events outbox to the events webhook in the background,
+// unless no webhook is configured. The returned function stops the relay and waits for it.
+func StartEventRelay(deps *cmd.Deps) (stop func()) {
+	if deps.EventRelayConfig.WebhookURL == "" {
+		logger.Log.Info("Event relay disabled, events stay in the outbox until EVENTS_WEBHOOK_URL is set")
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		deps.EventService.Run(ctx, *deps.EventRelayConfig)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// StartServer serves handler until ctx is cancelled, on SIGINT or SIGTERM. /readyz then
+// turns unready and the server keeps serving for the drain delay, so load balancers stop
+// routing to it before it stops accepting connections. It returns early if the server
+// cannot listen.
+func StartServer(ctx context.Context, handler http.Handler, deps *cmd.Deps) error {
+	cfg := deps.Config
+	port := cfg.HTTP.Port
+
 	srv := &http.Server{
-		Handler:      router,
+		Handler:      handler,
 		Addr:         ":" + port,
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+	}
+
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
 	}
 
+	served := make(chan error, 1)
 	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			logger.Log.Error("Server error", zap.Error(err))
-		}
+		served <- srv.Serve(listener)
 	}()
 
 	logger.Log.Info("Server is listening", zap.String("port", port))
 
 	// Graceful shutdown
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-served:
+		return fmt.Errorf("server stopped: %v", err)
+	case <-ctx.Done():
+	}
 
-	<-c
+	deps.Health.Shutdown()
+	if delay := deps.HealthConfig.DrainDelay; delay > 0 {
+		logger.Log.Info("Draining before shutdown", zap.Duration("delay", delay))
+		time.Sleep(delay)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 	return srv.Shutdown(ctx)
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wazadio/coupon-system/cmd"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+// TestOpenAPI_CoversEveryRoute checks openapi.json, as the API serves it, documents exactly
+// the routes NewRouter registers
+func TestOpenAPI_CoversEveryRoute(t *testing.T) {
+	logger.Init()
+
+	// Routing does not reach the services, so they are left nil
+	router := NewRouter(&cmd.Deps{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+
+	routed := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Subrouter prefixes have no methods
+			return nil
+		}
+		for _, method := range methods {
+			routed[method+" "+path] = true
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	documented := map[string]bool{}
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	assert.Empty(t, missing(routed, documented), "routes without an operation in openapi.json")
+	assert.Empty(t, missing(documented, routed), "operations in openapi.json without a route")
+}
+
+// missing returns the keys of want that has lacks, sorted for stable failure messages
+func missing(want, has map[string]bool) []string {
+	var keys []string
+	for key := range want {
+		if !has[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,32 +1,178 @@
 package cmd
 
 import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/wazadio/coupon-system/internal/config"
 	"github.com/wazadio/coupon-system/internal/database"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/health"
+	"github.com/wazadio/coupon-system/internal/metrics"
 	"github.com/wazadio/coupon-system/internal/repository"
 	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/internal/tracing"
+	"github.com/wazadio/coupon-system/pkg/jwt"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/ratelimit"
+	"go.uber.org/zap"
 )
 
 type Deps struct {
 	// Add dependencies here as needed
 
+	// Configuration loaded on startup
+	Config *config.Config
+
+	// Database connection pool, closed by Close
+	DB *sql.DB
+
 	// Repositories
-	CouponRepository repository.CouponRepository
+	CouponRepository     repository.CouponRepository
+	AccessListRepository repository.AccessListRepository
+	APIKeyRepository     repository.APIKeyRepository
+	QueueRepository      repository.QueueRepository
+	LotteryRepository    repository.LotteryRepository
+	EventRepository      repository.EventRepository
 
 	// Services
-	CouponService service.CouponService
+	CouponService     service.CouponService
+	AccessListService service.AccessListService
+	APIKeyService     service.APIKeyService
+	QueueService      service.QueueService
+	LotteryService    service.LotteryService
+	EventService      service.EventService
+
+	// Claim queue workers
+	QueueConfig *service.QueueConfig
+
+	// Outbox relay to the events webhook
+	EventRelayConfig *service.EventRelayConfig
+
+	// Authentication, nil when Config.Features.AuthDisabled is set
+	Verifier *jwt.Verifier
+
+	// Rate limiting, RateLimitConfig is nil when Config.Features.RateLimitDisabled is set
+	RateLimitStore  ratelimit.Store
+	RateLimitConfig *middleware.RateLimitConfig
+
+	// Access log sampling
+	AccessLogConfig *middleware.AccessLogConfig
+
+	// Readiness checks behind /readyz
+	HealthConfig *health.Config
+	Health       *health.Checker
+
+	// closers release what Init acquired, in the order it was acquired
+	closers []closer
+}
+
+// closer releases one resource named name
+type closer struct {
+	name  string
+	close func() error
 }
 
-func Init() (deps *Deps, err error) {
-	deps = &Deps{}
+// Init builds the dependencies of the API servers. It waits for the database as
+// cfg.Database allows, giving up when ctx is cancelled. On error, whatever was acquired
+// is released again.
+func Init(ctx context.Context, cfg *config.Config) (deps *Deps, err error) {
+	deps = &Deps{Config: cfg}
+	// deps is nil by the time a failed Init returns, so hold on to what it acquired
+	defer func(acquired *Deps) {
+		if err != nil {
+			acquired.Close()
+		}
+	}(deps)
 
 	// Connect to the database
-	db, err := database.Connect(database.NewConfigFromEnv())
+	dbConfig := &cfg.Database
+	db, err := database.Connect(ctx, dbConfig)
+	if err != nil {
+		return nil, err
+	}
+	deps.DB = db
+	deps.closers = append(deps.closers, closer{"database", db.Close})
+
+	// Expose the connection pool statistics on /metrics
+	if err := metrics.RegisterDB(db, dbConfig.DBName); err != nil {
+		return nil, err
+	}
 
 	// Initialize repositories
 	deps.CouponRepository = repository.NewCouponRepository(db)
+	deps.AccessListRepository = repository.NewAccessListRepository(db)
+	deps.APIKeyRepository = repository.NewAPIKeyRepository(db)
+	deps.QueueRepository = repository.NewQueueRepository(db)
+	deps.LotteryRepository = repository.NewLotteryRepository(db)
+	deps.EventRepository = repository.NewEventRepository(db)
 
 	// Initialize services with injected repositories
 	deps.CouponService = service.NewCouponService(deps.CouponRepository)
+	deps.AccessListService = service.NewAccessListService(deps.AccessListRepository)
+	deps.APIKeyService = service.NewAPIKeyService(deps.APIKeyRepository, middleware.IsPermission)
+	deps.QueueService = service.NewQueueService(deps.QueueRepository, deps.CouponRepository)
+	deps.LotteryService = service.NewLotteryService(deps.LotteryRepository)
+	deps.EventService = service.NewEventService(deps.EventRepository, &http.Client{})
+
+	deps.QueueConfig = &cfg.Queue
+	deps.EventRelayConfig = &cfg.Events
+
+	// Load JWT verification keys unless authentication is explicitly disabled
+	if !cfg.Features.AuthDisabled {
+		deps.Verifier, err = jwt.NewVerifier(&cfg.Auth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Rate limits are kept in memory, so each API instance enforces them separately
+	if !cfg.Features.RateLimitDisabled {
+		deps.RateLimitConfig = &cfg.RateLimit
+	}
+	deps.RateLimitStore = ratelimit.NewMemoryStore()
+
+	deps.AccessLogConfig = &cfg.AccessLog
+
+	deps.HealthConfig = &cfg.Health
+	deps.Health = health.NewChecker(deps.HealthConfig.Timeout,
+		health.DatabaseCheck(db),
+		health.SchemaCheck(db),
+		health.PoolCheck(db, deps.HealthConfig.PoolSaturation),
+	)
 
 	return
 }
+
+// Close releases the resources acquired by Init, the most recent first, logging any that
+// fail to close. Call it once nothing uses the dependencies any more.
+func (d *Deps) Close() {
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		if err := d.closers[i].close(); err != nil {
+			logger.Log.Error("Failed to close "+d.closers[i].name, zap.Error(err))
+		}
+	}
+	d.closers = nil
+}
+
+// tracingShutdownTimeout bounds how long exiting waits for pending spans to be exported
+const tracingShutdownTimeout = 5 * time.Second
+
+// InitTracing installs the tracer provider configured by config. The returned function
+// flushes pending spans and must be called before exiting.
+func InitTracing(ctx context.Context, config *tracing.Config) (shutdown func(), err error) {
+	shutdownProvider, err := tracing.Init(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownProvider(ctx); err != nil {
+			logger.Log.Error("Failed to flush traces", zap.Error(err))
+		}
+	}, nil
+}
@@ -0,0 +1,84 @@
+// Command lottery draws the winners of a lottery coupon once its entries have closed.
+//
+//	go run ./cmd/lottery -new-seed
+//	go run ./cmd/lottery -coupon FLASH25 -seed <seed>
+//
+// -new-seed prints a random seed and the lottery_seed_hash committing to it, to create the
+// coupon with. The draw takes that seed back and is refused if it does not match. It loads
+// the database and log settings as the API does, from -config or CONFIG_FILE, the
+// environment and flags, and prints the recorded draw as JSON.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wazadio/coupon-system/internal/config"
+	"github.com/wazadio/coupon-system/internal/database"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/lottery"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine)
+	couponName := flag.String("coupon", "", "name of the lottery coupon to draw")
+	seed := flag.String("seed", "", "seed the coupon's lottery_seed_hash committed to")
+	newSeed := flag.Bool("new-seed", false, "print a new seed and its lottery_seed_hash, then exit")
+	flag.Parse()
+
+	if *newSeed {
+		seed, err := lottery.NewSeed()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate seed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("seed:              %s\nlottery_seed_hash: %s\n", seed, lottery.Commit(seed))
+		return
+	}
+
+	if *couponName == "" || *seed == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(loader, *couponName, *seed); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run draws the lottery of couponName with seed and prints the draw. It returns instead of
+// exiting so the logger is synced and the database closed on every path.
+func run(loader *config.Loader, couponName, seed string) error {
+	cfg, err := loader.Load()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	if err := logger.InitWithConfig(&cfg.Log); err != nil {
+		return fmt.Errorf("failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	db, err := database.Connect(context.Background(), &cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	lotteryService := service.NewLotteryService(repository.NewLotteryRepository(db))
+	draw, err := lotteryService.DrawLottery(context.Background(), couponName, &models.DrawLotteryRequest{Seed: seed})
+	if err != nil {
+		return fmt.Errorf("failed to draw lottery: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(draw)
+}
@@ -0,0 +1,319 @@
+// Package config loads the typed configuration of the API servers and commands. Settings come
+// from, in increasing precedence, the defaults, a YAML file, environment variables and
+// command-line flags, and are validated once on startup so a bad setting fails fast with a
+// clear error.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/wazadio/coupon-system/internal/database"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/health"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/internal/tracing"
+	"github.com/wazadio/coupon-system/pkg/jwt"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the configuration of the API servers
+type Config struct {
+	Database database.Config `yaml:"database"`
+	HTTP     HTTPConfig      `yaml:"http"`
+	GRPC     GRPCConfig      `yaml:"grpc"`
+	// ShutdownTimeout bounds how long a stopping server waits for in-flight requests
+	ShutdownTimeout time.Duration  `yaml:"shutdown_timeout"`
+	Log             logger.Config  `yaml:"log"`
+	Features        FeaturesConfig `yaml:"features"`
+	// Auth lists the JWT verification keys, unused when Features.AuthDisabled is set
+	Auth jwt.Config `yaml:"auth"`
+	// RateLimit holds the rules per route, unused when Features.RateLimitDisabled is set.
+	// Routes in the YAML file replace the default rules of the same route.
+	RateLimit middleware.RateLimitConfig `yaml:"rate_limit"`
+	AccessLog middleware.AccessLogConfig `yaml:"access_log"`
+	Health    health.Config              `yaml:"health"`
+	Queue     service.QueueConfig        `yaml:"queue"`
+	Events    service.EventRelayConfig   `yaml:"events"`
+	Tracing   tracing.Config             `yaml:"tracing"`
+}
+
+// HTTPConfig holds the port and timeouts of the HTTP server
+type HTTPConfig struct {
+	Port         string        `yaml:"port"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+}
+
+// GRPCConfig holds the port of the gRPC server
+type GRPCConfig struct {
+	Port string `yaml:"port"`
+}
+
+// FeaturesConfig switches off features that are on by default
+type FeaturesConfig struct {
+	// AuthDisabled treats every request as an anonymous admin. Local development only.
+	AuthDisabled bool `yaml:"auth_disabled"`
+	// RateLimitDisabled lets every request through regardless of the rate limits
+	RateLimitDisabled bool `yaml:"rate_limit_disabled"`
+}
+
+// Default returns the configuration used when nothing is set
+func Default() *Config {
+	return &Config{
+		Database: *database.DefaultConfig(),
+		HTTP: HTTPConfig{
+			Port:         "8080",
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		},
+		GRPC:            GRPCConfig{Port: "9090"},
+		ShutdownTimeout: 15 * time.Second,
+		Log:             *logger.DefaultConfig(),
+		RateLimit:       *middleware.DefaultRateLimitConfig(),
+		AccessLog:       *middleware.DefaultAccessLogConfig(),
+		Health:          *health.DefaultConfig(),
+		Queue:           *service.DefaultQueueConfig(),
+		Events:          *service.DefaultEventRelayConfig(),
+		Tracing:         *tracing.DefaultConfig(),
+	}
+}
+
+// Load builds the configuration for the command name from the YAML file given by -config
+// or CONFIG_FILE, the environment and the flags in args, then validates it. Invalid flags
+// print the usage and exit, as -h does.
+func Load(name string, args []string) (*Config, error) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	loader := NewLoader(flags)
+	flags.Parse(args)
+	return loader.Load()
+}
+
+// Loader loads the configuration once the flags it defined are parsed. Commands with flags
+// of their own use it in place of Load.
+type Loader struct {
+	path      *string
+	overrides *flagOverrides
+}
+
+// NewLoader defines -config and the setting flags on flags
+func NewLoader(flags *flag.FlagSet) *Loader {
+	return &Loader{
+		path:      flags.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration `file`"),
+		overrides: registerFlags(flags),
+	}
+}
+
+// Load builds the configuration from the YAML file, the environment and the parsed flags,
+// then validates it
+func (l *Loader) Load() (*Config, error) {
+	config := Default()
+	if *l.path != "" {
+		if err := config.loadFile(*l.path); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.LoadEnv(); err != nil {
+		return nil, err
+	}
+	l.overrides.apply(config)
+
+	return config, config.Validate()
+}
+
+// loadFile overrides the settings present in the YAML file at path. Unknown keys are
+// rejected, so a misspelt setting is not silently ignored.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// LoadEnv overrides the settings whose environment variable is set
+func (c *Config) LoadEnv() error {
+	if err := c.Database.LoadEnv(); err != nil {
+		return err
+	}
+	for _, section := range []interface{ LoadEnv() error }{
+		&c.Log, &c.Auth, &c.RateLimit, &c.AccessLog, &c.Health, &c.Queue, &c.Events, &c.Tracing,
+	} {
+		if err := section.LoadEnv(); err != nil {
+			return err
+		}
+	}
+
+	if port := os.Getenv("SERVER_PORT"); port != "" {
+		c.HTTP.Port = port
+	}
+	if port := os.Getenv("GRPC_PORT"); port != "" {
+		c.GRPC.Port = port
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", &c.HTTP.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", &c.HTTP.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", &c.HTTP.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", &c.ShutdownTimeout},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q", setting.name, value)
+			}
+			*setting.value = d
+		}
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value *bool
+	}{
+		{"AUTH_DISABLED", &c.Features.AuthDisabled},
+		{"RATE_LIMIT_DISABLED", &c.Features.RateLimitDisabled},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q", setting.name, value)
+			}
+			*setting.value = b
+		}
+	}
+
+	return nil
+}
+
+// Validate checks every section and reports all the problems found at once
+func (c *Config) Validate() error {
+	var errs []error
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("database: %v", err))
+	}
+	if err := validPort(c.HTTP.Port); err != nil {
+		errs = append(errs, fmt.Errorf("http: %v", err))
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"read_timeout", c.HTTP.ReadTimeout},
+		{"write_timeout", c.HTTP.WriteTimeout},
+		{"idle_timeout", c.HTTP.IdleTimeout},
+	} {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("http: %s must be positive, got %s", timeout.name, timeout.value))
+		}
+	}
+	if err := validPort(c.GRPC.Port); err != nil {
+		errs = append(errs, fmt.Errorf("grpc: %v", err))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown_timeout must be positive, got %s", c.ShutdownTimeout))
+	}
+	for _, section := range []struct {
+		name   string
+		config interface{ Validate() error }
+	}{
+		{"log", &c.Log},
+		{"auth", &c.Auth},
+		{"rate_limit", &c.RateLimit},
+		{"access_log", &c.AccessLog},
+		{"health", &c.Health},
+		{"queue", &c.Queue},
+		{"events", &c.Events},
+		{"tracing", &c.Tracing},
+	} {
+		if err := section.config.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", section.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validPort(port string) error {
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// flagOverrides holds the settings given on the command line, applied last
+type flagOverrides []func(*Config)
+
+// registerFlags defines a flag for each setting commonly changed from the command line.
+// Values are checked as flags are parsed and applied by apply.
+func registerFlags(flags *flag.FlagSet) *flagOverrides {
+	overrides := &flagOverrides{}
+	str := func(name, usage string, set func(*Config, string)) {
+		flags.Func(name, usage, func(value string) error {
+			*overrides = append(*overrides, func(c *Config) { set(c, value) })
+			return nil
+		})
+	}
+	boolean := func(name, usage string, set func(*Config, bool)) {
+		flags.BoolFunc(name, usage, func(value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			*overrides = append(*overrides, func(c *Config) { set(c, b) })
+			return nil
+		})
+	}
+
+	str("http-port", "HTTP server `port`", func(c *Config, v string) { c.HTTP.Port = v })
+	str("grpc-port", "gRPC server `port`", func(c *Config, v string) { c.GRPC.Port = v })
+	str("db-host", "database `host`", func(c *Config, v string) { c.Database.Host = v })
+	str("db-port", "database `port`", func(c *Config, v string) { c.Database.Port = v })
+	str("db-name", "database `name`", func(c *Config, v string) { c.Database.DBName = v })
+	str("log-format", "log `format`, json or console", func(c *Config, v string) { c.Log.Format = v })
+	flags.Func("log-level", "minimum `level` logged", func(value string) error {
+		level, err := zapcore.ParseLevel(value)
+		if err != nil {
+			return err
+		}
+		*overrides = append(*overrides, func(c *Config) { c.Log.Level = level })
+		return nil
+	})
+	boolean("auth-disabled", "treat every request as an anonymous admin", func(c *Config, v bool) { c.Features.AuthDisabled = v })
+	boolean("rate-limit-disabled", "do not enforce rate limits", func(c *Config, v bool) { c.Features.RateLimitDisabled = v })
+	flags.Func("queue-workers", "`number` of claim queue workers", func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*overrides = append(*overrides, func(c *Config) { c.Queue.Workers = n })
+		return nil
+	})
+	str("trace-exporter", "trace `exporter`, none, otlp or file", func(c *Config, v string) { c.Tracing.Exporter = v })
+
+	return overrides
+}
+
+// apply applies the overrides in the order the flags were given
+func (o *flagOverrides) apply(c *Config) {
+	for _, override := range *o {
+		override(c)
+	}
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"go.uber.org/zap/zapcore"
+)
+
+// clearEnv unsets every variable Load reads, for the duration of the test
+func clearEnv(t *testing.T) {
+	for _, name := range []string{
+		"CONFIG_FILE", "DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS",
+		"DB_CONN_MAX_LIFETIME", "DB_CONN_MAX_IDLE_TIME", "DB_CONNECT_ATTEMPTS", "DB_CONNECT_BACKOFF", "DB_CONNECT_MAX_BACKOFF",
+		"SERVER_PORT", "GRPC_PORT", "HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT", "SHUTDOWN_TIMEOUT",
+		"AUTH_DISABLED", "RATE_LIMIT_DISABLED", "LOG_LEVEL", "LOG_FORMAT", "LOG_OUTPUTS", "LOG_FILE",
+		"LOG_REDACTION_KEY", "LOG_REDACT_HASH_FIELDS", "LOG_REDACT_MASK_FIELDS", "LOG_FILE_COMPRESS",
+		"JWT_HMAC_KEY_FILE", "JWT_RSA_PUBLIC_KEY_FILE", "JWT_JWKS_FILE", "JWT_ISSUER", "JWT_AUDIENCE", "JWT_LEEWAY",
+		"RATE_LIMIT_FILE", "RATE_LIMIT_TRUST_FORWARDED_FOR", "ACCESS_LOG_SAMPLE_RATE",
+		"READINESS_TIMEOUT", "READINESS_POOL_SATURATION", "SHUTDOWN_DRAIN_DELAY",
+		"QUEUE_WORKERS", "QUEUE_POLL_INTERVAL", "QUEUE_TICKET_LEASE",
+		"EVENTS_WEBHOOK_URL", "EVENTS_POLL_INTERVAL", "EVENTS_BATCH_SIZE", "EVENTS_WEBHOOK_TIMEOUT",
+		"OTEL_TRACES_EXPORTER", "OTEL_SERVICE_NAME", "OTEL_TRACES_FILE",
+	} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_USER", "coupon_user")
+	t.Setenv("DB_NAME", "coupon_db")
+
+	config, err := Load("api", nil)
+	require.NoError(t, err)
+
+	want := Default()
+	want.Database.User = "coupon_user"
+	want.Database.DBName = "coupon_db"
+	assert.Equal(t, want, config)
+	assert.Equal(t, 25, config.Database.MaxOpenConns)
+	assert.Equal(t, 15*time.Second, config.HTTP.WriteTimeout)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, `
+database:
+  host: db.internal
+  user: coupon_user
+  name: coupon_db
+  max_open_conns: 50
+http:
+  port: "8000"
+  write_timeout: 30s
+log:
+  level: debug
+  outputs: [stdout]
+features:
+  rate_limit_disabled: true
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("DB_HOST", "db.env")
+	t.Setenv("SERVER_PORT", "8001")
+
+	config, err := Load("api", []string{"-http-port", "8002", "-log-level", "warn", "-auth-disabled"})
+	require.NoError(t, err)
+
+	// The file overrides the defaults
+	assert.Equal(t, 50, config.Database.MaxOpenConns)
+	assert.Equal(t, 30*time.Second, config.HTTP.WriteTimeout)
+	assert.Equal(t, []string{"stdout"}, config.Log.Outputs)
+	assert.True(t, config.Features.RateLimitDisabled)
+	assert.Equal(t, 15*time.Second, config.HTTP.ReadTimeout)
+	// The environment overrides the file
+	assert.Equal(t, "db.env", config.Database.Host)
+	// Flags override both
+	assert.Equal(t, "8002", config.HTTP.Port)
+	assert.Equal(t, zapcore.WarnLevel, config.Log.Level)
+	assert.True(t, config.Features.AuthDisabled)
+}
+
+func TestLoad_Sections(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, `
+database: {user: coupon_user, name: coupon_db}
+auth:
+  hmac_key_file: /etc/coupon/jwt.key
+  issuer: https://auth.example.com
+rate_limit:
+  trust_forwarded_for: true
+  routes:
+    GET /api/coupons/{name}:
+      - {key: ip, requests: 30, per: 10s}
+access_log: {success_sample_rate: 0.5}
+health: {drain_delay: 5s}
+queue: {workers: 4, poll_interval: 1s}
+events: {webhook_url: "https://events.example.com/coupons", batch_size: 10}
+tracing: {exporter: file}
+`)
+	t.Setenv("QUEUE_WORKERS", "2")
+	t.Setenv("JWT_AUDIENCE", "coupon-api")
+
+	config, err := Load("api", []string{"-config", path, "-trace-exporter", "otlp"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/etc/coupon/jwt.key", config.Auth.HMACKeyFile)
+	assert.Equal(t, "https://auth.example.com", config.Auth.Issuer)
+	assert.Equal(t, "coupon-api", config.Auth.Audience)
+	assert.True(t, config.RateLimit.TrustForwardedFor)
+	assert.Equal(t, []middleware.RateLimitRule{{Key: middleware.RateLimitByIP, Requests: 30, Per: 10 * time.Second}},
+		config.RateLimit.Routes["GET /api/coupons/{name}"])
+	// Routes the file leaves out keep their default rules
+	assert.Equal(t, middleware.DefaultRateLimitConfig().Routes["POST /api/coupons/claim"], config.RateLimit.Routes["POST /api/coupons/claim"])
+	assert.Equal(t, 0.5, config.AccessLog.SuccessSampleRate)
+	assert.Equal(t, 5*time.Second, config.Health.DrainDelay)
+	assert.Equal(t, 2, config.Queue.Workers)
+	assert.Equal(t, time.Second, config.Queue.PollInterval)
+	assert.Equal(t, "https://events.example.com/coupons", config.Events.WebhookURL)
+	assert.Equal(t, 10, config.Events.BatchSize)
+	assert.Equal(t, "otlp", config.Tracing.Exporter)
+}
+
+func TestLoad_ExampleFile(t *testing.T) {
+	clearEnv(t)
+
+	config, err := Load("api", []string{"-config", filepath.Join("..", "..", "config.example.yaml")})
+	require.NoError(t, err)
+	assert.Equal(t, Default().RateLimit, config.RateLimit)
+	assert.Equal(t, Default().Queue, config.Queue)
+}
+
+func TestLoad_ConfigFlag(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, "database: {user: from_env_file, name: coupon_db}\n"))
+	path := writeFile(t, "database: {user: from_flag_file, name: coupon_db}\n")
+
+	config, err := Load("api", []string{"-config", path})
+	require.NoError(t, err)
+	assert.Equal(t, "from_flag_file", config.Database.User)
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	clearEnv(t)
+
+	_, err := Load("api", []string{"-config", writeFile(t, "http:\n  prot: \"8000\"\n")})
+	assert.ErrorContains(t, err, `field prot not found`)
+
+	_, err = Load("api", []string{"-config", writeFile(t, "http:\n  read_timeout: soon\n")})
+	assert.ErrorContains(t, err, "invalid config file")
+
+	_, err = Load("api", []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")})
+	assert.ErrorContains(t, err, "error reading config file")
+}
+
+func TestLoad_InvalidEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("HTTP_WRITE_TIMEOUT", "15")
+
+	_, err := Load("api", nil)
+	assert.EqualError(t, err, `invalid HTTP_WRITE_TIMEOUT: "15"`)
+
+	clearEnv(t)
+	t.Setenv("AUTH_DISABLED", "yes")
+	_, err = Load("api", nil)
+	assert.EqualError(t, err, `invalid AUTH_DISABLED: "yes"`)
+}
+
+func TestLoad_BoolEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_USER", "coupon_user")
+	t.Setenv("DB_NAME", "coupon_db")
+	t.Setenv("AUTH_DISABLED", "1")
+	t.Setenv("RATE_LIMIT_DISABLED", "TRUE")
+
+	config, err := Load("api", nil)
+	require.NoError(t, err)
+	assert.True(t, config.Features.AuthDisabled)
+	assert.True(t, config.Features.RateLimitDisabled)
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	config := Default()
+	config.Database.User = "coupon_user"
+	config.Database.DBName = "coupon_db"
+	require.NoError(t, config.Validate())
+
+	config.Database.MaxIdleConns = 30
+	config.HTTP.Port = "http"
+	config.HTTP.ReadTimeout = 0
+	config.Log.Format = "xml"
+	config.Queue.PollInterval = 0
+	config.Tracing.Exporter = "zipkin"
+
+	err := config.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "database: max_idle_conns must be between 0 and max_open_conns (25), got 30")
+	assert.ErrorContains(t, err, `http: invalid port "http"`)
+	assert.ErrorContains(t, err, "http: read_timeout must be positive, got 0s")
+	assert.ErrorContains(t, err, `log: invalid format "xml"`)
+	assert.ErrorContains(t, err, "queue: poll_interval must be positive, got 0s")
+	assert.ErrorContains(t, err, `tracing: invalid exporter "zipkin"`)
+}
@@ -1,36 +1,160 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/wazadio/coupon-system/pkg/logger"
+	"go.uber.org/zap"
 )
 
+// SchemaVersion is the version of scripts/init.sql this code needs, recorded in the
+// schema_version table. Bump it, and the version the script records, whenever the script
+// adds a table or column, so instances stay unready on a database that was not upgraded.
+//
+//	1: schema_version table
+//	2: coupon and coupon code columns added to existing databases
+//	3: claim_queue.taken_at, the lease of tickets being processed
+//	4: lottery seed commitments, draw entrants and coupon_events.delivered_at
+const SchemaVersion = 4
+
 // Config holds database configuration
 type Config struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	DBName   string `yaml:"name"`
+	// MaxOpenConns caps the connections to Postgres; claims beyond it wait for one to free up
+	MaxOpenConns int `yaml:"max_open_conns"`
+	// MaxIdleConns is how many unused connections are kept open for the next requests
+	MaxIdleConns int `yaml:"max_idle_conns"`
+	// ConnMaxLifetime closes connections once this old, so failovers and load balancers
+	// in front of Postgres see connections move. Zero keeps them forever.
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	// ConnMaxIdleTime closes connections left unused this long. Zero keeps them forever.
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	// ConnectAttempts is how many times Connect tries to reach the database before failing
+	ConnectAttempts int `yaml:"connect_attempts"`
+	// ConnectBackoff is the wait after the first failed attempt, doubled after each next
+	// one up to ConnectMaxBackoff
+	ConnectBackoff    time.Duration `yaml:"connect_backoff"`
+	ConnectMaxBackoff time.Duration `yaml:"connect_max_backoff"`
 }
 
-// NewConfigFromEnv creates a new database config from environment variables
-func NewConfigFromEnv() *Config {
+// DefaultConfig connects to localhost:5432 with a pool of 25 connections, 5 kept idle. It
+// makes 6 attempts to connect, about 15s, so the database can finish starting alongside
+// the API.
+func DefaultConfig() *Config {
 	return &Config{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     os.Getenv("DB_PORT"),
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   os.Getenv("DB_NAME"),
+		Host:              "localhost",
+		Port:              "5432",
+		MaxOpenConns:      25,
+		MaxIdleConns:      5,
+		ConnMaxLifetime:   30 * time.Minute,
+		ConnMaxIdleTime:   5 * time.Minute,
+		ConnectAttempts:   6,
+		ConnectBackoff:    500 * time.Millisecond,
+		ConnectMaxBackoff: 8 * time.Second,
 	}
 }
 
-// Connect establishes a database connection and returns it
-func Connect(config *Config) (*sql.DB, error) {
+// LoadEnv overrides the settings whose DB_* environment variable is set
+func (c *Config) LoadEnv() error {
+	for _, setting := range []struct {
+		name  string
+		value *string
+	}{
+		{"DB_HOST", &c.Host},
+		{"DB_PORT", &c.Port},
+		{"DB_USER", &c.User},
+		{"DB_PASSWORD", &c.Password},
+		{"DB_NAME", &c.DBName},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			*setting.value = value
+		}
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{
+		{"DB_MAX_OPEN_CONNS", &c.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", &c.MaxIdleConns},
+		{"DB_CONNECT_ATTEMPTS", &c.ConnectAttempts},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q", setting.name, value)
+			}
+			*setting.value = n
+		}
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"DB_CONN_MAX_LIFETIME", &c.ConnMaxLifetime},
+		{"DB_CONN_MAX_IDLE_TIME", &c.ConnMaxIdleTime},
+		{"DB_CONNECT_BACKOFF", &c.ConnectBackoff},
+		{"DB_CONNECT_MAX_BACKOFF", &c.ConnectMaxBackoff},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q", setting.name, value)
+			}
+			*setting.value = d
+		}
+	}
+	return nil
+}
+
+// Validate checks a connection can be attempted with the config. Settings are named by
+// their YAML key, as they may come from the config file or the environment.
+func (c *Config) Validate() error {
+	switch {
+	case c.Host == "":
+		return errors.New("host is required")
+	case c.User == "":
+		return errors.New("user is required")
+	case c.DBName == "":
+		return errors.New("name is required")
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port %q", c.Port)
+	}
+	if c.MaxOpenConns <= 0 {
+		return fmt.Errorf("max_open_conns must be positive, got %d", c.MaxOpenConns)
+	}
+	if c.MaxIdleConns < 0 || c.MaxIdleConns > c.MaxOpenConns {
+		return fmt.Errorf("max_idle_conns must be between 0 and max_open_conns (%d), got %d", c.MaxOpenConns, c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 {
+		return errors.New("conn_max_lifetime and conn_max_idle_time must not be negative")
+	}
+	if c.ConnectAttempts <= 0 {
+		return fmt.Errorf("connect_attempts must be positive, got %d", c.ConnectAttempts)
+	}
+	if c.ConnectBackoff <= 0 || c.ConnectMaxBackoff < c.ConnectBackoff {
+		return fmt.Errorf("connect_backoff must be positive and at most connect_max_backoff (%s), got %s", c.ConnectMaxBackoff, c.ConnectBackoff)
+	}
+	return nil
+}
+
+// Connect opens a connection pool and waits for the database to answer. A failed ping is
+// retried up to ConnectAttempts times with exponential backoff, so the API can start while
+// the database is still coming up; it gives up early when ctx is cancelled.
+func Connect(ctx context.Context, config *Config) (*sql.DB, error) {
 	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		config.Host, config.Port, config.User, config.Password, config.DBName)
 
@@ -39,15 +163,43 @@ func Connect(config *Config) (*sql.DB, error) {
 		return nil, fmt.Errorf("error opening database: %v", err)
 	}
 
-	// Test the connection
-	if err = db.Ping(); err != nil {
-		return nil, fmt.Errorf("error connecting to database: %v", err)
-	}
-
 	// Set connection pool settings
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	if err := ping(ctx, db, config); err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	logger.Log.Info("Database connection established")
 	return db, nil
 }
+
+// ping pings db until it answers, backing off between attempts as config says
+func ping(ctx context.Context, db *sql.DB, config *Config) error {
+	backoff := config.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= config.ConnectAttempts || ctx.Err() != nil {
+			return fmt.Errorf("error connecting to database after %d attempts: %v", attempt, err)
+		}
+
+		logger.Log.Warn("Database not reachable, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("error connecting to database after %d attempts: %v", attempt, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, config.ConnectMaxBackoff)
+	}
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+// retryConfig retries quickly, so tests do not wait on the backoff
+func retryConfig(attempts int) *Config {
+	config := DefaultConfig()
+	config.ConnectAttempts = attempts
+	config.ConnectBackoff = time.Millisecond
+	config.ConnectMaxBackoff = 2 * time.Millisecond
+	return config
+}
+
+func TestPing_RetriesUntilReachable(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+
+	refused := errors.New("connection refused")
+	mock.ExpectPing().WillReturnError(refused)
+	mock.ExpectPing().WillReturnError(refused)
+	mock.ExpectPing()
+
+	assert.NoError(t, ping(context.Background(), db, retryConfig(3)))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPing_GivesUp(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+
+	for i := 0; i < 3; i++ {
+		mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	}
+
+	err = ping(context.Background(), db, retryConfig(3))
+	assert.EqualError(t, err, "error connecting to database after 3 attempts: connection refused")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPing_StopsWhenCancelled(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+	config := retryConfig(10)
+	config.ConnectBackoff = time.Hour
+	config.ConnectMaxBackoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err = ping(ctx, db, config)
+	assert.ErrorContains(t, err, "after 1 attempts")
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := func() *Config {
+		config := DefaultConfig()
+		config.User = "coupon_user"
+		config.DBName = "coupon_db"
+		return config
+	}
+	require.NoError(t, valid().Validate())
+
+	tests := []struct {
+		name   string
+		change func(*Config)
+	}{
+		{"no user", func(c *Config) { c.User = "" }},
+		{"bad port", func(c *Config) { c.Port = "postgres" }},
+		{"no connections", func(c *Config) { c.MaxOpenConns = 0 }},
+		{"more idle than open", func(c *Config) { c.MaxIdleConns = c.MaxOpenConns + 1 }},
+		{"negative lifetime", func(c *Config) { c.ConnMaxLifetime = -time.Second }},
+		{"no attempts", func(c *Config) { c.ConnectAttempts = 0 }},
+		{"backoff above max", func(c *Config) { c.ConnectBackoff = time.Minute }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid()
+			tt.change(config)
+			assert.Error(t, config.Validate())
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package graphql
+
+import (
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// maxQueryDepth caps how deeply a query may nest fields. Coupons and claims refer to each
+// other, so without a cap one query could walk coupon.claims.coupon.claims... indefinitely.
+const maxQueryDepth = 10
+
+// queryDepth returns how deeply the operations of query nest fields, following fragments.
+// Introspection fields are not counted, as they read the static schema and the standard
+// introspection query nests deeper than any query over coupons needs to. Queries that do not
+// parse have depth 0 and are left to the executor to report.
+func queryDepth(query string) int {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return 0
+	}
+
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	depth := 0
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			depth = max(depth, selectionDepth(operation.SelectionSet, fragments, map[string]bool{}))
+		}
+	}
+	return depth
+}
+
+// selectionDepth returns the depth of set. visiting holds the fragments being expanded, so a
+// fragment spreading itself, which validation rejects later, does not recurse forever.
+func selectionDepth(set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, visiting map[string]bool) int {
+	if set == nil {
+		return 0
+	}
+
+	depth := 0
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			depth = max(depth, 1+selectionDepth(selection.SelectionSet, fragments, visiting))
+		case *ast.InlineFragment:
+			depth = max(depth, selectionDepth(selection.SelectionSet, fragments, visiting))
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := fragments[name]
+			if !ok || visiting[name] {
+				continue
+			}
+			visiting[name] = true
+			depth = max(depth, selectionDepth(fragment.SelectionSet, fragments, visiting))
+			delete(visiting, name)
+		}
+	}
+	return depth
+}
@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/handlers/rest"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+)
+
+// ErrorCodeQueryTooDeep is returned for queries nesting fields deeper than maxQueryDepth
+const ErrorCodeQueryTooDeep = "QUERY_TOO_DEEP"
+
+// problemError reports a problem as a GraphQL error. Its extensions carry the code the REST
+// API returns for the same error, and the offending fields of validation errors.
+type problemError struct {
+	problem *pkgRest.Problem
+}
+
+func (e *problemError) Error() string {
+	return e.problem.Detail
+}
+
+// Extensions implements gqlerrors.ExtendedError
+func (e *problemError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.problem.Code}
+	if len(e.problem.Errors) > 0 {
+		extensions["errors"] = e.problem.Errors
+	}
+	return extensions
+}
+
+// resolveError logs err and converts it with rest.ProblemFor, so unknown errors are reported
+// without their message
+func resolveError(ctx context.Context, err error) error {
+	var reported *problemError
+	if errors.As(err, &reported) {
+		return reported
+	}
+
+	logger.Print(ctx, logger.LevelError, err.Error())
+	return &problemError{problem: rest.ProblemFor(err)}
+}
+
+// forbidden reports a field the caller may not resolve
+func forbidden(ctx context.Context, detail string) error {
+	logger.Print(ctx, logger.LevelWarn, "Permission denied: "+detail)
+	return &problemError{problem: &pkgRest.Problem{
+		Status: http.StatusForbidden,
+		Code:   middleware.ErrorCodeForbidden,
+		Detail: detail,
+	}}
+}
+
+// requirePermission returns a forbidden error unless the caller holds perm
+func requirePermission(ctx context.Context, perm middleware.Permission) error {
+	identity, ok := middleware.IdentityFromContext(ctx)
+	if !ok || !identity.HasPermission(perm) {
+		return forbidden(ctx, "Missing permission "+string(perm))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package graphql serves coupons, their claims and the users who made them over GraphQL.
+// It shares the services, error codes and permissions of the REST API in
+// internal/handlers/rest, and batches the reads of each query level to avoid N+1 queries.
+package graphql
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/handlers/rest"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/ratelimit"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+	"github.com/wazadio/coupon-system/pkg/validation"
+)
+
+// GraphQLHandler handles GraphQL requests
+type GraphQLHandler struct {
+	service    service.CouponService
+	schema     graphql.Schema
+	store      ratelimit.Store
+	rateLimits *middleware.RateLimitConfig
+}
+
+// NewGraphQLHandler creates a new GraphQLHandler with injected service. Claims are rate
+// limited with the rules of POST /api/coupons/claim in rateLimits, taking tokens from
+// store; a nil rateLimits disables rate limiting.
+func NewGraphQLHandler(service service.CouponService, store ratelimit.Store, rateLimits *middleware.RateLimitConfig) *GraphQLHandler {
+	schema, err := newSchema()
+	if err != nil {
+		// The schema is fixed, so this is a programming error the tests catch
+		panic(fmt.Sprintf("graphql: invalid schema: %v", err))
+	}
+
+	return &GraphQLHandler{
+		service:    service,
+		schema:     schema,
+		store:      store,
+		rateLimits: rateLimits,
+	}
+}
+
+// graphQLRequest is the body of a GraphQL request. Extensions, used by some clients for
+// persisted queries, is accepted and ignored.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// ServeGraphQL handles POST /api/graphql. Requests that reach the executor get 200 with any
+// errors listed in the response, as GraphQL clients expect.
+func (h *GraphQLHandler) ServeGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+
+	// Parse request body
+	if err := pkgRest.DecodeJSON(w, r, &req); err != nil {
+		rest.RespondWithInvalidBody(w, r, err)
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		err := validation.Invalid("query", "query is required")
+		logger.Print(r.Context(), logger.LevelWarn, err.Error())
+		pkgRest.RespondWithProblem(w, rest.ProblemFor(err))
+		return
+	}
+
+	if depth := queryDepth(req.Query); depth > maxQueryDepth {
+		logger.Print(r.Context(), logger.LevelWarn, fmt.Sprintf("Query depth %d exceeds %d", depth, maxQueryDepth))
+		pkgRest.RespondWithJSON(w, http.StatusOK, &graphql.Result{
+			Errors: []gqlerrors.FormattedError{{
+				Message:    fmt.Sprintf("Query nests %d levels deep, at most %d are allowed", depth, maxQueryDepth),
+				Extensions: map[string]interface{}{"code": ErrorCodeQueryTooDeep},
+			}},
+		})
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        withRateLimiter(withLoaders(r.Context(), h.service), h.store, h.rateLimits, r),
+	})
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, result)
+}
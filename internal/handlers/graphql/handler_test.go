@@ -0,0 +1,570 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/handlers/rest"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/ratelimit"
+)
+
+// MockCouponService is a mock implementation of CouponService
+type MockCouponService struct {
+	mock.Mock
+}
+
+func (m *MockCouponService) CreateCoupon(ctx context.Context, req *models.CreateCouponRequest) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+
+func (m *MockCouponService) ClaimCoupon(ctx context.Context, req *models.ClaimCouponRequest) (*models.Claim, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Claim), args.Error(1)
+}
+
+func (m *MockCouponService) GetCouponDetails(ctx context.Context, name string) (*models.CouponDetailResponse, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CouponDetailResponse), args.Error(1)
+}
+
+func (m *MockCouponService) UpdateCoupon(ctx context.Context, name string) (int64, error) {
+	args := m.Called(name)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCouponService) ImportCoupons(ctx context.Context, rows []models.ImportCouponRow) *models.ImportCouponsResponse {
+	args := m.Called(rows)
+	return args.Get(0).(*models.ImportCouponsResponse)
+}
+
+func (m *MockCouponService) ExportCoupons(ctx context.Context, fn func(*models.Coupon) error) error {
+	args := m.Called(fn)
+	return args.Error(0)
+}
+
+func (m *MockCouponService) ExportClaims(ctx context.Context, couponName string, fn func(*models.Claim) error) error {
+	args := m.Called(couponName, fn)
+	return args.Error(0)
+}
+
+func (m *MockCouponService) ListCoupons(ctx context.Context, page models.Page) (*models.CouponPage, error) {
+	args := m.Called(page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CouponPage), args.Error(1)
+}
+
+func (m *MockCouponService) GetCouponsByNames(ctx context.Context, names []string) (map[string]*models.Coupon, error) {
+	args := m.Called(names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponService) ListClaimsByCoupons(ctx context.Context, couponNames []string, page models.Page) (map[string]*models.ClaimPage, error) {
+	args := m.Called(couponNames, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.ClaimPage), args.Error(1)
+}
+
+func (m *MockCouponService) ListClaimsByUsers(ctx context.Context, userIDs []string, page models.Page) (map[string]*models.ClaimPage, error) {
+	args := m.Called(userIDs, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.ClaimPage), args.Error(1)
+}
+
+func (m *MockCouponService) LookupCode(ctx context.Context, code string) (*models.CodeLookupResponse, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeLookupResponse), args.Error(1)
+}
+
+func (m *MockCouponService) RedeemCode(ctx context.Context, code string) (*models.CodeRedemption, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeRedemption), args.Error(1)
+}
+
+// graphQLResponse is the body of a GraphQL response
+type graphQLResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Path       []interface{}          `json:"path"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+var admin = &middleware.Identity{Roles: []string{middleware.RoleAdmin}}
+
+// execute posts query as identity and decodes the response
+func execute(t *testing.T, handler *GraphQLHandler, identity *middleware.Identity, query string, variables map[string]interface{}) graphQLResponse {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req := httptest.NewRequest(http.MethodPost, "/api/graphql", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.WithIdentity(req.Context(), identity))
+	rec := httptest.NewRecorder()
+
+	handler.ServeGraphQL(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var response graphQLResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return response
+}
+
+func TestCouponsWithClaims_GraphQL_BatchesClaims(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	claimedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	coupons := []models.Coupon{{ID: 1, Name: "A"}, {ID: 2, Name: "B"}, {ID: 3, Name: "C"}}
+	mockService.On("ListCoupons", models.Page{Limit: 3}).Return(&models.CouponPage{Coupons: coupons, HasNextPage: true}, nil)
+	mockService.On("ListClaimsByCoupons", []string{"A", "B", "C"}, models.Page{Limit: 2}).Return(map[string]*models.ClaimPage{
+		"A": {Claims: []models.Claim{{ID: 10, UserID: "user1", CouponName: "A", ClaimedAt: claimedAt}, {ID: 11, UserID: "user2", CouponName: "A", ClaimedAt: claimedAt}}, HasNextPage: true},
+		"B": {Claims: []models.Claim{{ID: 12, UserID: "user1", CouponName: "B", ClaimedAt: claimedAt}}},
+		"C": {Claims: []models.Claim{}},
+	}, nil)
+	mockService.On("GetCouponsByNames", []string{"A", "B"}).Return(map[string]*models.Coupon{
+		"A": &coupons[0], "B": &coupons[1],
+	}, nil)
+
+	response := execute(t, handler, admin, `{
+		coupons(first: 3) {
+			nodes {
+				name
+				claims(first: 2) {
+					nodes { id userId coupon { name } }
+					pageInfo { hasNextPage endCursor }
+				}
+			}
+			pageInfo { hasNextPage endCursor }
+		}
+	}`, nil)
+
+	assert.Empty(t, response.Errors)
+	connection := response.Data["coupons"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"hasNextPage": true, "endCursor": *cursorOf(3)}, connection["pageInfo"])
+
+	nodes := connection["nodes"].([]interface{})
+	require.Len(t, nodes, 3)
+	claimsOfA := nodes[0].(map[string]interface{})["claims"].(map[string]interface{})
+	assert.Len(t, claimsOfA["nodes"], 2)
+	assert.Equal(t, map[string]interface{}{"hasNextPage": true, "endCursor": *cursorOf(11)}, claimsOfA["pageInfo"])
+	claimsOfC := nodes[2].(map[string]interface{})["claims"].(map[string]interface{})
+	assert.Empty(t, claimsOfC["nodes"])
+	assert.Equal(t, map[string]interface{}{"hasNextPage": false, "endCursor": nil}, claimsOfC["pageInfo"])
+
+	// One query for the claims of every coupon, and one for the coupons of every claim
+	mockService.AssertNumberOfCalls(t, "ListClaimsByCoupons", 1)
+	mockService.AssertNumberOfCalls(t, "GetCouponsByNames", 1)
+	mockService.AssertExpectations(t)
+}
+
+func TestCoupon_GraphQL_NotFound(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	mockService.On("GetCouponsByNames", []string{"MISSING"}).Return(map[string]*models.Coupon{}, nil)
+
+	response := execute(t, handler, admin, `{ coupon(name: "MISSING") { name } }`, nil)
+
+	assert.Empty(t, response.Errors)
+	assert.Nil(t, response.Data["coupon"])
+}
+
+func TestCouponClaims_GraphQL_RequiresExport(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	customer := &middleware.Identity{Subject: "user1", Roles: []string{middleware.RoleCustomer}}
+	mockService.On("GetCouponsByNames", []string{"A"}).Return(map[string]*models.Coupon{"A": {ID: 1, Name: "A"}}, nil)
+
+	response := execute(t, handler, customer, `{ coupon(name: "A") { name claims { nodes { userId } } } }`, nil)
+
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, middleware.ErrorCodeForbidden, response.Errors[0].Extensions["code"])
+	assert.Equal(t, []interface{}{"coupon", "claims"}, response.Errors[0].Path)
+	mockService.AssertNotCalled(t, "ListClaimsByCoupons", mock.Anything, mock.Anything)
+}
+
+func TestUserClaims_GraphQL_OwnClaims(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	customer := &middleware.Identity{Subject: "user1", Roles: []string{middleware.RoleCustomer}}
+	mockService.On("ListClaimsByUsers", []string{"user1"}, models.Page{Limit: defaultPageSize}).Return(map[string]*models.ClaimPage{
+		"user1": {Claims: []models.Claim{{ID: 5, UserID: "user1", CouponName: "A", Code: "A-XYZ"}}},
+	}, nil)
+
+	response := execute(t, handler, customer, `{ user(id: "user1") { claims { nodes { couponName code } } } }`, nil)
+
+	assert.Empty(t, response.Errors)
+	claims := response.Data["user"].(map[string]interface{})["claims"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"couponName": "A", "code": "A-XYZ"}}, claims["nodes"])
+
+	// Other users' claims need coupons:export
+	response = execute(t, handler, customer, `{ user(id: "user2") { claims { nodes { couponName } } } }`, nil)
+
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, middleware.ErrorCodeForbidden, response.Errors[0].Extensions["code"])
+	mockService.AssertNumberOfCalls(t, "ListClaimsByUsers", 1)
+}
+
+func TestCoupons_GraphQL_AfterCursor(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	mockService.On("ListCoupons", models.Page{AfterID: 7, Limit: 2}).Return(&models.CouponPage{Coupons: []models.Coupon{}}, nil)
+
+	response := execute(t, handler, admin, `query($after: String) { coupons(first: 2, after: $after) { nodes { name } } }`,
+		map[string]interface{}{"after": *cursorOf(7)})
+
+	assert.Empty(t, response.Errors)
+	mockService.AssertExpectations(t)
+}
+
+func TestCoupons_GraphQL_InvalidPage(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	tests := []struct {
+		query string
+		field string
+	}{
+		{`{ coupons(first: 0) { nodes { name } } }`, "first"},
+		{`{ coupons(first: 101) { nodes { name } } }`, "first"},
+		{`{ coupons(after: "not a cursor") { nodes { name } } }`, "after"},
+	}
+
+	for _, tt := range tests {
+		response := execute(t, handler, admin, tt.query, nil)
+
+		require.Len(t, response.Errors, 1, tt.query)
+		extensions := response.Errors[0].Extensions
+		assert.Equal(t, rest.ErrorCodeValidationFailed, extensions["code"], tt.query)
+		assert.Equal(t, tt.field, extensions["errors"].([]interface{})[0].(map[string]interface{})["field"], tt.query)
+	}
+	mockService.AssertNotCalled(t, "ListCoupons", mock.Anything)
+}
+
+func TestCouponClaims_GraphQL_LoadErrorHidesMessage(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	mockService.On("GetCouponsByNames", []string{"A"}).Return(map[string]*models.Coupon{"A": {ID: 1, Name: "A"}}, nil)
+	mockService.On("ListClaimsByCoupons", []string{"A"}, models.Page{Limit: defaultPageSize}).Return(nil, errors.New("pq: connection refused"))
+
+	response := execute(t, handler, admin, `{ coupon(name: "A") { claims { nodes { id } } } }`, nil)
+
+	// Claims are resolved by a batched load, whose errors must keep their code
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, rest.ErrorCodeInternal, response.Errors[0].Extensions["code"])
+	assert.NotContains(t, response.Errors[0].Message, "pq")
+	assert.Nil(t, response.Data["coupon"])
+}
+
+func TestCreateCoupon_GraphQL_Success(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("CreateCoupon", &models.CreateCouponRequest{
+		Name:      "FLASH25",
+		Amount:    100,
+		ExpiresAt: &expiresAt,
+		EligibilityRules: models.EligibilityRules{
+			{Name: "adults", Attribute: "age", Operator: models.OperatorGte, Value: float64(18)},
+		},
+	}).Return(nil)
+	mockService.On("GetCouponsByNames", []string{"FLASH25"}).Return(map[string]*models.Coupon{
+		"FLASH25": {ID: 1, Name: "FLASH25", Amount: 100, RemainingAmount: 100, ExpiresAt: &expiresAt},
+	}, nil)
+
+	response := execute(t, handler, admin, `mutation($input: CreateCouponInput!) {
+		createCoupon(input: $input) { id name remainingAmount expiresAt }
+	}`, map[string]interface{}{"input": map[string]interface{}{
+		"name":      "FLASH25",
+		"amount":    100,
+		"expiresAt": "2030-01-01T00:00:00Z",
+		"eligibilityRules": []interface{}{
+			map[string]interface{}{"name": "adults", "attribute": "age", "operator": "gte", "value": 18},
+		},
+	}})
+
+	assert.Empty(t, response.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"id":              "1",
+		"name":            "FLASH25",
+		"remainingAmount": float64(100),
+		"expiresAt":       "2030-01-01T00:00:00Z",
+	}, response.Data["createCoupon"])
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateCoupon_GraphQL_Errors(t *testing.T) {
+	logger.Init()
+
+	tests := []struct {
+		name     string
+		identity *middleware.Identity
+		err      error
+		code     string
+	}{
+		{"already exists", admin, repository.ErrCouponAlreadyExists, rest.ErrorCodeCouponAlreadyExists},
+		{"missing permission", &middleware.Identity{Roles: []string{middleware.RoleOperator}}, nil, middleware.ErrorCodeForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCouponService)
+			handler := NewGraphQLHandler(mockService, nil, nil)
+			mockService.On("CreateCoupon", mock.Anything).Return(tt.err)
+
+			response := execute(t, handler, tt.identity, `mutation { createCoupon(input: {name: "FLASH25", amount: 1}) { name } }`, nil)
+
+			require.Len(t, response.Errors, 1)
+			assert.Equal(t, tt.code, response.Errors[0].Extensions["code"])
+			assert.Equal(t, []interface{}{"createCoupon"}, response.Errors[0].Path)
+			assert.Nil(t, response.Data)
+		})
+	}
+}
+
+func TestClaimCoupon_GraphQL_Success(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	customer := &middleware.Identity{
+		Subject:    "user1",
+		Roles:      []string{middleware.RoleCustomer},
+		Attributes: map[string]interface{}{"age": float64(21)},
+	}
+	mockService.On("ClaimCoupon", &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+		Attributes: map[string]interface{}{"age": float64(21)},
+	}).Return(&models.Claim{ID: 3, UserID: "user1", CouponName: "FLASH25", Code: "FLASH25-ABC"}, nil)
+
+	response := execute(t, handler, customer, `mutation {
+		claimCoupon(input: {couponName: "FLASH25"}) { claim { id code } lotteryEntry }
+	}`, nil)
+
+	assert.Empty(t, response.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"claim":        map[string]interface{}{"id": "3", "code": "FLASH25-ABC"},
+		"lotteryEntry": false,
+	}, response.Data["claimCoupon"])
+}
+
+func TestClaimCoupon_GraphQL_LotteryEntry(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	mockService.On("ClaimCoupon", mock.Anything).Return(&models.Claim{ID: 3, UserID: "user1", CouponName: "DRAW", Entry: true}, nil)
+
+	response := execute(t, handler, admin, `mutation {
+		claimCoupon(input: {userId: "user1", couponName: "DRAW"}) { claim { id } lotteryEntry }
+	}`, nil)
+
+	assert.Empty(t, response.Errors)
+	assert.Equal(t, map[string]interface{}{"claim": nil, "lotteryEntry": true}, response.Data["claimCoupon"])
+}
+
+func TestClaimCoupon_GraphQL_UserMismatch(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	customer := &middleware.Identity{Subject: "user1", Roles: []string{middleware.RoleCustomer}}
+
+	response := execute(t, handler, customer, `mutation {
+		claimCoupon(input: {userId: "user2", couponName: "FLASH25"}) { lotteryEntry }
+	}`, nil)
+
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, rest.ErrorCodeUserMismatch, response.Errors[0].Extensions["code"])
+	mockService.AssertNotCalled(t, "ClaimCoupon", mock.Anything)
+}
+
+func TestClaimCoupon_GraphQL_Attributes(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	mockService.On("ClaimCoupon", &models.ClaimCouponRequest{
+		UserID:     "user2",
+		CouponName: "FLASH25",
+		Attributes: map[string]interface{}{"age": float64(30)},
+	}).Return(&models.Claim{ID: 4, UserID: "user2", CouponName: "FLASH25"}, nil)
+
+	customer := &middleware.Identity{Subject: "user1", Roles: []string{middleware.RoleCustomer}}
+	response := execute(t, handler, customer, `mutation {
+		claimCoupon(input: {couponName: "FLASH25", attributes: {age: 30}}) { lotteryEntry }
+	}`, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, rest.ErrorCodeAttributesNotAllowed, response.Errors[0].Extensions["code"])
+
+	checkout := &middleware.Identity{APIKeyID: 7, Permissions: []middleware.Permission{middleware.PermCouponsClaim}}
+	response = execute(t, handler, checkout, `mutation {
+		claimCoupon(input: {userId: "user2", couponName: "FLASH25", attributes: {age: 30}}) { lotteryEntry }
+	}`, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, rest.ErrorCodeAttributesNotAllowed, response.Errors[0].Extensions["code"])
+
+	checkout.Permissions = append(checkout.Permissions, middleware.PermCouponsClaimAttributes)
+	response = execute(t, handler, checkout, `mutation {
+		claimCoupon(input: {userId: "user2", couponName: "FLASH25", attributes: {age: 30}}) { lotteryEntry }
+	}`, nil)
+	assert.Empty(t, response.Errors)
+
+	mockService.AssertNumberOfCalls(t, "ClaimCoupon", 1)
+}
+
+func TestClaimCoupon_GraphQL_RateLimited(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	config := &middleware.RateLimitConfig{Routes: map[string][]middleware.RateLimitRule{
+		"POST /api/coupons/claim": {{Key: middleware.RateLimitByUser, Requests: 3, Per: time.Minute}},
+	}}
+	store := ratelimit.NewMemoryStore()
+	handler := NewGraphQLHandler(mockService, store, config)
+
+	// A token already taken over REST counts against the same bucket
+	_, err := store.Take(context.Background(), "POST /api/coupons/claim|user|user1", ratelimit.Per(3, time.Minute))
+	require.NoError(t, err)
+
+	customer := &middleware.Identity{Subject: "user1", Roles: []string{middleware.RoleCustomer}}
+	mockService.On("ClaimCoupon", mock.Anything).Return(&models.Claim{ID: 3, UserID: "user1", CouponName: "FLASH25"}, nil)
+
+	// Every claim in a query takes a token, so batching them does not get around the limit
+	response := execute(t, handler, customer, `mutation {
+		a: claimCoupon(input: {couponName: "A"}) { lotteryEntry }
+		b: claimCoupon(input: {couponName: "B"}) { lotteryEntry }
+		c: claimCoupon(input: {couponName: "C"}) { lotteryEntry }
+	}`, nil)
+
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, middleware.ErrorCodeRateLimited, response.Errors[0].Extensions["code"])
+	assert.Equal(t, "Too many requests", response.Errors[0].Message)
+	mockService.AssertNumberOfCalls(t, "ClaimCoupon", 2)
+}
+
+func TestUpdateCoupon_GraphQL_NotFound(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	mockService.On("UpdateCoupon", "MISSING").Return(int64(0), nil)
+
+	response := execute(t, handler, admin, `mutation { updateCoupon(name: "MISSING") { name } }`, nil)
+
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, rest.ErrorCodeCouponNotFound, response.Errors[0].Extensions["code"])
+	mockService.AssertNotCalled(t, "GetCouponsByNames", mock.Anything)
+}
+
+func TestGraphQL_QueryTooDeep(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewGraphQLHandler(mockService, nil, nil)
+
+	query := `{ coupon(name: "A") { claims { nodes { coupon { claims { nodes { coupon { claims { nodes { user { claims { nodes { id } } } } } } } } } } } } }`
+
+	response := execute(t, handler, admin, query, nil)
+
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, ErrorCodeQueryTooDeep, response.Errors[0].Extensions["code"])
+	assert.Nil(t, response.Data)
+	mockService.AssertNotCalled(t, "GetCouponsByNames", mock.Anything)
+}
+
+func TestGraphQL_InvalidRequest(t *testing.T) {
+	logger.Init()
+	handler := NewGraphQLHandler(new(MockCouponService), nil, nil)
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		status      int
+		code        string
+	}{
+		{"missing query", `{"variables": {}}`, "application/json", http.StatusBadRequest, rest.ErrorCodeValidationFailed},
+		{"unknown field", `{"query": "{ coupons { nodes { name } } }", "qurey": ""}`, "application/json", http.StatusBadRequest, rest.ErrorCodeInvalidRequestBody},
+		{"not json", `query=%7B%7D`, "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType, rest.ErrorCodeUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+
+			handler.ServeGraphQL(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			var problem map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &problem)
+			assert.Equal(t, tt.code, problem["code"])
+		})
+	}
+}
+
+func TestSetupRouter_GraphQL_RequiresAuthentication(t *testing.T) {
+	logger.Init()
+	router := mux.NewRouter()
+	NewGraphQLHandler(new(MockCouponService), nil, nil).SetupRouter(router)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ coupons { nodes { name } } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
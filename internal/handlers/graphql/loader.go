@@ -0,0 +1,144 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/service"
+)
+
+// loader batches the keys requested by the fields of one query level into a single fetch.
+// load only queues a key; the first of the returned thunks to run fetches every queued key.
+// The executor runs thunks after the rest of their level, so sibling fields share a fetch
+// and a list of 20 coupons costs one claims query instead of 20. Results are cached for the
+// request.
+type loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	results map[K]loaded[V]
+}
+
+// loaded is the outcome of fetching one key. Keys the fetch did not return hold the zero value.
+type loaded[V any] struct {
+	value V
+	err   error
+}
+
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		queued:  make(map[K]bool),
+		results: make(map[K]loaded[V]),
+	}
+}
+
+// load queues key and returns a thunk yielding its value
+func (l *loader[K, V]) load(key K) func() (V, error) {
+	l.mu.Lock()
+	if _, done := l.results[key]; !done && !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, done := l.results[key]; !done {
+			l.dispatch()
+		}
+		result := l.results[key]
+		return result.value, result.err
+	}
+}
+
+// dispatch fetches every queued key. A failed fetch fails each of its keys.
+func (l *loader[K, V]) dispatch() {
+	keys := l.pending
+	l.pending = nil
+	l.queued = make(map[K]bool)
+
+	values, err := l.fetch(keys)
+	for _, key := range keys {
+		l.results[key] = loaded[V]{value: values[key], err: err}
+	}
+}
+
+// loadersContext is the context key for the loaders of a request
+type loadersContext struct{}
+
+// loaders holds the loaders of one request. Claims are paged, so there is a claims loader
+// per page a query asks for; fields asking for the same page share it.
+type loaders struct {
+	ctx     context.Context
+	service service.CouponService
+
+	coupons *loader[string, *models.Coupon]
+
+	mu           sync.Mutex
+	couponClaims map[models.Page]*loader[string, *models.ClaimPage]
+	userClaims   map[models.Page]*loader[string, *models.ClaimPage]
+}
+
+// newLoaders creates the loaders of a request. Batches are fetched with ctx, the request
+// context, as they run on behalf of every field that queued a key.
+func newLoaders(ctx context.Context, couponService service.CouponService) *loaders {
+	return &loaders{
+		ctx:     ctx,
+		service: couponService,
+		coupons: newLoader(func(names []string) (map[string]*models.Coupon, error) {
+			return couponService.GetCouponsByNames(ctx, names)
+		}),
+		couponClaims: make(map[models.Page]*loader[string, *models.ClaimPage]),
+		userClaims:   make(map[models.Page]*loader[string, *models.ClaimPage]),
+	}
+}
+
+// withLoaders returns a copy of ctx carrying fresh loaders, so nothing is cached across requests
+func withLoaders(ctx context.Context, couponService service.CouponService) context.Context {
+	return context.WithValue(ctx, loadersContext{}, newLoaders(ctx, couponService))
+}
+
+// loadersFrom returns the loaders stored by withLoaders
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersContext{}).(*loaders)
+}
+
+// coupon loads the coupon named name, nil when it does not exist
+func (l *loaders) coupon(name string) func() (*models.Coupon, error) {
+	return l.coupons.load(name)
+}
+
+// claimsOfCoupon loads page of the claims of couponName
+func (l *loaders) claimsOfCoupon(couponName string, page models.Page) func() (*models.ClaimPage, error) {
+	return l.claimsLoader(l.couponClaims, page, l.service.ListClaimsByCoupons).load(couponName)
+}
+
+// claimsOfUser loads page of the claims of userID
+func (l *loaders) claimsOfUser(userID string, page models.Page) func() (*models.ClaimPage, error) {
+	return l.claimsLoader(l.userClaims, page, l.service.ListClaimsByUsers).load(userID)
+}
+
+// claimsLoader returns the loader of byPage for page, creating it on first use
+func (l *loaders) claimsLoader(
+	byPage map[models.Page]*loader[string, *models.ClaimPage],
+	page models.Page,
+	list func(ctx context.Context, keys []string, page models.Page) (map[string]*models.ClaimPage, error),
+) *loader[string, *models.ClaimPage] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	claimsLoader, ok := byPage[page]
+	if !ok {
+		claimsLoader = newLoader(func(keys []string) (map[string]*models.ClaimPage, error) {
+			return list(l.ctx, keys, page)
+		})
+		byPage[page] = claimsLoader
+	}
+	return claimsLoader
+}
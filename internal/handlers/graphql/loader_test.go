@@ -0,0 +1,75 @@
+package graphql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader_BatchesQueuedKeys(t *testing.T) {
+	var fetches [][]string
+	l := newLoader(func(keys []string) (map[string]int, error) {
+		fetches = append(fetches, keys)
+		values := make(map[string]int, len(keys))
+		for _, key := range keys {
+			values[key] = len(key)
+		}
+		return values, nil
+	})
+
+	a, bb, aAgain := l.load("a"), l.load("bb"), l.load("a")
+
+	value, err := bb()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, value)
+	value, _ = a()
+	assert.Equal(t, 1, value)
+	value, _ = aAgain()
+	assert.Equal(t, 1, value)
+
+	// Cached keys are not fetched again
+	value, _ = l.load("a")()
+	assert.Equal(t, 1, value)
+
+	assert.Equal(t, [][]string{{"a", "bb"}}, fetches)
+}
+
+func TestLoader_MissingKeyAndErrors(t *testing.T) {
+	fetchErr := errors.New("fetch failed")
+	l := newLoader(func(keys []string) (map[string]*int, error) {
+		if keys[0] == "broken" {
+			return nil, fetchErr
+		}
+		return map[string]*int{}, nil
+	})
+
+	value, err := l.load("missing")()
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	_, err = l.load("broken")()
+	assert.Equal(t, fetchErr, err)
+}
+
+func TestQueryDepth(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		depth int
+	}{
+		{"flat", `{ coupon(name: "A") { name } }`, 2},
+		{"nested", `{ coupons { nodes { claims { nodes { id } } } } }`, 5},
+		{"fragment", `{ coupons { nodes { ...C } } } fragment C on Coupon { claims { nodes { id } } }`, 5},
+		{"inline fragment", `{ coupons { ... on CouponConnection { nodes { name } } } }`, 3},
+		{"introspection", `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`, 0},
+		{"self spread", `{ coupons { ...C } } fragment C on CouponConnection { nodes { name } ...C }`, 3},
+		{"unparsable", `{ coupons {`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.depth, queryDepth(tt.query))
+		})
+	}
+}
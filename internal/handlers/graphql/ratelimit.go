@@ -0,0 +1,61 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/pkg/ratelimit"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+)
+
+// claimRoute is the REST route whose rate limits claimCoupon shares, so claiming over
+// GraphQL does not get around them
+const claimRoute = "POST /api/coupons/claim"
+
+// rateLimiterContext is the context key for the rate limiter of a request
+type rateLimiterContext struct{}
+
+// rateLimiter applies the rate limits of REST routes to the mutations mirroring them
+type rateLimiter struct {
+	store  ratelimit.Store
+	config *middleware.RateLimitConfig
+	ip     string
+}
+
+// withRateLimiter returns a copy of ctx carrying a rate limiter for r. A nil config
+// disables rate limiting.
+func withRateLimiter(ctx context.Context, store ratelimit.Store, config *middleware.RateLimitConfig, r *http.Request) context.Context {
+	if config == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, rateLimiterContext{}, &rateLimiter{
+		store:  store,
+		config: config,
+		ip:     middleware.ClientIP(r, config.TrustForwardedFor),
+	})
+}
+
+// takeClaimRateLimit takes a token from each claimRoute rule for a claim of couponName by
+// userID. Each claimCoupon field takes its own, so a query cannot batch claims past the limits.
+func takeClaimRateLimit(ctx context.Context, couponName, userID string) error {
+	limiter, ok := ctx.Value(rateLimiterContext{}).(*rateLimiter)
+	if !ok {
+		return nil
+	}
+
+	_, allowed := middleware.TakeRateLimit(ctx, limiter.store, limiter.config, middleware.RateLimitRequest{
+		Route:  claimRoute,
+		IP:     func() string { return limiter.ip },
+		Coupon: func() string { return couponName },
+		User:   func() string { return userID },
+	})
+	if !allowed {
+		return &problemError{problem: &pkgRest.Problem{
+			Status: http.StatusTooManyRequests,
+			Code:   middleware.ErrorCodeRateLimited,
+			Detail: "Too many requests",
+		}}
+	}
+	return nil
+}
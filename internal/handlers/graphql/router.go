@@ -0,0 +1,16 @@
+package graphql
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+)
+
+// SetupRouter registers the GraphQL endpoint. Reading coupons is the least a query needs;
+// fields and mutations needing more check the caller's permissions as they resolve.
+func (h *GraphQLHandler) SetupRouter(router *mux.Router) {
+	router.Handle("/graphql", middleware.RequirePermission(middleware.PermCouponsRead)(
+		http.HandlerFunc(h.ServeGraphQL),
+	)).Methods("POST")
+}
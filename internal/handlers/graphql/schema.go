@@ -0,0 +1,629 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/handlers/rest"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/service"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+	"github.com/wazadio/coupon-system/pkg/validation"
+)
+
+// defaultPageSize is the number of items a connection returns when first is omitted
+const defaultPageSize = 20
+
+// user is the source of the User type. Users are only known by the claims they made.
+type user struct {
+	ID string
+}
+
+// claimPayload is the source of the ClaimCouponPayload type. Claim is nil for lottery entries.
+type claimPayload struct {
+	Claim        *models.Claim
+	LotteryEntry bool
+}
+
+// connection is the source of the connection types
+type connection struct {
+	Nodes    interface{}
+	PageInfo pageInfo
+}
+
+type pageInfo struct {
+	HasNextPage bool
+	EndCursor   *string
+}
+
+// jsonScalar carries arbitrary JSON, for eligibility rule values and claimant attributes
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Any JSON value.",
+	Serialize:   func(value interface{}) interface{} { return value },
+	ParseValue:  func(value interface{}) interface{} { return value },
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		return jsonLiteral(valueAST)
+	},
+})
+
+// jsonLiteral converts an inline JSON value to what encoding/json decodes it to, so a rule
+// value means the same whether it was sent inline or as a variable
+func jsonLiteral(valueAST ast.Value) interface{} {
+	switch valueAST := valueAST.(type) {
+	case *ast.StringValue:
+		return valueAST.Value
+	case *ast.BooleanValue:
+		return valueAST.Value
+	case *ast.IntValue:
+		number, _ := strconv.ParseFloat(valueAST.Value, 64)
+		return number
+	case *ast.FloatValue:
+		number, _ := strconv.ParseFloat(valueAST.Value, 64)
+		return number
+	case *ast.EnumValue:
+		return valueAST.Value
+	case *ast.ListValue:
+		values := make([]interface{}, len(valueAST.Values))
+		for i, value := range valueAST.Values {
+			values[i] = jsonLiteral(value)
+		}
+		return values
+	case *ast.ObjectValue:
+		fields := make(map[string]interface{}, len(valueAST.Fields))
+		for _, field := range valueAST.Fields {
+			fields[field.Name.Value] = jsonLiteral(field.Value)
+		}
+		return fields
+	default:
+		return nil
+	}
+}
+
+// pageArgs declares the first and after arguments of paginated fields
+var pageArgs = graphql.FieldConfigArgument{
+	"first": &graphql.ArgumentConfig{
+		Type:         graphql.Int,
+		DefaultValue: defaultPageSize,
+		Description:  "Number of items to return, at most 100.",
+	},
+	"after": &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Return the items after this cursor, the endCursor of the previous page.",
+	},
+}
+
+// newSchema builds the schema. Resolvers reach the service through the request's loaders.
+func newSchema() (graphql.Schema, error) {
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	eligibilityRuleType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "EligibilityRule",
+		Fields: graphql.Fields{
+			"name":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"attribute": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"operator":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"value":     &graphql.Field{Type: jsonScalar},
+			"order":     &graphql.Field{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		},
+	})
+
+	// Coupons, claims and users refer to each other, so their fields are declared once all exist
+	var couponType, claimType, userType, claimConnectionType *graphql.Object
+
+	couponType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Coupon",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":              &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"name":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"amount":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"remainingAmount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"uniqueCodes":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"expiresAt":       &graphql.Field{Type: graphql.DateTime},
+				"eligibilityRules": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(eligibilityRuleType))),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if rules := p.Source.(*models.Coupon).EligibilityRules; rules != nil {
+							return rules, nil
+						}
+						return models.EligibilityRules{}, nil
+					},
+				},
+				"allowlistOnly":   &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"queueMode":       &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"lotteryClosesAt": &graphql.Field{Type: graphql.DateTime},
+				"lotterySeedHash": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"createdAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"updatedAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"claims": &graphql.Field{
+					Type:        graphql.NewNonNull(claimConnectionType),
+					Args:        pageArgs,
+					Description: "Claims of the coupon in the order they were made. Requires coupons:export.",
+					Resolve:     resolveCouponClaims,
+				},
+			}
+		}),
+	})
+
+	claimType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Claim",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":         &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"userId":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"couponName": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"code": &graphql.Field{
+					Type:        graphql.String,
+					Description: "Code assigned by a coupon with unique codes.",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if code := p.Source.(*models.Claim).Code; code != "" {
+							return code, nil
+						}
+						return nil, nil
+					},
+				},
+				"claimedAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"coupon": &graphql.Field{
+					Type:    couponType,
+					Resolve: resolveCouponOfClaim,
+				},
+				"user": &graphql.Field{
+					Type: graphql.NewNonNull(userType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return &user{ID: p.Source.(*models.Claim).UserID}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"claims": &graphql.Field{
+					Type:        graphql.NewNonNull(claimConnectionType),
+					Args:        pageArgs,
+					Description: "Claims made by the user. Requires coupons:export, except for the user's own claims.",
+					Resolve:     resolveUserClaims,
+				},
+			}
+		}),
+	})
+
+	claimConnectionType = connectionType("ClaimConnection", claimType, pageInfoType)
+	couponConnectionType := connectionType("CouponConnection", couponType, pageInfoType)
+
+	claimPayloadType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ClaimCouponPayload",
+		Fields: graphql.Fields{
+			"claim": &graphql.Field{
+				Type:        claimType,
+				Description: "The claim, null when the coupon is a lottery and the claim was recorded as an entry.",
+			},
+			"lotteryEntry": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		},
+	})
+
+	eligibilityRuleInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "EligibilityRuleInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"attribute": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"operator":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"value":     &graphql.InputObjectFieldConfig{Type: jsonScalar},
+			"order":     &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		},
+	})
+
+	createCouponInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateCouponInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":             &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"amount":           &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"uniqueCodes":      &graphql.InputObjectFieldConfig{Type: graphql.Boolean, DefaultValue: false},
+			"expiresAt":        &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+			"eligibilityRules": &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(eligibilityRuleInput))},
+			"allowlistOnly":    &graphql.InputObjectFieldConfig{Type: graphql.Boolean, DefaultValue: false},
+			"queueMode":        &graphql.InputObjectFieldConfig{Type: graphql.Boolean, DefaultValue: false},
+			"lotteryClosesAt":  &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+			"lotterySeedHash":  &graphql.InputObjectFieldConfig{Type: graphql.String, DefaultValue: ""},
+		},
+	})
+
+	claimCouponInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ClaimCouponInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"userId": &graphql.InputObjectFieldConfig{
+				Type:        graphql.String,
+				Description: "Defaults to the authenticated user, who may only claim for themselves.",
+			},
+			"couponName": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"attributes": &graphql.InputObjectFieldConfig{
+				Type:        jsonScalar,
+				Description: "Only for API keys with coupons:claim_attributes. Users' attributes come from their token.",
+			},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"coupon": &graphql.Field{
+				Type: couponType,
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: resolveCoupon,
+			},
+			"coupons": &graphql.Field{
+				Type:        graphql.NewNonNull(couponConnectionType),
+				Args:        pageArgs,
+				Description: "Coupons in the order they were created. Requires coupons:export.",
+				Resolve:     resolveCoupons,
+			},
+			"user": &graphql.Field{
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: resolveUser,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createCoupon": &graphql.Field{
+				Type: graphql.NewNonNull(couponType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createCouponInput)},
+				},
+				Resolve: resolveCreateCoupon,
+			},
+			"claimCoupon": &graphql.Field{
+				Type: graphql.NewNonNull(claimPayloadType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(claimCouponInput)},
+				},
+				Resolve: resolveClaimCoupon,
+			},
+			"updateCoupon": &graphql.Field{
+				Type: graphql.NewNonNull(couponType),
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: resolveUpdateCoupon,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// connectionType declares a page of nodes and where it ends
+func connectionType(name string, nodeType, pageInfoType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		Fields: graphql.Fields{
+			"nodes":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(nodeType)))},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+		},
+	})
+}
+
+func resolveCoupon(p graphql.ResolveParams) (interface{}, error) {
+	load := loadersFrom(p.Context).coupon(p.Args["name"].(string))
+	return thunk(p.Context, func() (interface{}, error) {
+		return load()
+	}), nil
+}
+
+func resolveCoupons(p graphql.ResolveParams) (interface{}, error) {
+	if err := requirePermission(p.Context, middleware.PermCouponsExport); err != nil {
+		return nil, err
+	}
+
+	page, err := pageOf(p.Args)
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+
+	coupons, err := loadersFrom(p.Context).service.ListCoupons(p.Context, page)
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+
+	nodes := make([]*models.Coupon, len(coupons.Coupons))
+	for i := range coupons.Coupons {
+		nodes[i] = &coupons.Coupons[i]
+	}
+
+	result := &connection{Nodes: nodes, PageInfo: pageInfo{HasNextPage: coupons.HasNextPage}}
+	if len(nodes) > 0 {
+		result.PageInfo.EndCursor = cursorOf(nodes[len(nodes)-1].ID)
+	}
+	return result, nil
+}
+
+func resolveUser(p graphql.ResolveParams) (interface{}, error) {
+	id := p.Args["id"].(string)
+	if id == "" {
+		return nil, resolveError(p.Context, validation.Invalid("id", "id is required"))
+	}
+	return &user{ID: id}, nil
+}
+
+func resolveCouponClaims(p graphql.ResolveParams) (interface{}, error) {
+	if err := requirePermission(p.Context, middleware.PermCouponsExport); err != nil {
+		return nil, err
+	}
+
+	page, err := pageOf(p.Args)
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+
+	load := loadersFrom(p.Context).claimsOfCoupon(p.Source.(*models.Coupon).Name, page)
+	return thunk(p.Context, func() (interface{}, error) {
+		claims, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return claimConnection(claims), nil
+	}), nil
+}
+
+func resolveUserClaims(p graphql.ResolveParams) (interface{}, error) {
+	userID := p.Source.(*user).ID
+
+	// Users may read their own claims
+	identity, ok := middleware.IdentityFromContext(p.Context)
+	if !ok || identity.Subject == "" || identity.Subject != userID {
+		if err := requirePermission(p.Context, middleware.PermCouponsExport); err != nil {
+			return nil, err
+		}
+	}
+
+	page, err := pageOf(p.Args)
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+
+	load := loadersFrom(p.Context).claimsOfUser(userID, page)
+	return thunk(p.Context, func() (interface{}, error) {
+		claims, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return claimConnection(claims), nil
+	}), nil
+}
+
+func resolveCouponOfClaim(p graphql.ResolveParams) (interface{}, error) {
+	load := loadersFrom(p.Context).coupon(p.Source.(*models.Claim).CouponName)
+	return thunk(p.Context, func() (interface{}, error) {
+		return load()
+	}), nil
+}
+
+func resolveCreateCoupon(p graphql.ResolveParams) (interface{}, error) {
+	if err := requirePermission(p.Context, middleware.PermCouponsCreate); err != nil {
+		return nil, err
+	}
+
+	input := p.Args["input"].(map[string]interface{})
+	req := &models.CreateCouponRequest{
+		Name:             input["name"].(string),
+		Amount:           input["amount"].(int),
+		UniqueCodes:      input["uniqueCodes"].(bool),
+		ExpiresAt:        timeOf(input["expiresAt"]),
+		EligibilityRules: eligibilityRulesOf(input["eligibilityRules"]),
+		AllowlistOnly:    input["allowlistOnly"].(bool),
+		QueueMode:        input["queueMode"].(bool),
+		LotteryClosesAt:  timeOf(input["lotteryClosesAt"]),
+		LotterySeedHash:  input["lotterySeedHash"].(string),
+	}
+
+	couponService := loadersFrom(p.Context).service
+	if err := couponService.CreateCoupon(p.Context, req); err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+	return fetchCoupon(p.Context, couponService, req.Name)
+}
+
+func resolveClaimCoupon(p graphql.ResolveParams) (interface{}, error) {
+	if err := requirePermission(p.Context, middleware.PermCouponsClaim); err != nil {
+		return nil, err
+	}
+
+	input := p.Args["input"].(map[string]interface{})
+	req := &models.ClaimCouponRequest{CouponName: input["couponName"].(string)}
+	if userID, ok := input["userId"].(string); ok {
+		req.UserID = userID
+	}
+	if attributes, ok := input["attributes"].(map[string]interface{}); ok {
+		req.Attributes = attributes
+	}
+
+	switch err := middleware.AuthorizeClaim(p.Context, req); {
+	case errors.Is(err, middleware.ErrUserMismatch):
+		return nil, &problemError{problem: &pkgRest.Problem{
+			Status: http.StatusForbidden,
+			Code:   rest.ErrorCodeUserMismatch,
+			Detail: "user_id does not match the authenticated user",
+		}}
+	case errors.Is(err, middleware.ErrAttributesNotAllowed):
+		return nil, &problemError{problem: &pkgRest.Problem{
+			Status: http.StatusForbidden,
+			Code:   rest.ErrorCodeAttributesNotAllowed,
+			Detail: "attributes cannot be sent by this caller",
+		}}
+	case errors.Is(err, middleware.ErrCouponOutOfScope):
+		return nil, forbidden(p.Context, "Coupon is outside this API key's scope")
+	}
+
+	if err := takeClaimRateLimit(p.Context, req.CouponName, req.UserID); err != nil {
+		return nil, err
+	}
+
+	claim, err := loadersFrom(p.Context).service.ClaimCoupon(p.Context, req)
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+
+	if claim.Entry {
+		return &claimPayload{LotteryEntry: true}, nil
+	}
+	return &claimPayload{Claim: claim}, nil
+}
+
+func resolveUpdateCoupon(p graphql.ResolveParams) (interface{}, error) {
+	if err := requirePermission(p.Context, middleware.PermCouponsUpdate); err != nil {
+		return nil, err
+	}
+
+	name := p.Args["name"].(string)
+	couponService := loadersFrom(p.Context).service
+	rowsAffected, err := couponService.UpdateCoupon(p.Context, name)
+	if err != nil {
+		return nil, resolveError(p.Context, err)
+	}
+	if rowsAffected == 0 {
+		return nil, resolveError(p.Context, repository.ErrCouponNotFound)
+	}
+	return fetchCoupon(p.Context, couponService, name)
+}
+
+// fetchCoupon reads a coupon a mutation just wrote. The loaders are bypassed, as they may
+// hold the coupon from before the write.
+func fetchCoupon(ctx context.Context, couponService service.CouponService, name string) (interface{}, error) {
+	coupons, err := couponService.GetCouponsByNames(ctx, []string{name})
+	if err != nil {
+		return nil, resolveError(ctx, err)
+	}
+	coupon, ok := coupons[name]
+	if !ok {
+		return nil, resolveError(ctx, repository.ErrCouponNotFound)
+	}
+	return coupon, nil
+}
+
+// thunk defers fn until the executor has resolved the rest of the field's level, so the
+// loads it waits on are batched. The executor drops the extensions of errors a thunk returns
+// but keeps those it panics with, so errors are raised by panicking.
+func thunk(ctx context.Context, fn func() (interface{}, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		value, err := fn()
+		if err != nil {
+			panic(resolveError(ctx, err))
+		}
+		return value, nil
+	}
+}
+
+// claimConnection converts a page of claims to a connection
+func claimConnection(claims *models.ClaimPage) *connection {
+	nodes := make([]*models.Claim, len(claims.Claims))
+	for i := range claims.Claims {
+		nodes[i] = &claims.Claims[i]
+	}
+
+	result := &connection{Nodes: nodes, PageInfo: pageInfo{HasNextPage: claims.HasNextPage}}
+	if len(nodes) > 0 {
+		result.PageInfo.EndCursor = cursorOf(int64(nodes[len(nodes)-1].ID))
+	}
+	return result
+}
+
+// pageOf reads the first and after arguments of a paginated field
+func pageOf(args map[string]interface{}) (models.Page, error) {
+	first, _ := args["first"].(int)
+	if first < 1 || first > service.MaxPageSize {
+		return models.Page{}, validation.Invalid("first", "first must be between 1 and %d", service.MaxPageSize)
+	}
+
+	page := models.Page{Limit: first}
+	if after, ok := args["after"].(string); ok {
+		afterID, err := idOfCursor(after)
+		if err != nil {
+			return models.Page{}, validation.Invalid("after", "after is not a valid cursor")
+		}
+		page.AfterID = afterID
+	}
+	return page, nil
+}
+
+// cursorOf returns the opaque cursor of the item with id. Clients must not build cursors,
+// so they are encoded even though they only hold the ID.
+func cursorOf(id int64) *string {
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+	return &cursor
+}
+
+// idOfCursor returns the ID held by a cursor from cursorOf
+func idOfCursor(cursor string) (int64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(string(decoded), 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return id, nil
+}
+
+// timeOf returns the value of an optional DateTime argument
+func timeOf(value interface{}) *time.Time {
+	t, ok := value.(time.Time)
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+// eligibilityRulesOf converts the eligibilityRules argument of createCoupon
+func eligibilityRulesOf(value interface{}) models.EligibilityRules {
+	inputs, _ := value.([]interface{})
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	rules := make(models.EligibilityRules, len(inputs))
+	for i, input := range inputs {
+		fields := input.(map[string]interface{})
+		rule := models.EligibilityRule{
+			Name:      fields["name"].(string),
+			Attribute: fields["attribute"].(string),
+			Value:     fields["value"],
+		}
+		if operator, ok := fields["operator"].(string); ok {
+			rule.Operator = operator
+		}
+		if order, ok := fields["order"].([]interface{}); ok {
+			for _, rank := range order {
+				rule.Order = append(rule.Order, rank.(string))
+			}
+		}
+		rules[i] = rule
+	}
+	return rules
+}
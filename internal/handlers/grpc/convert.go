@@ -0,0 +1,140 @@
+package grpc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wazadio/coupon-system/internal/models"
+	couponv1 "github.com/wazadio/coupon-system/pkg/pb/coupon/v1"
+	"github.com/wazadio/coupon-system/pkg/validation"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toCreateCouponRequest converts req to the model the service validates. Only the
+// timestamps are checked here, as the model has no way to hold an invalid one.
+func toCreateCouponRequest(req *couponv1.CreateCouponRequest) (*models.CreateCouponRequest, error) {
+	var errs validation.Errors
+
+	createReq := &models.CreateCouponRequest{
+		Name:             req.GetName(),
+		Amount:           int(req.GetAmount()),
+		UniqueCodes:      req.GetUniqueCodes(),
+		ExpiresAt:        toTime(&errs, "expires_at", req.GetExpiresAt()),
+		EligibilityRules: toEligibilityRules(req.GetEligibilityRules()),
+		AllowlistOnly:    req.GetAllowlistOnly(),
+		QueueMode:        req.GetQueueMode(),
+		LotteryClosesAt:  toTime(&errs, "lottery_closes_at", req.GetLotteryClosesAt()),
+		LotterySeedHash:  req.GetLotterySeedHash(),
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return createReq, nil
+}
+
+// toTime converts an optional timestamp, recording a violation of field when it is out of range
+func toTime(errs *validation.Errors, field string, ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	if err := ts.CheckValid(); err != nil {
+		errs.Add(field, "%s is not a valid timestamp", field)
+		return nil
+	}
+
+	t := ts.AsTime()
+	return &t
+}
+
+func toEligibilityRules(rules []*couponv1.EligibilityRule) models.EligibilityRules {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	converted := make(models.EligibilityRules, len(rules))
+	for i, rule := range rules {
+		converted[i] = models.EligibilityRule{
+			Name:      rule.GetName(),
+			Attribute: rule.GetAttribute(),
+			Operator:  rule.GetOperator(),
+			Order:     rule.GetOrder(),
+		}
+		// Values decode to the same types as JSON, so rules behave alike over both transports
+		if rule.GetValue() != nil {
+			converted[i].Value = rule.GetValue().AsInterface()
+		}
+	}
+	return converted
+}
+
+func fromEligibilityRules(rules models.EligibilityRules) ([]*couponv1.EligibilityRule, error) {
+	converted := make([]*couponv1.EligibilityRule, len(rules))
+	for i, rule := range rules {
+		value, err := structpb.NewValue(rule.Value)
+		if err != nil {
+			return nil, fmt.Errorf("error converting value of eligibility rule %q: %v", rule.Name, err)
+		}
+
+		converted[i] = &couponv1.EligibilityRule{
+			Name:      rule.Name,
+			Attribute: rule.Attribute,
+			Operator:  rule.Operator,
+			Value:     value,
+			Order:     rule.Order,
+		}
+	}
+	return converted, nil
+}
+
+func fromTime(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func fromCoupon(coupon *models.Coupon) (*couponv1.Coupon, error) {
+	rules, err := fromEligibilityRules(coupon.EligibilityRules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &couponv1.Coupon{
+		Id:               coupon.ID,
+		Name:             coupon.Name,
+		Amount:           int32(coupon.Amount),
+		RemainingAmount:  int32(coupon.RemainingAmount),
+		UniqueCodes:      coupon.UniqueCodes,
+		ExpiresAt:        fromTime(coupon.ExpiresAt),
+		EligibilityRules: rules,
+		AllowlistOnly:    coupon.AllowlistOnly,
+		QueueMode:        coupon.QueueMode,
+		LotteryClosesAt:  fromTime(coupon.LotteryClosesAt),
+		LotterySeedHash:  coupon.LotterySeedHash,
+		CreatedAt:        timestamppb.New(coupon.CreatedAt),
+		UpdatedAt:        timestamppb.New(coupon.UpdatedAt),
+	}, nil
+}
+
+func fromCouponDetails(details *models.CouponDetailResponse) (*couponv1.GetCouponResponse, error) {
+	rules, err := fromEligibilityRules(details.EligibilityRules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &couponv1.GetCouponResponse{
+		Name:             details.Name,
+		Amount:           int32(details.Amount),
+		RemainingAmount:  int32(details.RemainingAmount),
+		UniqueCodes:      details.UniqueCodes,
+		ExpiresAt:        fromTime(details.ExpiresAt),
+		EligibilityRules: rules,
+		AllowlistOnly:    details.AllowlistOnly,
+		QueueMode:        details.QueueMode,
+		LotteryClosesAt:  fromTime(details.LotteryClosesAt),
+		LotterySeedHash:  details.LotterySeedHash,
+		ClaimedBy:        details.ClaimedBy,
+	}, nil
+}
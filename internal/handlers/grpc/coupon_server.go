@@ -0,0 +1,127 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/handlers/rest"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	couponv1 "github.com/wazadio/coupon-system/pkg/pb/coupon/v1"
+	"google.golang.org/grpc/codes"
+)
+
+// CouponServer serves couponv1.CouponService with the same service.CouponService the
+// REST handlers use
+type CouponServer struct {
+	couponv1.UnimplementedCouponServiceServer
+	service service.CouponService
+}
+
+// NewCouponServer creates a new CouponServer with injected service
+func NewCouponServer(couponService service.CouponService) *CouponServer {
+	return &CouponServer{
+		service: couponService,
+	}
+}
+
+// CreateCoupon handles coupon.v1.CouponService/CreateCoupon
+func (s *CouponServer) CreateCoupon(ctx context.Context, req *couponv1.CreateCouponRequest) (*couponv1.CreateCouponResponse, error) {
+	createReq, err := toCreateCouponRequest(req)
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+
+	if err := s.service.CreateCoupon(ctx, createReq); err != nil {
+		return nil, statusFor(ctx, err)
+	}
+
+	return &couponv1.CreateCouponResponse{}, nil
+}
+
+// ClaimCoupon handles coupon.v1.CouponService/ClaimCoupon
+func (s *CouponServer) ClaimCoupon(ctx context.Context, req *couponv1.ClaimCouponRequest) (*couponv1.ClaimCouponResponse, error) {
+	claimReq := &models.ClaimCouponRequest{
+		UserID:     req.GetUserId(),
+		CouponName: req.GetCouponName(),
+	}
+	if req.GetAttributes() != nil {
+		claimReq.Attributes = req.GetAttributes().AsMap()
+	}
+
+	if err := authorizeClaimant(ctx, claimReq); err != nil {
+		return nil, err
+	}
+
+	claim, err := s.service.ClaimCoupon(ctx, claimReq)
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+
+	return &couponv1.ClaimCouponResponse{Code: claim.Code, LotteryEntry: claim.Entry}, nil
+}
+
+// authorizeClaimant applies middleware.AuthorizeClaim, as the REST claim handler does
+func authorizeClaimant(ctx context.Context, req *models.ClaimCouponRequest) error {
+	switch err := middleware.AuthorizeClaim(ctx, req); {
+	case errors.Is(err, middleware.ErrUserMismatch):
+		logger.Print(ctx, logger.LevelError, "user_id does not match the authenticated user")
+		return errorWithInfo(codes.PermissionDenied, rest.ErrorCodeUserMismatch, "user_id does not match the authenticated user")
+	case errors.Is(err, middleware.ErrAttributesNotAllowed):
+		logger.Print(ctx, logger.LevelError, "attributes cannot be sent by this caller")
+		return errorWithInfo(codes.PermissionDenied, rest.ErrorCodeAttributesNotAllowed, "attributes cannot be sent by this caller")
+	case errors.Is(err, middleware.ErrCouponOutOfScope):
+		logger.Print(ctx, logger.LevelError, "Coupon is outside this API key's scope")
+		return errorWithInfo(codes.PermissionDenied, middleware.ErrorCodeForbidden, "Coupon is outside this API key's scope")
+	}
+	return nil
+}
+
+// GetCoupon handles coupon.v1.CouponService/GetCoupon
+func (s *CouponServer) GetCoupon(ctx context.Context, req *couponv1.GetCouponRequest) (*couponv1.GetCouponResponse, error) {
+	details, err := s.service.GetCouponDetails(ctx, req.GetName())
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+
+	response, err := fromCouponDetails(details)
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	return response, nil
+}
+
+// UpdateCoupon handles coupon.v1.CouponService/UpdateCoupon
+func (s *CouponServer) UpdateCoupon(ctx context.Context, req *couponv1.UpdateCouponRequest) (*couponv1.UpdateCouponResponse, error) {
+	rowsAffected, err := s.service.UpdateCoupon(ctx, req.GetName())
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+
+	return &couponv1.UpdateCouponResponse{RowsAffected: rowsAffected}, nil
+}
+
+// ListCoupons handles coupon.v1.CouponService/ListCoupons, sending coupons as they are
+// read so the whole table is never held in memory
+func (s *CouponServer) ListCoupons(req *couponv1.ListCouponsRequest, stream couponv1.CouponService_ListCouponsServer) error {
+	ctx := stream.Context()
+
+	err := s.service.ExportCoupons(ctx, func(coupon *models.Coupon) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		msg, err := fromCoupon(coupon)
+		if err != nil {
+			return err
+		}
+		return stream.Send(msg)
+	})
+	if err != nil {
+		return statusFor(ctx, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,545 @@
+package grpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/jwt"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	couponv1 "github.com/wazadio/coupon-system/pkg/pb/coupon/v1"
+	"github.com/wazadio/coupon-system/pkg/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MockCouponService is a mock implementation of CouponService
+type MockCouponService struct {
+	mock.Mock
+}
+
+func (m *MockCouponService) CreateCoupon(ctx context.Context, req *models.CreateCouponRequest) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+
+func (m *MockCouponService) ClaimCoupon(ctx context.Context, req *models.ClaimCouponRequest) (*models.Claim, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Claim), args.Error(1)
+}
+
+func (m *MockCouponService) GetCouponDetails(ctx context.Context, name string) (*models.CouponDetailResponse, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CouponDetailResponse), args.Error(1)
+}
+
+func (m *MockCouponService) UpdateCoupon(ctx context.Context, name string) (int64, error) {
+	args := m.Called(name)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCouponService) ImportCoupons(ctx context.Context, rows []models.ImportCouponRow) *models.ImportCouponsResponse {
+	args := m.Called(rows)
+	return args.Get(0).(*models.ImportCouponsResponse)
+}
+
+func (m *MockCouponService) ExportCoupons(ctx context.Context, fn func(*models.Coupon) error) error {
+	args := m.Called(fn)
+	return args.Error(0)
+}
+
+func (m *MockCouponService) ExportClaims(ctx context.Context, couponName string, fn func(*models.Claim) error) error {
+	args := m.Called(couponName, fn)
+	return args.Error(0)
+}
+
+func (m *MockCouponService) ListCoupons(ctx context.Context, page models.Page) (*models.CouponPage, error) {
+	args := m.Called(page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CouponPage), args.Error(1)
+}
+
+func (m *MockCouponService) GetCouponsByNames(ctx context.Context, names []string) (map[string]*models.Coupon, error) {
+	args := m.Called(names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponService) ListClaimsByCoupons(ctx context.Context, couponNames []string, page models.Page) (map[string]*models.ClaimPage, error) {
+	args := m.Called(couponNames, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.ClaimPage), args.Error(1)
+}
+
+func (m *MockCouponService) ListClaimsByUsers(ctx context.Context, userIDs []string, page models.Page) (map[string]*models.ClaimPage, error) {
+	args := m.Called(userIDs, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.ClaimPage), args.Error(1)
+}
+
+func (m *MockCouponService) LookupCode(ctx context.Context, code string) (*models.CodeLookupResponse, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeLookupResponse), args.Error(1)
+}
+
+func (m *MockCouponService) RedeemCode(ctx context.Context, code string) (*models.CodeRedemption, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeRedemption), args.Error(1)
+}
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// stubAPIKeys accepts a single key, restricted to FLASH25
+type stubAPIKeys struct{}
+
+func (stubAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	if key != "cpk_abc_secret" {
+		return nil, errors.New("invalid api key")
+	}
+	return &models.APIKey{
+		ID:          7,
+		Scopes:      []string{string(middleware.PermCouponsClaim), string(middleware.PermCouponsRead), string(middleware.PermCouponsExport)},
+		CouponNames: []string{"FLASH25"},
+	}, nil
+}
+
+// newTestClient serves couponService on an in-process listener. A nil verifier
+// disables authentication, as AUTH_DISABLED=true does.
+func newTestClient(t *testing.T, couponService service.CouponService, verifier *jwt.Verifier) couponv1.CouponServiceClient {
+	return dialServer(t, NewServer(couponService, verifier, stubAPIKeys{}, nil, nil))
+}
+
+// dialServer serves server on an in-process listener and returns a client connected to it
+func dialServer(t *testing.T, server *grpc.Server) couponv1.CouponServiceClient {
+	logger.Init()
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return couponv1.NewCouponServiceClient(conn)
+}
+
+func testVerifier() *jwt.Verifier {
+	return &jwt.Verifier{Keys: []jwt.Key{{Algorithm: jwt.AlgHS256, HMACSecret: testSecret}}}
+}
+
+// withToken returns a context sending a bearer token for subject with roles
+func withToken(t *testing.T, subject string, roles ...string) context.Context {
+	return withClaims(t, map[string]interface{}{"sub": subject, "roles": roles})
+}
+
+// withClaims returns a context sending a bearer token with claims, valid for an hour
+func withClaims(t *testing.T, claims map[string]interface{}) context.Context {
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	input := segment(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + segment(claims)
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(input))
+	token := input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// errorInfoReason returns the reason of the ErrorInfo detail of err
+func errorInfoReason(t *testing.T, err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			assert.Equal(t, ErrorDomain, info.Domain)
+			return info.Reason
+		}
+	}
+	t.Errorf("%v has no ErrorInfo detail", err)
+	return ""
+}
+
+func TestCreateCoupon_GRPC_Success(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, nil)
+
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	value, err := structpb.NewValue(18)
+	require.NoError(t, err)
+
+	mockService.On("CreateCoupon", &models.CreateCouponRequest{
+		Name:      "FLASH25",
+		Amount:    100,
+		ExpiresAt: &expiresAt,
+		EligibilityRules: models.EligibilityRules{
+			{Name: "adults", Attribute: "age", Operator: "gte", Value: float64(18)},
+		},
+	}).Return(nil)
+
+	_, err = client.CreateCoupon(context.Background(), &couponv1.CreateCouponRequest{
+		Name:      "FLASH25",
+		Amount:    100,
+		ExpiresAt: timestamppb.New(expiresAt),
+		EligibilityRules: []*couponv1.EligibilityRule{
+			{Name: "adults", Attribute: "age", Operator: "gte", Value: value},
+		},
+	})
+
+	assert.NoError(t, err)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateCoupon_GRPC_ValidationFailed(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, nil)
+
+	mockService.On("CreateCoupon", mock.Anything).Return(validation.Errors{
+		{Field: "name", Message: "name is required"},
+		{Field: "amount", Message: "amount must be at least 1"},
+	})
+
+	_, err := client.CreateCoupon(context.Background(), &couponv1.CreateCouponRequest{})
+
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "name is required; amount must be at least 1", st.Message())
+	assert.Equal(t, "VALIDATION_FAILED", errorInfoReason(t, err))
+
+	var fields []string
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				fields = append(fields, violation.Field)
+			}
+		}
+	}
+	assert.Equal(t, []string{"name", "amount"}, fields)
+}
+
+func TestCreateCoupon_GRPC_InvalidTimestamp(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, nil)
+
+	_, err := client.CreateCoupon(context.Background(), &couponv1.CreateCouponRequest{
+		Name:      "FLASH25",
+		Amount:    1,
+		ExpiresAt: &timestamppb.Timestamp{Seconds: 1, Nanos: -1},
+	})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "expires_at is not a valid timestamp", status.Convert(err).Message())
+	mockService.AssertNotCalled(t, "CreateCoupon", mock.Anything)
+}
+
+func TestClaimCoupon_GRPC_Success(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, nil)
+
+	attributes, err := structpb.NewStruct(map[string]interface{}{"country": "ID"})
+	require.NoError(t, err)
+
+	mockService.On("ClaimCoupon", &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+		Attributes: map[string]interface{}{"country": "ID"},
+	}).Return(&models.Claim{Code: "FLASH25-ABCD"}, nil)
+
+	resp, err := client.ClaimCoupon(context.Background(), &couponv1.ClaimCouponRequest{
+		UserId:     "user1",
+		CouponName: "FLASH25",
+		Attributes: attributes,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "FLASH25-ABCD", resp.GetCode())
+	assert.False(t, resp.GetLotteryEntry())
+}
+
+func TestClaimCoupon_GRPC_SentinelErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{"not found", repository.ErrCouponNotFound, codes.NotFound, "COUPON_NOT_FOUND"},
+		{"already claimed", repository.ErrAlreadyClaimed, codes.AlreadyExists, "COUPON_ALREADY_CLAIMED"},
+		{"sold out", repository.ErrNoStockAvailable, codes.FailedPrecondition, "COUPON_SOLD_OUT"},
+		{"expired", repository.ErrCouponExpired, codes.FailedPrecondition, "COUPON_EXPIRED"},
+		{"denied", repository.ErrUserDenied, codes.PermissionDenied, "USER_DENIED"},
+		{"not eligible", &service.EligibilityError{Rule: "adults"}, codes.PermissionDenied, "USER_NOT_ELIGIBLE"},
+		{"queue mode", service.ErrQueueRequired, codes.FailedPrecondition, "QUEUE_REQUIRED"},
+		{"unknown", errors.New("pq: connection refused"), codes.Internal, "INTERNAL_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCouponService)
+			client := newTestClient(t, mockService, nil)
+			mockService.On("ClaimCoupon", mock.Anything).Return(nil, tt.err)
+
+			_, err := client.ClaimCoupon(context.Background(), &couponv1.ClaimCouponRequest{UserId: "user1", CouponName: "FLASH25"})
+
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Equal(t, tt.reason, errorInfoReason(t, err))
+			assert.NotContains(t, status.Convert(err).Message(), "pq:")
+		})
+	}
+}
+
+func TestClaimCoupon_GRPC_ClaimsForAuthenticatedUser(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, testVerifier())
+
+	mockService.On("ClaimCoupon", &models.ClaimCouponRequest{UserID: "user1", CouponName: "FLASH25"}).
+		Return(&models.Claim{Entry: true}, nil)
+
+	ctx := withToken(t, "user1", middleware.RoleCustomer)
+	resp, err := client.ClaimCoupon(ctx, &couponv1.ClaimCouponRequest{CouponName: "FLASH25"})
+	assert.NoError(t, err)
+	assert.True(t, resp.GetLotteryEntry())
+
+	_, err = client.ClaimCoupon(ctx, &couponv1.ClaimCouponRequest{UserId: "user2", CouponName: "FLASH25"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "USER_ID_MISMATCH", errorInfoReason(t, err))
+	mockService.AssertNumberOfCalls(t, "ClaimCoupon", 1)
+}
+
+func TestClaimCoupon_GRPC_AttributesFromToken(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, testVerifier())
+
+	mockService.On("ClaimCoupon", &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+		Attributes: map[string]interface{}{"tier": "silver"},
+	}).Return(&models.Claim{Code: "FLASH25-ABCD"}, nil)
+
+	ctx := withClaims(t, map[string]interface{}{
+		"sub":        "user1",
+		"roles":      []string{middleware.RoleCustomer},
+		"attributes": map[string]interface{}{"tier": "silver"},
+	})
+	_, err := client.ClaimCoupon(ctx, &couponv1.ClaimCouponRequest{CouponName: "FLASH25"})
+	assert.NoError(t, err)
+
+	attributes, err := structpb.NewStruct(map[string]interface{}{"tier": "gold"})
+	require.NoError(t, err)
+	_, err = client.ClaimCoupon(ctx, &couponv1.ClaimCouponRequest{CouponName: "FLASH25", Attributes: attributes})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "ATTRIBUTES_NOT_ALLOWED", errorInfoReason(t, err))
+	mockService.AssertNumberOfCalls(t, "ClaimCoupon", 1)
+}
+
+func TestGetCoupon_GRPC(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, nil)
+
+	mockService.On("GetCouponDetails", "FLASH25").Return(&models.CouponDetailResponse{
+		Name:            "FLASH25",
+		Amount:          100,
+		RemainingAmount: 98,
+		EligibilityRules: models.EligibilityRules{
+			{Name: "tiers", Attribute: "tier", Operator: "in", Value: []interface{}{"gold", "silver"}},
+		},
+		ClaimedBy: []string{"user1", "user2"},
+	}, nil)
+	mockService.On("GetCouponDetails", "MISSING").Return(nil, repository.ErrCouponNotFound)
+
+	resp, err := client.GetCoupon(context.Background(), &couponv1.GetCouponRequest{Name: "FLASH25"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(98), resp.GetRemainingAmount())
+	assert.Equal(t, []string{"user1", "user2"}, resp.GetClaimedBy())
+	assert.Nil(t, resp.GetExpiresAt())
+	assert.Equal(t, []interface{}{"gold", "silver"}, resp.GetEligibilityRules()[0].GetValue().AsInterface())
+
+	_, err = client.GetCoupon(context.Background(), &couponv1.GetCouponRequest{Name: "MISSING"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "Coupon not found", status.Convert(err).Message())
+}
+
+func TestUpdateCoupon_GRPC(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, nil)
+
+	mockService.On("UpdateCoupon", "FLASH25").Return(int64(1), nil)
+
+	resp, err := client.UpdateCoupon(context.Background(), &couponv1.UpdateCouponRequest{Name: "FLASH25"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.GetRowsAffected())
+}
+
+func TestListCoupons_GRPC_Streams(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, nil)
+
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("ExportCoupons", mock.Anything).Run(func(args mock.Arguments) {
+		fn := args.Get(0).(func(*models.Coupon) error)
+		fn(&models.Coupon{ID: 1, Name: "FLASH25", Amount: 10, CreatedAt: createdAt})
+		fn(&models.Coupon{ID: 2, Name: "WELCOME", Amount: 5, QueueMode: true, CreatedAt: createdAt})
+	}).Return(nil)
+
+	stream, err := client.ListCoupons(context.Background(), &couponv1.ListCouponsRequest{})
+	require.NoError(t, err)
+
+	var names []string
+	for {
+		coupon, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, coupon.GetName())
+		assert.Equal(t, createdAt, coupon.GetCreatedAt().AsTime())
+	}
+	assert.Equal(t, []string{"FLASH25", "WELCOME"}, names)
+}
+
+func TestListCoupons_GRPC_Error(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, nil)
+
+	mockService.On("ExportCoupons", mock.Anything).Return(errors.New("error exporting coupons: pq: timeout"))
+
+	stream, err := client.ListCoupons(context.Background(), &couponv1.ListCouponsRequest{})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "An unexpected error occurred", status.Convert(err).Message())
+}
+
+func TestAuth_GRPC(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, testVerifier())
+
+	mockService.On("GetCouponDetails", "FLASH25").Return(&models.CouponDetailResponse{Name: "FLASH25"}, nil)
+	mockService.On("CreateCoupon", mock.Anything).Return(nil)
+
+	apiKey := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "cpk_abc_secret")
+	badKey := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "cpk_abc_wrong")
+	badToken := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer not.a.token")
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		call   func(ctx context.Context) error
+		code   codes.Code
+		reason string
+	}{
+		{"no credentials", context.Background(), getCoupon(client, "FLASH25"), codes.Unauthenticated, "UNAUTHENTICATED"},
+		{"invalid token", badToken, getCoupon(client, "FLASH25"), codes.Unauthenticated, "INVALID_TOKEN"},
+		{"invalid api key", badKey, getCoupon(client, "FLASH25"), codes.Unauthenticated, "INVALID_API_KEY"},
+		{"customer reads", withToken(t, "user1", middleware.RoleCustomer), getCoupon(client, "FLASH25"), codes.OK, ""},
+		{"customer creates", withToken(t, "user1", middleware.RoleCustomer), createCoupon(client), codes.PermissionDenied, "FORBIDDEN"},
+		{"admin creates", withToken(t, "admin1", middleware.RoleAdmin), createCoupon(client), codes.OK, ""},
+		{"api key reads its coupon", apiKey, getCoupon(client, "FLASH25"), codes.OK, ""},
+		{"api key reads other coupon", apiKey, getCoupon(client, "OTHER"), codes.PermissionDenied, "FORBIDDEN"},
+		{"api key lists", apiKey, listCoupons(client), codes.PermissionDenied, "FORBIDDEN"},
+		{"api key lacks scope", apiKey, createCoupon(client), codes.PermissionDenied, "FORBIDDEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(tt.ctx)
+
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.reason != "" {
+				assert.Equal(t, tt.reason, errorInfoReason(t, err))
+			}
+		})
+	}
+}
+
+func getCoupon(client couponv1.CouponServiceClient, name string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := client.GetCoupon(ctx, &couponv1.GetCouponRequest{Name: name})
+		return err
+	}
+}
+
+func createCoupon(client couponv1.CouponServiceClient) func(context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := client.CreateCoupon(ctx, &couponv1.CreateCouponRequest{Name: "FLASH25", Amount: 1})
+		return err
+	}
+}
+
+func listCoupons(client couponv1.CouponServiceClient) func(context.Context) error {
+	return func(ctx context.Context) error {
+		stream, err := client.ListCoupons(ctx, &couponv1.ListCouponsRequest{})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		return err
+	}
+}
+
+func TestLogging_GRPC_EchoesTraceID(t *testing.T) {
+	mockService := new(MockCouponService)
+	client := newTestClient(t, mockService, nil)
+
+	mockService.On("UpdateCoupon", "FLASH25").Return(int64(1), nil)
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-trace-id", "trace-123")
+	_, err := client.UpdateCoupon(ctx, &couponv1.UpdateCouponRequest{Name: "FLASH25"}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"trace-123"}, header.Get("x-trace-id"))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "x-trace-id", "bad id;drop")
+	_, err = client.UpdateCoupon(ctx, &couponv1.UpdateCouponRequest{Name: "FLASH25"}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.NotEqual(t, "bad id;drop", header.Get("x-trace-id")[0])
+}
@@ -0,0 +1,93 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wazadio/coupon-system/internal/handlers/rest"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail attached to every error status. Its
+// reason is the code the REST API returns for the same error.
+const ErrorDomain = "coupon-system"
+
+// errorMapping describes the status for a repository or service sentinel error. An empty
+// message uses the error's own message, for errors that carry specifics such as a rule name.
+type errorMapping struct {
+	err     error
+	code    codes.Code
+	reason  string
+	message string
+}
+
+// errorMappings lists the sentinel errors CouponService returns to the RPCs served here
+var errorMappings = []errorMapping{
+	{repository.ErrCouponNotFound, codes.NotFound, rest.ErrorCodeCouponNotFound, "Coupon not found"},
+	{repository.ErrCouponAlreadyExists, codes.AlreadyExists, rest.ErrorCodeCouponAlreadyExists, "Coupon already exists"},
+	{repository.ErrAlreadyClaimed, codes.AlreadyExists, rest.ErrorCodeCouponAlreadyClaimed, "User already claimed this coupon"},
+	{repository.ErrNoStockAvailable, codes.FailedPrecondition, rest.ErrorCodeCouponSoldOut, "No stock available"},
+	{repository.ErrCouponExpired, codes.FailedPrecondition, rest.ErrorCodeCouponExpired, "Coupon has expired"},
+	{repository.ErrUserDenied, codes.PermissionDenied, rest.ErrorCodeUserDenied, "User is not allowed to claim coupons"},
+	{repository.ErrUserNotAllowed, codes.PermissionDenied, rest.ErrorCodeUserNotAllowlisted, "User is not on this coupon's allowlist"},
+	{service.ErrNotEligible, codes.PermissionDenied, rest.ErrorCodeUserNotEligible, ""},
+	{service.ErrQueueRequired, codes.FailedPrecondition, rest.ErrorCodeQueueRequired, "Coupon is in queue mode, join its queue instead"},
+	{repository.ErrAlreadyEntered, codes.AlreadyExists, rest.ErrorCodeLotteryAlreadyEntered, "User already entered this lottery"},
+	{repository.ErrEntriesClosed, codes.FailedPrecondition, rest.ErrorCodeLotteryEntriesClosed, "Lottery entries are closed"},
+}
+
+// statusFor logs err and converts it to a status error. Validation errors report every
+// offending field in a BadRequest detail, and unknown errors become Internal without their
+// message, which may hold database details.
+func statusFor(ctx context.Context, err error) error {
+	logger.Print(ctx, logger.LevelError, err.Error())
+
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		badRequest := &errdetails.BadRequest{}
+		for _, v := range violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Message,
+			})
+		}
+		return errorWithInfo(codes.InvalidArgument, rest.ErrorCodeValidationFailed, violations.Error(), badRequest)
+	}
+
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.err) {
+			message := mapping.message
+			if message == "" {
+				message = err.Error()
+			}
+			return errorWithInfo(mapping.code, mapping.reason, message)
+		}
+	}
+
+	// Failed sends already carry a status, and cancelled streams surface the context error
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	return errorWithInfo(codes.Internal, rest.ErrorCodeInternal, "An unexpected error occurred")
+}
+
+// errorWithInfo builds a status error carrying reason in an ErrorInfo detail, followed by details
+func errorWithInfo(code codes.Code, reason, message string, details ...protoadapt.MessageV1) error {
+	st := status.New(code, message)
+	details = append([]protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: reason, Domain: ErrorDomain}}, details...)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,208 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/tracing"
+	"github.com/wazadio/coupon-system/pkg/jwt"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	couponv1 "github.com/wazadio/coupon-system/pkg/pb/coupon/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read from and written to calls. gRPC lowercases metadata keys, so these
+// match the X-API-Key, Authorization and X-Trace-ID headers of the REST API.
+const (
+	metadataAPIKey        = "x-api-key"
+	metadataAuthorization = "authorization"
+	metadataTraceID       = "x-trace-id"
+)
+
+// methodPermissions declares the permission each RPC requires, as routes do with
+// RequirePermission. Methods missing here are denied.
+var methodPermissions = map[string]middleware.Permission{
+	couponv1.CouponService_CreateCoupon_FullMethodName: middleware.PermCouponsCreate,
+	couponv1.CouponService_ClaimCoupon_FullMethodName:  middleware.PermCouponsClaim,
+	couponv1.CouponService_GetCoupon_FullMethodName:    middleware.PermCouponsRead,
+	couponv1.CouponService_UpdateCoupon_FullMethodName: middleware.PermCouponsUpdate,
+	couponv1.CouponService_ListCoupons_FullMethodName:  middleware.PermCouponsExport,
+}
+
+// UnaryLoggingInterceptor is the gRPC counterpart of middleware.LoggingMiddleware. It writes
+// one access line per call once the call completes.
+func UnaryLoggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, traceID, callLogger := withCallLogger(ctx, info.FullMethod)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(metadataTraceID, traceID))
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	callLogger.Info("Request completed", accessLogFields(err, time.Since(start))...)
+
+	return resp, err
+}
+
+// StreamLoggingInterceptor is the gRPC counterpart of middleware.LoggingMiddleware for streams
+func StreamLoggingInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, traceID, callLogger := withCallLogger(ss.Context(), info.FullMethod)
+	_ = ss.SetHeader(metadata.Pairs(metadataTraceID, traceID))
+
+	start := time.Now()
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	callLogger.Info("Request completed", accessLogFields(err, time.Since(start))...)
+
+	return err
+}
+
+// accessLogFields describes a call that ended with err in duration
+func accessLogFields(err error, duration time.Duration) []zap.Field {
+	return []zap.Field{
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", duration),
+	}
+}
+
+// withCallLogger stores a logger carrying the call's trace ID and method in ctx. Behind
+// the tracing interceptors the trace ID is the call span's. Otherwise a valid x-trace-id
+// sent by the caller is kept so a request can be followed across services.
+func withCallLogger(ctx context.Context, method string) (context.Context, string, *zap.Logger) {
+	traceID, spanID, traced := tracing.IDs(ctx)
+	if !traced {
+		traceID = firstMetadata(ctx, metadataTraceID)
+		if !middleware.ValidTraceID(traceID) {
+			traceID = uuid.New().String()
+		}
+	}
+
+	fields := []zap.Field{
+		zap.String("trace_id", traceID),
+		zap.String("method", method),
+	}
+	if traced {
+		fields = append(fields, zap.String("span_id", spanID))
+	}
+	callLogger := logger.Log.With(fields...)
+	return context.WithValue(ctx, logger.LoggerContext{}, callLogger), traceID, callLogger
+}
+
+// UnaryAuthInterceptor authenticates calls like middleware.AuthMiddleware, reading the
+// x-api-key or authorization metadata, then checks the permission the method requires.
+// A nil verifier disables authentication and every call acts as an anonymous admin.
+func UnaryAuthInterceptor(verifier *jwt.Verifier, apiKeys middleware.APIKeyAuthenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authorize(ctx, verifier, apiKeys, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor is UnaryAuthInterceptor for streams. The request is not read
+// before authorizing, so streams are closed to API keys restricted to specific coupons.
+func StreamAuthInterceptor(verifier *jwt.Verifier, apiKeys middleware.APIKeyAuthenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), verifier, apiKeys, info.FullMethod, nil)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize authenticates the caller, checks they may call method and returns ctx with
+// their identity. Callers restricted to specific coupons must also be allowed the coupon
+// named by req; calls without one are closed to them, except claims, which check it themselves.
+func authorize(ctx context.Context, verifier *jwt.Verifier, apiKeys middleware.APIKeyAuthenticator, method string, req interface{}) (context.Context, error) {
+	identity, err := authenticate(ctx, verifier, apiKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	perm, ok := methodPermissions[method]
+	if !ok {
+		logger.Print(ctx, logger.LevelWarn, "No permission declared for "+method)
+		return nil, errorWithInfo(codes.PermissionDenied, middleware.ErrorCodeForbidden, "Method is not available")
+	}
+	if !identity.HasPermission(perm) {
+		logger.Print(ctx, logger.LevelWarn, "Permission denied: "+method)
+		return nil, errorWithInfo(codes.PermissionDenied, middleware.ErrorCodeForbidden, "Missing permission "+string(perm))
+	}
+
+	if len(identity.CouponNames) > 0 && perm != middleware.PermCouponsClaim {
+		name, named := couponNameOf(req)
+		if !named || !identity.AllowsCoupon(name) {
+			logger.Print(ctx, logger.LevelWarn, "Coupon not in api key scope")
+			return nil, errorWithInfo(codes.PermissionDenied, middleware.ErrorCodeForbidden, "Coupon is outside this API key's scope")
+		}
+	}
+
+	return middleware.WithIdentity(ctx, identity), nil
+}
+
+// authenticate resolves the caller from the call metadata
+func authenticate(ctx context.Context, verifier *jwt.Verifier, apiKeys middleware.APIKeyAuthenticator) (*middleware.Identity, error) {
+	if verifier == nil {
+		return &middleware.Identity{Roles: []string{middleware.RoleAdmin}}, nil
+	}
+
+	if key := firstMetadata(ctx, metadataAPIKey); key != "" {
+		identity, err := middleware.AuthenticateAPIKey(ctx, apiKeys, key)
+		if err != nil {
+			logger.Print(ctx, logger.LevelWarn, "Invalid api key: "+err.Error())
+			return nil, errorWithInfo(codes.Unauthenticated, middleware.ErrorCodeInvalidAPIKey, "Invalid API key")
+		}
+		return identity, nil
+	}
+
+	token, ok := middleware.BearerToken(firstMetadata(ctx, metadataAuthorization))
+	if !ok {
+		logger.Print(ctx, logger.LevelWarn, "Missing bearer token")
+		return nil, errorWithInfo(codes.Unauthenticated, middleware.ErrorCodeUnauthenticated, "Missing bearer token")
+	}
+
+	identity, err := middleware.AuthenticateToken(verifier, token)
+	if err != nil {
+		logger.Print(ctx, logger.LevelWarn, "Invalid bearer token: "+err.Error())
+		return nil, errorWithInfo(codes.Unauthenticated, middleware.ErrorCodeInvalidToken, "Invalid bearer token")
+	}
+	return identity, nil
+}
+
+// couponNameOf returns the coupon a request acts on, for the RPCs whose REST route has
+// the coupon name in its path
+func couponNameOf(req interface{}) (string, bool) {
+	switch req := req.(type) {
+	case *couponv1.GetCouponRequest:
+		return req.GetName(), true
+	case *couponv1.UpdateCouponRequest:
+		return req.GetName(), true
+	default:
+		return "", false
+	}
+}
+
+// firstMetadata returns the first value of the incoming metadata key, or ""
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// serverStream replaces the context of a stream, which interceptors cannot otherwise change
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
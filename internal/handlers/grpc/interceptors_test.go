@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryLoggingInterceptor_LogsOneLinePerCall(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	previous := logger.Log
+	logger.Log = zap.New(core)
+	t.Cleanup(func() { logger.Log = previous })
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/coupon.v1.CouponService/GetCoupon"}
+	_, err := UnaryLoggingInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "coupon not found")
+	})
+	assert.Error(t, err)
+
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "Request completed", entries[0].Message)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, codes.NotFound.String(), fields["code"])
+		assert.Equal(t, info.FullMethod, fields["method"])
+		assert.Contains(t, fields, "duration")
+		assert.Contains(t, fields, "trace_id")
+	}
+}
@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	couponv1 "github.com/wazadio/coupon-system/pkg/pb/coupon/v1"
+	"github.com/wazadio/coupon-system/pkg/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// metadataForwardedFor matches the X-Forwarded-For header of the REST API
+const metadataForwardedFor = "x-forwarded-for"
+
+// methodRoutes maps each RPC to the REST route it mirrors. Calls are limited by that route's
+// rules under the same bucket keys, so with a shared store switching transports does not
+// double a caller's limit.
+var methodRoutes = map[string]string{
+	couponv1.CouponService_CreateCoupon_FullMethodName: "POST /api/coupons",
+	couponv1.CouponService_ClaimCoupon_FullMethodName:  "POST /api/coupons/claim",
+	couponv1.CouponService_GetCoupon_FullMethodName:    "GET /api/coupons/{name}",
+	couponv1.CouponService_UpdateCoupon_FullMethodName: "PUT /api/coupons/{name}",
+}
+
+// UnaryRateLimitInterceptor is the gRPC counterpart of middleware.RateLimitMiddleware,
+// sharing its store and config. It must run after UnaryAuthInterceptor so user limits see
+// the caller. Limited calls fail with ResourceExhausted and a RetryInfo detail.
+func UnaryRateLimitInterceptor(store ratelimit.Store, config *middleware.RateLimitConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		result, allowed := middleware.TakeRateLimit(ctx, store, config, middleware.RateLimitRequest{
+			Route:  methodRoutes[info.FullMethod],
+			IP:     func() string { return peerIP(ctx, config.TrustForwardedFor) },
+			Coupon: func() string { return rateLimitCouponName(req) },
+			User:   func() string { return rateLimitUserID(req) },
+		})
+		if !allowed {
+			return nil, errorWithInfo(codes.ResourceExhausted, middleware.ErrorCodeRateLimited, "Too many requests",
+				&errdetails.RetryInfo{RetryDelay: durationpb.New(result.RetryAfter)})
+		}
+		return handler(ctx, req)
+	}
+}
+
+// peerIP returns the caller's address without the port, or the first x-forwarded-for
+// address when trusted
+func peerIP(ctx context.Context, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := firstMetadata(ctx, metadataForwardedFor); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// rateLimitCouponName returns the coupon a request acts on, including the coupon claimed
+func rateLimitCouponName(req interface{}) string {
+	if claim, ok := req.(*couponv1.ClaimCouponRequest); ok {
+		return claim.GetCouponName()
+	}
+	name, _ := couponNameOf(req)
+	return name
+}
+
+// rateLimitUserID returns the user a request acts for, which limits API keys claiming for users
+func rateLimitUserID(req interface{}) string {
+	if claim, ok := req.(*couponv1.ClaimCouponRequest); ok {
+		return claim.GetUserId()
+	}
+	return ""
+}
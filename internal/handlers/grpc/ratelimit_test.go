@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/models"
+	couponv1 "github.com/wazadio/coupon-system/pkg/pb/coupon/v1"
+	"github.com/wazadio/coupon-system/pkg/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimit_GRPC_SharesRESTRules(t *testing.T) {
+	mockService := new(MockCouponService)
+	config := &middleware.RateLimitConfig{Routes: map[string][]middleware.RateLimitRule{
+		"POST /api/coupons/claim": {{Key: middleware.RateLimitByUser, Requests: 2, Per: time.Minute}},
+	}}
+	store := ratelimit.NewMemoryStore()
+	client := dialServer(t, NewServer(mockService, testVerifier(), stubAPIKeys{}, store, config))
+
+	mockService.On("ClaimCoupon", mock.Anything).Return(&models.Claim{Code: "FLASH25-ABCD"}, nil)
+	mockService.On("GetCouponDetails", "FLASH25").Return(&models.CouponDetailResponse{Name: "FLASH25"}, nil)
+
+	// A token already taken over REST counts against the same bucket
+	_, err := store.Take(context.Background(), "POST /api/coupons/claim|user|user1", ratelimit.Per(2, time.Minute))
+	assert.NoError(t, err)
+
+	claim := func(subject string) error {
+		_, err := client.ClaimCoupon(withToken(t, subject, middleware.RoleCustomer), &couponv1.ClaimCouponRequest{CouponName: "FLASH25"})
+		return err
+	}
+	assert.NoError(t, claim("user1"))
+
+	err = claim("user1")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, middleware.ErrorCodeRateLimited, errorInfoReason(t, err))
+	var retry *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if assert.NotNil(t, retry) {
+		assert.InDelta(t, 30*time.Second, retry.GetRetryDelay().AsDuration(), float64(time.Second))
+	}
+
+	// Other callers and methods without rules are not limited
+	assert.NoError(t, claim("user2"))
+	_, err = client.GetCoupon(withToken(t, "user1", middleware.RoleCustomer), &couponv1.GetCouponRequest{Name: "FLASH25"})
+	assert.NoError(t, err)
+	mockService.AssertNumberOfCalls(t, "ClaimCoupon", 2)
+}
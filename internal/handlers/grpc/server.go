@@ -0,0 +1,29 @@
+// Package grpc serves the coupon service over gRPC for internal callers. It shares the
+// services, credentials and permissions of the REST API in internal/handlers/rest.
+package grpc
+
+import (
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/jwt"
+	couponv1 "github.com/wazadio/coupon-system/pkg/pb/coupon/v1"
+	"github.com/wazadio/coupon-system/pkg/ratelimit"
+	"google.golang.org/grpc"
+)
+
+// NewServer returns a server exposing couponv1.CouponService behind tracing, logging,
+// authentication and rate limiting. A nil verifier disables authentication, and a nil
+// rateLimits disables rate limiting.
+func NewServer(couponService service.CouponService, verifier *jwt.Verifier, apiKeys middleware.APIKeyAuthenticator, store ratelimit.Store, rateLimits *middleware.RateLimitConfig) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{UnaryTracingInterceptor, UnaryLoggingInterceptor, UnaryAuthInterceptor(verifier, apiKeys)}
+	if rateLimits != nil {
+		unary = append(unary, UnaryRateLimitInterceptor(store, rateLimits))
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(StreamTracingInterceptor, StreamLoggingInterceptor, StreamAuthInterceptor(verifier, apiKeys)),
+	)
+	couponv1.RegisterCouponServiceServer(server, NewCouponServer(couponService))
+	return server
+}
@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/pkg/jwt"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+)
+
+// identityContext is the context key for the authenticated Identity
+type identityContext struct{}
+
+// Identity is the caller authenticated from a bearer token or an API key. Subject is
+// only set for end users; API keys act for backend services and carry their scopes as Permissions.
+// Attributes are the end user's eligibility attributes, read from the token's attributes claim.
+type Identity struct {
+	Subject     string
+	Roles       []string
+	Claims      *jwt.Claims
+	Attributes  map[string]interface{}
+	APIKeyID    int64
+	Permissions []Permission
+	CouponNames []string
+}
+
+// HasPermission reports whether the caller's roles or API key scopes grant perm
+func (i *Identity) HasPermission(perm Permission) bool {
+	if HasPermission(i.Roles, perm) {
+		return true
+	}
+	for _, p := range i.Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsCoupon reports whether the caller may act on couponName. Only API keys
+// restricted to specific coupons are limited.
+func (i *Identity) AllowsCoupon(couponName string) bool {
+	if len(i.CouponNames) == 0 {
+		return true
+	}
+	for _, name := range i.CouponNames {
+		if name == couponName {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors returned by AuthorizeClaim
+var (
+	ErrUserMismatch         = errors.New("user_id does not match the authenticated user")
+	ErrCouponOutOfScope     = errors.New("coupon is outside this API key's scope")
+	ErrAttributesNotAllowed = errors.New("attributes cannot be sent by this caller")
+)
+
+// AuthorizeClaim applies the claim rules every transport shares. Authenticated users claim
+// for themselves, so req may only repeat their user ID and is set to it, and their
+// eligibility attributes come from their token: attributes in req are rejected. Other
+// callers may only send attributes with PermCouponsClaimAttributes, and API keys
+// restricted to specific coupons can only claim those. Unauthenticated contexts pass.
+func AuthorizeClaim(ctx context.Context, req *models.ClaimCouponRequest) error {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	if identity.Subject != "" {
+		if req.UserID != "" && req.UserID != identity.Subject {
+			return ErrUserMismatch
+		}
+		if len(req.Attributes) > 0 {
+			return ErrAttributesNotAllowed
+		}
+		req.UserID = identity.Subject
+		req.Attributes = identity.Attributes
+	} else if len(req.Attributes) > 0 && !identity.HasPermission(PermCouponsClaimAttributes) {
+		return ErrAttributesNotAllowed
+	}
+
+	if !identity.AllowsCoupon(req.CouponName) {
+		return ErrCouponOutOfScope
+	}
+	return nil
+}
+
+// APIKeyAuthenticator resolves an API key to its stored record
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// WithIdentity returns a copy of ctx carrying identity
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityContext{}, identity)
+}
+
+// IdentityFromContext returns the authenticated caller, if the request was authenticated
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityContext{}).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// AuthMiddleware rejects requests without a valid bearer token or X-API-Key header and
+// stores the caller's identity in the request context
+func AuthMiddleware(verifier *jwt.Verifier, apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := r.Header.Get("X-API-Key"); key != "" {
+				identity, err := AuthenticateAPIKey(r.Context(), apiKeys, key)
+				if err != nil {
+					logger.Print(r.Context(), logger.LevelWarn, "Invalid api key: "+err.Error())
+					pkgRest.RespondWithError(w, http.StatusUnauthorized, ErrorCodeInvalidAPIKey, "Invalid API key")
+					return
+				}
+
+				next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+				return
+			}
+
+			token, ok := BearerToken(r.Header.Get("Authorization"))
+			if !ok {
+				logger.Print(r.Context(), logger.LevelWarn, "Missing bearer token")
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				pkgRest.RespondWithError(w, http.StatusUnauthorized, ErrorCodeUnauthenticated, "Missing bearer token")
+				return
+			}
+
+			identity, err := AuthenticateToken(verifier, token)
+			if err != nil {
+				logger.Print(r.Context(), logger.LevelWarn, "Invalid bearer token: "+err.Error())
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				pkgRest.RespondWithError(w, http.StatusUnauthorized, ErrorCodeInvalidToken, "Invalid bearer token")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+// AuthenticateAPIKey resolves an API key to the identity of the service holding it
+func AuthenticateAPIKey(ctx context.Context, apiKeys APIKeyAuthenticator, key string) (*Identity, error) {
+	apiKey, err := apiKeys.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return identityFromAPIKey(apiKey), nil
+}
+
+// AuthenticateToken verifies a bearer token and returns the identity of the user it was issued to
+func AuthenticateToken(verifier *jwt.Verifier, token string) (*Identity, error) {
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Subject:    claims.Subject,
+		Roles:      rolesFromClaims(claims),
+		Claims:     claims,
+		Attributes: attributesFromClaims(claims),
+	}, nil
+}
+
+// DevIdentityMiddleware grants every request an anonymous admin identity. It stands in for
+// AuthMiddleware when authentication is disabled for local development.
+func DevIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithIdentity(r.Context(), &Identity{Roles: []string{RoleAdmin}})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func identityFromAPIKey(apiKey *models.APIKey) *Identity {
+	permissions := make([]Permission, len(apiKey.Scopes))
+	for i, scope := range apiKey.Scopes {
+		permissions[i] = Permission(scope)
+	}
+
+	return &Identity{
+		APIKeyID:    apiKey.ID,
+		Permissions: permissions,
+		CouponNames: apiKey.CouponNames,
+	}
+}
+
+// rolesFromClaims reads the roles claim, which may be a list of strings or a single string
+func rolesFromClaims(claims *jwt.Claims) []string {
+	switch v := claims.Raw["roles"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		roles := make([]string, 0, len(v))
+		for _, role := range v {
+			if s, ok := role.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	default:
+		return nil
+	}
+}
+
+// attributesFromClaims reads the attributes claim, an object the token issuer fills with
+// the user's verified eligibility attributes
+func attributesFromClaims(claims *jwt.Claims) map[string]interface{} {
+	attributes, _ := claims.Raw["attributes"].(map[string]interface{})
+	return attributes
+}
+
+// BearerToken extracts the token from an Authorization value of the form "Bearer <token>"
+func BearerToken(authorization string) (string, bool) {
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/pkg/jwt"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func signToken(t *testing.T, claims map[string]interface{}) string {
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		assert.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	input := segment(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + segment(claims)
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// stubAPIKeys accepts a single key
+type stubAPIKeys struct{}
+
+func (stubAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	if key != "cpk_abc_secret" {
+		return nil, errors.New("invalid api key")
+	}
+	return &models.APIKey{ID: 7, Scopes: []string{string(PermCouponsClaim)}, CouponNames: []string{"FLASH25"}}, nil
+}
+
+func serveWithAuth(authorization string) (*httptest.ResponseRecorder, *Identity) {
+	return serveWithHeaders(map[string]string{"Authorization": authorization})
+}
+
+func serveWithHeaders(headers map[string]string) (*httptest.ResponseRecorder, *Identity) {
+	verifier := &jwt.Verifier{Keys: []jwt.Key{{Algorithm: jwt.AlgHS256, HMACSecret: testSecret}}}
+
+	var seen *Identity
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = IdentityFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/FLASH25", nil)
+	for name, value := range headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	rec := httptest.NewRecorder()
+	AuthMiddleware(verifier, stubAPIKeys{})(next).ServeHTTP(rec, req)
+
+	return rec, seen
+}
+
+func TestAuthMiddleware_ValidToken(t *testing.T) {
+	logger.Init()
+
+	token := signToken(t, map[string]interface{}{"sub": "user1", "exp": time.Now().Add(time.Hour).Unix()})
+	rec, identity := serveWithAuth("Bearer " + token)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	if assert.NotNil(t, identity) {
+		assert.Equal(t, "user1", identity.Subject)
+	}
+}
+
+func TestAuthMiddleware_MissingToken(t *testing.T) {
+	logger.Init()
+
+	rec, identity := serveWithAuth("")
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	assert.Nil(t, identity)
+}
+
+func TestAuthMiddleware_WrongScheme(t *testing.T) {
+	logger.Init()
+
+	rec, _ := serveWithAuth("Basic dXNlcjpwYXNz")
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAuthMiddleware_ExpiredToken(t *testing.T) {
+	logger.Init()
+
+	token := signToken(t, map[string]interface{}{"sub": "user1", "exp": time.Now().Add(-time.Hour).Unix()})
+	rec, identity := serveWithAuth("Bearer " + token)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, rec.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"Invalid bearer token","code":"INVALID_TOKEN"}`, rec.Body.String())
+	assert.Nil(t, identity)
+}
+
+func TestAuthMiddleware_RolesClaim(t *testing.T) {
+	logger.Init()
+
+	token := signToken(t, map[string]interface{}{
+		"sub":   "user1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{RoleOperator, RoleReadOnly},
+	})
+	_, identity := serveWithAuth("Bearer " + token)
+	if assert.NotNil(t, identity) {
+		assert.Equal(t, []string{RoleOperator, RoleReadOnly}, identity.Roles)
+	}
+
+	token = signToken(t, map[string]interface{}{"sub": "user1", "exp": time.Now().Add(time.Hour).Unix(), "roles": RoleCustomer})
+	_, identity = serveWithAuth("Bearer " + token)
+	if assert.NotNil(t, identity) {
+		assert.Equal(t, []string{RoleCustomer}, identity.Roles)
+	}
+}
+
+func TestAuthMiddleware_AttributesClaim(t *testing.T) {
+	logger.Init()
+
+	token := signToken(t, map[string]interface{}{
+		"sub":        "user1",
+		"exp":        time.Now().Add(time.Hour).Unix(),
+		"attributes": map[string]interface{}{"tier": "gold"},
+	})
+	_, identity := serveWithAuth("Bearer " + token)
+	if assert.NotNil(t, identity) {
+		assert.Equal(t, map[string]interface{}{"tier": "gold"}, identity.Attributes)
+	}
+}
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	logger.Init()
+
+	rec, identity := serveWithHeaders(map[string]string{"X-API-Key": "cpk_abc_secret"})
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	if assert.NotNil(t, identity) {
+		assert.Empty(t, identity.Subject)
+		assert.Equal(t, int64(7), identity.APIKeyID)
+		assert.True(t, identity.HasPermission(PermCouponsClaim))
+		assert.False(t, identity.HasPermission(PermCouponsCreate))
+		assert.True(t, identity.AllowsCoupon("FLASH25"))
+		assert.False(t, identity.AllowsCoupon("OTHER"))
+	}
+}
+
+func TestAuthMiddleware_InvalidAPIKey(t *testing.T) {
+	logger.Init()
+
+	rec, identity := serveWithHeaders(map[string]string{"X-API-Key": "cpk_abc_wrong"})
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"Invalid API key","code":"INVALID_API_KEY"}`, rec.Body.String())
+	assert.Nil(t, identity)
+}
+
+func TestAuthorizeClaim(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *Identity
+		req      models.ClaimCouponRequest
+		userID   string
+		err      error
+	}{
+		{"unauthenticated", nil, models.ClaimCouponRequest{UserID: "user2", CouponName: "A"}, "user2", nil},
+		{"user id defaults to subject", &Identity{Subject: "user1"}, models.ClaimCouponRequest{CouponName: "A"}, "user1", nil},
+		{"user id mismatch", &Identity{Subject: "user1"}, models.ClaimCouponRequest{UserID: "user2", CouponName: "A"}, "user2", ErrUserMismatch},
+		{"api key in scope", &Identity{CouponNames: []string{"A"}}, models.ClaimCouponRequest{UserID: "user2", CouponName: "A"}, "user2", nil},
+		{"api key out of scope", &Identity{CouponNames: []string{"A"}}, models.ClaimCouponRequest{UserID: "user2", CouponName: "B"}, "user2", ErrCouponOutOfScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.identity != nil {
+				ctx = WithIdentity(ctx, tt.identity)
+			}
+
+			err := AuthorizeClaim(ctx, &tt.req)
+
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.userID, tt.req.UserID)
+		})
+	}
+}
+
+func TestAuthorizeClaim_Attributes(t *testing.T) {
+	gold := map[string]interface{}{"tier": "gold"}
+	attributesKey := &Identity{APIKeyID: 7, Permissions: []Permission{PermCouponsClaim, PermCouponsClaimAttributes}}
+
+	tests := []struct {
+		name       string
+		identity   *Identity
+		attributes map[string]interface{}
+		expected   map[string]interface{}
+		err        error
+	}{
+		{"unauthenticated", nil, gold, gold, nil},
+		{"user takes token attributes", &Identity{Subject: "user1", Attributes: gold}, nil, gold, nil},
+		{"user without token attributes", &Identity{Subject: "user1"}, nil, nil, nil},
+		{"user sends attributes", &Identity{Subject: "user1", Attributes: gold}, map[string]interface{}{"tier": "platinum"}, map[string]interface{}{"tier": "platinum"}, ErrAttributesNotAllowed},
+		{"admin user sends attributes", &Identity{Subject: "user1", Roles: []string{RoleAdmin}}, gold, gold, ErrAttributesNotAllowed},
+		{"api key without permission", &Identity{APIKeyID: 7, Permissions: []Permission{PermCouponsClaim}}, gold, gold, ErrAttributesNotAllowed},
+		{"api key with permission", attributesKey, gold, gold, nil},
+		{"api key without attributes", &Identity{APIKeyID: 7, Permissions: []Permission{PermCouponsClaim}}, nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.identity != nil {
+				ctx = WithIdentity(ctx, tt.identity)
+			}
+			req := models.ClaimCouponRequest{UserID: "user1", CouponName: "A", Attributes: tt.attributes}
+
+			err := AuthorizeClaim(ctx, &req)
+
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.expected, req.Attributes)
+		})
+	}
+}
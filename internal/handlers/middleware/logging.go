@@ -2,39 +2,171 @@ package middleware
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/wazadio/coupon-system/internal/tracing"
 	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
 	"go.uber.org/zap"
 )
 
-// LoggingMiddleware adds trace_id to each request and creates a contextual logger
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Generate trace ID
-		traceID := uuid.New().String()
+// maxTraceIDLength caps the length of a trace ID accepted from the caller
+const maxTraceIDLength = 128
 
-		// Create a child logger with trace_id
-		reqLogger := logger.Log.With(
-			zap.String("trace_id", traceID),
-			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path),
-		)
+// AccessLogConfig controls which requests get an access log line
+type AccessLogConfig struct {
+	// SuccessSampleRate is the fraction, from 0 to 1, of requests answered below 400 that are
+	// logged. Client and server errors are always logged.
+	SuccessSampleRate float64 `yaml:"success_sample_rate"`
+}
+
+// DefaultAccessLogConfig logs every request
+func DefaultAccessLogConfig() *AccessLogConfig {
+	return &AccessLogConfig{SuccessSampleRate: 1}
+}
+
+// LoadEnv overrides the settings whose ACCESS_LOG_* environment variable is set
+func (c *AccessLogConfig) LoadEnv() error {
+	if rate := os.Getenv("ACCESS_LOG_SAMPLE_RATE"); rate != "" {
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil {
+			return fmt.Errorf("invalid ACCESS_LOG_SAMPLE_RATE: %q", rate)
+		}
+		c.SuccessSampleRate = r
+	}
+	return nil
+}
+
+// Validate checks the sample rate is a fraction
+func (c *AccessLogConfig) Validate() error {
+	if c.SuccessSampleRate < 0 || c.SuccessSampleRate > 1 {
+		return fmt.Errorf("success_sample_rate must be between 0 and 1, got %v", c.SuccessSampleRate)
+	}
+	return nil
+}
+
+// logged reports whether a request answered with status gets an access log line
+func (c *AccessLogConfig) logged(status int) bool {
+	if status >= http.StatusBadRequest || c.SuccessSampleRate >= 1 {
+		return true
+	}
+	return rand.Float64() < c.SuccessSampleRate
+}
+
+// LoggingMiddleware adds trace_id to each request, creates a contextual logger and writes
+// one access log line per request once it is answered, sampled as config says. A nil
+// config logs every request. Behind TracingMiddleware the trace ID is the request span's,
+// so logs and traces share it. Otherwise a valid X-Trace-ID sent by the caller is kept so
+// a request can be followed across services.
+func LoggingMiddleware(config *AccessLogConfig) func(http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultAccessLogConfig()
+	}
 
-		// Add logger and trace_id to context
-		ctx := context.WithValue(r.Context(), logger.LoggerContext{}, reqLogger)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceID, spanID, traced := tracing.IDs(r.Context())
+			if !traced {
+				// Reuse the caller's trace ID or generate one
+				traceID = r.Header.Get(pkgRest.TraceIDHeader)
+				if !ValidTraceID(traceID) {
+					traceID = uuid.New().String()
+				}
+			}
 
-		// Add trace_id to response header
-		w.Header().Set("X-Trace-ID", traceID)
+			// Create a child logger with trace_id
+			fields := []zap.Field{
+				zap.String("trace_id", traceID),
+				zap.String("method", r.Method),
+				zap.String("path", RedactedPath(r)),
+			}
+			if traced {
+				fields = append(fields, zap.String("span_id", spanID))
+			}
+			reqLogger := logger.Log.With(fields...)
 
-		// Log request
-		reqLogger.Info("Request started")
+			// Add logger and trace_id to context
+			ctx := context.WithValue(r.Context(), logger.LoggerContext{}, reqLogger)
 
-		// Call next handler with updated context
-		next.ServeHTTP(w, r.WithContext(ctx))
+			// Add trace_id to response header
+			w.Header().Set(pkgRest.TraceIDHeader, traceID)
 
-		// Log request completed
-		reqLogger.Info("Request completed")
+			reqLogger.Debug("Request started")
+
+			// Call next handler with updated context
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			if config.logged(recorder.status) {
+				reqLogger.Info("Request completed", accessLogFields(r, recorder, time.Since(start))...)
+			}
+		})
+	}
+}
+
+// accessLogFields describes a request answered through recorder in duration
+func accessLogFields(r *http.Request, recorder *statusRecorder, duration time.Duration) []zap.Field {
+	fields := []zap.Field{
+		zap.String("route", routeTemplate(r)),
+		zap.Int("status", recorder.status),
+		zap.Duration("duration", duration),
+		zap.Int64("bytes", recorder.bytes),
+		zap.String("remote_ip", ClientIP(r, false)),
+		zap.String("user_agent", r.UserAgent()),
+	}
+	// Logged as sent, since only a trusted proxy makes it meaningful
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		fields = append(fields, zap.String("forwarded_for", forwarded))
+	}
+	return fields
+}
+
+// RedactedPath is the path of r with the route variables the logger redacts, such as
+// {user_id}, replaced as the logger would write them. The path is rebuilt from the route's
+// template, so a value that also appears as a literal segment, as in
+// /api/users/users/claims, is redacted where the variable is. Paths no route matches are
+// returned as they are, since nothing tells which of their segments identify a user.
+func RedactedPath(r *http.Request) string {
+	template, vars := routePathTemplate(r), routeVars(r)
+	if template == "" {
+		return r.URL.Path
+	}
+
+	redacted := false
+	for name, value := range vars {
+		if logger.Redact(name, value) != value {
+			redacted = true
+			break
+		}
+	}
+	if !redacted {
+		return r.URL.Path
+	}
+
+	return expandTemplate(template, func(name string) string {
+		return logger.Redact(name, vars[name])
 	})
 }
+
+// ValidTraceID accepts IDs of letters, digits, '-', '_' and '.', so a trace ID cannot inject
+// anything into headers or logs
+func ValidTraceID(id string) bool {
+	if id == "" || len(id) > maxTraceIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
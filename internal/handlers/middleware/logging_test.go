@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggingMiddleware_TraceID(t *testing.T) {
+	logger.Init()
+
+	handler := LoggingMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{"generated when missing", "", false},
+		{"caller's ID kept", "checkout-7f3a.1", true},
+		{"unsafe ID replaced", "abc\r\nSet-Cookie: x", false},
+		{"overlong ID replaced", strings.Repeat("a", maxTraceIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Trace-ID", tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			traceID := rec.Header().Get("X-Trace-ID")
+			assert.NotEmpty(t, traceID)
+			if tt.kept {
+				assert.Equal(t, tt.incoming, traceID)
+			} else {
+				assert.NotEqual(t, tt.incoming, traceID)
+			}
+		})
+	}
+}
+
+// observeLogs replaces the global logger with one recording entries for the test
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	core, logs := observer.New(zap.InfoLevel)
+	previous := logger.Log
+	logger.Log = zap.New(core)
+	t.Cleanup(func() { logger.Log = previous })
+	return logs
+}
+
+func TestLoggingMiddleware_AccessLog(t *testing.T) {
+	logs := observeLogs(t)
+
+	handler := LoggingMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("conflict"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", nil)
+	req.Header.Set("User-Agent", "checkout/1.2")
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.FilterMessage("Request completed").All()
+	if assert.Len(t, entries, 1) {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, int64(http.StatusConflict), fields["status"])
+		assert.Equal(t, int64(len("conflict")), fields["bytes"])
+		assert.Equal(t, "192.0.2.1", fields["remote_ip"])
+		assert.Equal(t, "checkout/1.2", fields["user_agent"])
+		assert.Equal(t, "203.0.113.9", fields["forwarded_for"])
+		assert.Contains(t, fields, "duration")
+		assert.Contains(t, fields, "trace_id")
+	}
+}
+
+func TestLoggingMiddleware_RedactsPath(t *testing.T) {
+	logs := observeLogs(t)
+
+	router := mux.NewRouter()
+	router.Use(LoggingMiddleware(nil))
+	router.HandleFunc("/api/admin/denylist/{user_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/admin/denylist/user-42", nil))
+
+	entries := logs.FilterMessage("Request completed").All()
+	if assert.Len(t, entries, 1) {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "/api/admin/denylist/"+logger.Redact("user_id", "user-42"), fields["path"])
+		assert.NotContains(t, fields["path"], "user-42")
+		assert.Equal(t, "/api/admin/denylist/{user_id}", fields["route"])
+	}
+}
+
+func TestRedactedPath_ValueMatchingLiteralSegment(t *testing.T) {
+	router := mux.NewRouter()
+	var paths []string
+	record := func(w http.ResponseWriter, r *http.Request) { paths = append(paths, RedactedPath(r)) }
+	router.HandleFunc("/api/users/{user_id}/claims", record)
+	router.HandleFunc("/api/users/{user_id}/codes/{code:[A-Z]{2}-[0-9]+}", record)
+	handler := MatchRoute(router)(router)
+
+	// The user named "users" must not be confused with the literal users segment
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/users/claims", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/codes/codes/AB-12", nil))
+
+	assert.NotEqual(t, "users", logger.Redact("user_id", "users"))
+	assert.Equal(t, []string{
+		"/api/users/" + logger.Redact("user_id", "users") + "/claims",
+		"/api/users/" + logger.Redact("user_id", "codes") + "/codes/AB-12",
+	}, paths)
+}
+
+func TestLoggingMiddleware_SamplesSuccesses(t *testing.T) {
+	logs := observeLogs(t)
+
+	status := http.StatusOK
+	handler := LoggingMiddleware(&AccessLogConfig{SuccessSampleRate: 0})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	assert.Zero(t, logs.FilterMessage("Request completed").Len())
+
+	// Errors are logged whatever the sample rate
+	for _, status = range []int{http.StatusNotFound, http.StatusInternalServerError} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	}
+	assert.Equal(t, 2, logs.FilterMessage("Request completed").Len())
+}
+
+func TestAccessLogConfig_LoadEnv(t *testing.T) {
+	load := func() (*AccessLogConfig, error) {
+		config := DefaultAccessLogConfig()
+		if err := config.LoadEnv(); err != nil {
+			return nil, err
+		}
+		return config, config.Validate()
+	}
+
+	t.Setenv("ACCESS_LOG_SAMPLE_RATE", "")
+	config, err := load()
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, config.SuccessSampleRate)
+
+	t.Setenv("ACCESS_LOG_SAMPLE_RATE", "0.1")
+	config, err = load()
+	assert.NoError(t, err)
+	assert.Equal(t, 0.1, config.SuccessSampleRate)
+
+	for _, rate := range []string{"1.5", "-0.1", "often"} {
+		t.Setenv("ACCESS_LOG_SAMPLE_RATE", rate)
+		_, err = load()
+		assert.Error(t, err, rate)
+	}
+}
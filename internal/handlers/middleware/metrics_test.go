@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/metrics"
+)
+
+func TestMetricsMiddleware_LabelsByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(MetricsMiddleware)
+	router.HandleFunc("/api/coupons/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods("GET")
+	router.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}).Methods("GET")
+
+	notFound := metrics.HTTPRequests.WithLabelValues("/api/coupons/{name}", "GET", "404")
+	ok := metrics.HTTPRequests.WithLabelValues("/api/health", "GET", "200")
+	notFoundBefore, okBefore := testutil.ToFloat64(notFound), testutil.ToFloat64(ok)
+
+	for _, path := range []string{"/api/coupons/FLASH25", "/api/coupons/FLASH50", "/api/health"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Coupon names collapse into the route template, and the first status written counts
+	assert.Equal(t, notFoundBefore+2, testutil.ToFloat64(notFound))
+	assert.Equal(t, okBefore+1, testutil.ToFloat64(ok))
+}
+
+func TestStatusRecorder_Flush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	recorder := &statusRecorder{ResponseWriter: rec, status: http.StatusOK}
+
+	recorder.Flush()
+
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, rec, recorder.Unwrap())
+}
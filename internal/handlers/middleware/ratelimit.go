@@ -0,0 +1,345 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/ratelimit"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+	"github.com/wazadio/coupon-system/pkg/validation"
+)
+
+// ErrorCodeRateLimited is returned with 429 responses
+const ErrorCodeRateLimited = "RATE_LIMITED"
+
+// Keys a rate limit rule can bucket requests by
+const (
+	RateLimitByUser   = "user"
+	RateLimitByIP     = "ip"
+	RateLimitByCoupon = "coupon"
+)
+
+// maxCouponNameLength is the longest coupon name, as validated on creation
+const maxCouponNameLength = 255
+
+// maxPeekBody caps how much of a request body is read to find the coupon name and user ID
+const maxPeekBody = 1 << 20
+
+// RateLimitRule allows Requests per Per for each distinct Key value, in bursts of up to Burst
+type RateLimitRule struct {
+	Key      string        `yaml:"key"`
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
+}
+
+// limit converts r to a token bucket limit. Burst defaults to Requests.
+func (r RateLimitRule) limit() ratelimit.Limit {
+	limit := ratelimit.Per(r.Requests, r.Per)
+	if r.Burst > 0 {
+		limit.Burst = r.Burst
+	}
+	return limit
+}
+
+// UnmarshalJSON reads a rule with Per written as a duration string, e.g. "1m"
+func (r *RateLimitRule) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Key      string `json:"key"`
+		Requests int    `json:"requests"`
+		Per      string `json:"per"`
+		Burst    int    `json:"burst"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	per, err := time.ParseDuration(raw.Per)
+	if err != nil {
+		return fmt.Errorf("invalid per %q: %v", raw.Per, err)
+	}
+
+	*r = RateLimitRule{Key: raw.Key, Requests: raw.Requests, Per: per, Burst: raw.Burst}
+	return nil
+}
+
+// validate checks the rule can be enforced
+func (r RateLimitRule) validate() error {
+	switch r.Key {
+	case RateLimitByUser, RateLimitByIP, RateLimitByCoupon:
+	default:
+		return fmt.Errorf("unknown rate limit key %q", r.Key)
+	}
+	if r.Requests <= 0 || r.Per <= 0 {
+		return fmt.Errorf("rate limit by %s needs positive requests and per", r.Key)
+	}
+	return nil
+}
+
+// RateLimitConfig maps routes, written as "METHOD /path/template", to the rules applied to them.
+// Routes without rules are not limited.
+type RateLimitConfig struct {
+	Routes map[string][]RateLimitRule `json:"routes" yaml:"routes"`
+	// TrustForwardedFor keys IP limits by the first X-Forwarded-For address. Only enable
+	// it behind a proxy that overwrites the header, otherwise clients can pick their own key.
+	TrustForwardedFor bool `json:"trust_forwarded_for" yaml:"trust_forwarded_for"`
+}
+
+// DefaultRateLimitConfig limits the claim and queue endpoints, the ones bots hammer during flash sales
+func DefaultRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		Routes: map[string][]RateLimitRule{
+			"POST /api/coupons/claim": {
+				{Key: RateLimitByUser, Requests: 10, Per: time.Minute},
+				{Key: RateLimitByIP, Requests: 60, Per: time.Minute},
+				{Key: RateLimitByCoupon, Requests: 500, Per: time.Second},
+			},
+			"POST /api/coupons/{name}/queue": {
+				{Key: RateLimitByUser, Requests: 10, Per: time.Minute},
+				{Key: RateLimitByIP, Requests: 60, Per: time.Minute},
+			},
+		},
+	}
+}
+
+// LoadEnv replaces the config with the JSON file at RATE_LIMIT_FILE if set, then overrides
+// TrustForwardedFor if RATE_LIMIT_TRUST_FORWARDED_FOR is set
+func (c *RateLimitConfig) LoadEnv() error {
+	if path := os.Getenv("RATE_LIMIT_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading rate limit file: %v", err)
+		}
+		var file RateLimitConfig
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("invalid rate limit file: %v", err)
+		}
+		*c = file
+	}
+
+	if trust := os.Getenv("RATE_LIMIT_TRUST_FORWARDED_FOR"); trust != "" {
+		b, err := strconv.ParseBool(trust)
+		if err != nil {
+			return fmt.Errorf("invalid RATE_LIMIT_TRUST_FORWARDED_FOR: %q", trust)
+		}
+		c.TrustForwardedFor = b
+	}
+
+	return nil
+}
+
+// Validate checks every rule can be enforced
+func (c *RateLimitConfig) Validate() error {
+	for route, rules := range c.Routes {
+		for _, rule := range rules {
+			if err := rule.validate(); err != nil {
+				return fmt.Errorf("route %s: %v", route, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RateLimitMiddleware applies the configured rules of the matched route, taking one token
+// per rule from store. It must run after authentication so user limits see the caller.
+// Requests are let through when the store fails, so an outage does not take the API down.
+func RateLimitMiddleware(store ratelimit.Store, config *RateLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body := peekClaimBody(r)
+			result, allowed := TakeRateLimit(r.Context(), store, config, RateLimitRequest{
+				Route: routeName(r),
+				IP:    func() string { return ClientIP(r, config.TrustForwardedFor) },
+				Coupon: func() string {
+					if name, ok := mux.Vars(r)["name"]; ok {
+						return name
+					}
+					return body().CouponName
+				},
+				User: func() string { return body().UserID },
+			})
+			if !allowed {
+				setRateLimitHeaders(w, *result)
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				pkgRest.RespondWithError(w, http.StatusTooManyRequests, ErrorCodeRateLimited, "Too many requests")
+				return
+			}
+
+			if result != nil {
+				setRateLimitHeaders(w, *result)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitRequest describes a request to TakeRateLimit. Route names the rules that apply,
+// as a key of RateLimitConfig.Routes. User limits read the caller's identity from the
+// context, except for API keys, which act for many users and are limited by the user_id
+// User returns. IP, Coupon and User are only called for rules keyed by them.
+type RateLimitRequest struct {
+	Route  string
+	IP     func() string
+	Coupon func() string
+	User   func() string
+}
+
+// TakeRateLimit takes one token per rule of req.Route from store. When a rule denies the
+// request it returns its result and false; otherwise it returns the result of the rule
+// closest to its limit, nil if no rule applied. Rules are skipped when the store fails.
+func TakeRateLimit(ctx context.Context, store ratelimit.Store, config *RateLimitConfig, req RateLimitRequest) (*ratelimit.Result, bool) {
+	var tightest *ratelimit.Result
+	for _, rule := range config.Routes[req.Route] {
+		value, ok := rateLimitKey(ctx, rule.Key, req)
+		if !ok {
+			continue
+		}
+
+		result, err := store.Take(ctx, req.Route+"|"+rule.Key+"|"+value, rule.limit())
+		if err != nil {
+			logger.Print(ctx, logger.LevelError, "Rate limit store error: "+err.Error())
+			continue
+		}
+
+		if !result.Allowed {
+			logger.Print(ctx, logger.LevelWarn, "Rate limited by "+rule.Key)
+			return &result, false
+		}
+
+		// Report the rule closest to its limit
+		if tightest == nil || result.Remaining < tightest.Remaining {
+			tightest = &result
+		}
+	}
+	return tightest, true
+}
+
+// routeName identifies the matched route as "METHOD /path/template"
+func routeName(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return r.Method + " " + template
+}
+
+// rateLimitKey returns the value req is bucketed by, or false if it has none. API keys
+// are limited per user they act for, sharing the bucket of that user's own requests, and
+// not by IP: they are issued by admins to backend services that send every user's
+// requests from a few addresses.
+func rateLimitKey(ctx context.Context, key string, req RateLimitRequest) (string, bool) {
+	identity, ok := IdentityFromContext(ctx)
+	apiKey := ok && identity.Subject == "" && identity.APIKeyID != 0
+
+	var value string
+	switch key {
+	case RateLimitByUser:
+		switch {
+		case !ok:
+		case identity.Subject != "":
+			value = identity.Subject
+		case apiKey:
+			value = "apikey:" + strconv.FormatInt(identity.APIKeyID, 10)
+			if req.User != nil {
+				if user := req.User(); user != "" {
+					value = user
+				}
+			}
+		}
+	case RateLimitByIP:
+		if req.IP != nil && !apiKey {
+			value = req.IP()
+		}
+	case RateLimitByCoupon:
+		if req.Coupon != nil {
+			value = rateLimitCouponKey(req.Coupon())
+		}
+	}
+	return value, value != ""
+}
+
+// rateLimitCouponKey returns name if it can be a coupon's name, and "" otherwise. The name
+// comes from the request, so without this each made-up name would get its own bucket;
+// requests for names that cannot exist fail validation or lookup anyway.
+func rateLimitCouponKey(name string) string {
+	if len(name) > maxCouponNameLength || !validation.IsName(name) {
+		return ""
+	}
+	return name
+}
+
+// ClientIP returns the caller's address without the port, or the first X-Forwarded-For
+// address when trusted
+func ClientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// claimBody holds the fields of a claim or queue body that rate limits are keyed by
+type claimBody struct {
+	CouponName string `json:"coupon_name"`
+	UserID     string `json:"user_id"`
+}
+
+// peekClaimBody returns a function reading the JSON body of r on its first call, once.
+// The body is restored for the handler.
+func peekClaimBody(r *http.Request) func() claimBody {
+	var (
+		body claimBody
+		read bool
+	)
+	return func() claimBody {
+		if read || r.Body == nil {
+			return body
+		}
+		read = true
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBody))
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), r.Body))
+		if err != nil {
+			return body
+		}
+		if json.Unmarshal(data, &body) != nil {
+			body = claimBody{}
+		}
+		return body
+	}
+}
+
+// setRateLimitHeaders writes the RateLimit-* headers from the IETF httpapi draft
+func setRateLimitHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+// ceilSeconds rounds d up to whole seconds, as headers carry integer seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
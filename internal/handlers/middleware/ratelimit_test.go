@@ -0,0 +1,298 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/ratelimit"
+)
+
+// failingStore errors on every Take
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store unavailable")
+}
+
+func newRateLimitedRouter(store ratelimit.Store, config *RateLimitConfig, identity *Identity, seenBody *string) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if identity != nil {
+				r = r.WithContext(WithIdentity(r.Context(), identity))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Use(RateLimitMiddleware(store, config))
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		if seenBody != nil {
+			body, _ := io.ReadAll(r.Body)
+			*seenBody = string(body)
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	router.HandleFunc("/api/coupons/claim", ok).Methods("POST")
+	router.HandleFunc("/api/coupons/{name}", ok).Methods("GET")
+	return router
+}
+
+func claimRequest(couponName, remoteAddr string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim",
+		bytes.NewBufferString(`{"user_id":"user1","coupon_name":"`+couponName+`"}`))
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+func TestRateLimitMiddleware_ByUser(t *testing.T) {
+	logger.Init()
+
+	config := &RateLimitConfig{Routes: map[string][]RateLimitRule{
+		"POST /api/coupons/claim": {{Key: RateLimitByUser, Requests: 2, Per: time.Minute}},
+	}}
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore(), config, &Identity{Subject: "user1"}, nil)
+
+	for i := 1; i >= 0; i-- {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, claimRequest("FLASH25", "10.0.0.1:1234"))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, strconv.Itoa(i), rec.Header().Get("RateLimit-Remaining"))
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, claimRequest("FLASH25", "10.0.0.1:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Too Many Requests","status":429,"detail":"Too many requests","code":"RATE_LIMITED"}`, rec.Body.String())
+
+	// Routes without rules are not limited
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/coupons/FLASH25", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitMiddleware_APIKeyLimitedPerUser(t *testing.T) {
+	logger.Init()
+
+	// A checkout service claims for every user through one key from one address
+	checkout := &Identity{APIKeyID: 7, Permissions: []Permission{PermCouponsClaim}}
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore(), DefaultRateLimitConfig(), checkout, nil)
+	claim := func(userID string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim",
+			bytes.NewBufferString(`{"user_id":"`+userID+`","coupon_name":"FLASH25"}`))
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// More claims than both the per-user and the per-IP limits allow, each for another user
+	for i := 0; i < 100; i++ {
+		require.Equal(t, http.StatusOK, claim("user"+strconv.Itoa(i)), "claim %d", i)
+	}
+
+	// Each user is still limited, whichever key claims for them
+	for i := 1; i < 10; i++ {
+		assert.Equal(t, http.StatusOK, claim("user0"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, claim("user0"))
+}
+
+func TestRateLimitMiddleware_ByIP(t *testing.T) {
+	logger.Init()
+
+	config := &RateLimitConfig{Routes: map[string][]RateLimitRule{
+		"POST /api/coupons/claim": {{Key: RateLimitByIP, Requests: 1, Per: time.Minute}},
+	}}
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore(), config, nil, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, claimRequest("FLASH25", "10.0.0.1:1234"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The port does not matter
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, claimRequest("FLASH25", "10.0.0.1:5678"))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	// X-Forwarded-For is ignored unless trusted
+	req := claimRequest("FLASH25", "10.0.0.1:1234")
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, claimRequest("FLASH25", "10.0.0.2:1234"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRateLimitMiddleware_TrustForwardedFor(t *testing.T) {
+	logger.Init()
+
+	config := &RateLimitConfig{
+		Routes: map[string][]RateLimitRule{
+			"POST /api/coupons/claim": {{Key: RateLimitByIP, Requests: 1, Per: time.Minute}},
+		},
+		TrustForwardedFor: true,
+	}
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore(), config, nil, nil)
+
+	for _, client := range []string{"203.0.113.9", "203.0.113.10, 10.0.0.1"} {
+		req := claimRequest("FLASH25", "10.0.0.1:1234")
+		req.Header.Set("X-Forwarded-For", client)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+}
+
+func TestRateLimitMiddleware_ByCoupon(t *testing.T) {
+	logger.Init()
+
+	config := &RateLimitConfig{Routes: map[string][]RateLimitRule{
+		"POST /api/coupons/claim": {{Key: RateLimitByCoupon, Requests: 1, Per: time.Second}},
+	}}
+	var seenBody string
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore(), config, nil, &seenBody)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, claimRequest("FLASH25", "10.0.0.1:1234"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	// The handler still sees the whole body
+	assert.JSONEq(t, `{"user_id":"user1","coupon_name":"FLASH25"}`, seenBody)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, claimRequest("FLASH25", "10.0.0.2:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, claimRequest("PROMO", "10.0.0.2:1234"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRateLimitMiddleware_ByCouponSkipsImpossibleNames(t *testing.T) {
+	logger.Init()
+
+	config := &RateLimitConfig{Routes: map[string][]RateLimitRule{
+		"POST /api/coupons/claim": {{Key: RateLimitByCoupon, Requests: 1, Per: time.Second}},
+	}}
+	store := ratelimit.NewMemoryStore()
+	router := newRateLimitedRouter(store, config, nil, nil)
+
+	// Names no coupon can have get no bucket of their own
+	for _, name := range []string{strings.Repeat("A", 256), "FLASH 25", "-FLASH25", "ÄPFEL"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, claimRequest(name, "10.0.0.1:1234"))
+		assert.Equal(t, http.StatusOK, rec.Code, name)
+	}
+	assert.Equal(t, 0, store.Len())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, claimRequest(strings.Repeat("A", 255), "10.0.0.1:1234"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestRateLimitMiddleware_ReportsTightestRule(t *testing.T) {
+	logger.Init()
+
+	config := &RateLimitConfig{Routes: map[string][]RateLimitRule{
+		"POST /api/coupons/claim": {
+			{Key: RateLimitByIP, Requests: 100, Per: time.Minute},
+			{Key: RateLimitByUser, Requests: 5, Per: time.Minute},
+		},
+	}}
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore(), config, &Identity{APIKeyID: 7}, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, claimRequest("FLASH25", "10.0.0.1:1234"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "5", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "4", rec.Header().Get("RateLimit-Remaining"))
+}
+
+func TestRateLimitMiddleware_StoreErrorFailsOpen(t *testing.T) {
+	logger.Init()
+
+	config := &RateLimitConfig{Routes: map[string][]RateLimitRule{
+		"POST /api/coupons/claim": {{Key: RateLimitByIP, Requests: 1, Per: time.Minute}},
+	}}
+	router := newRateLimitedRouter(failingStore{}, config, nil, nil)
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, claimRequest("FLASH25", "10.0.0.1:1234"))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+}
+
+// loadRateLimitConfig loads the default config as the API does, from the environment
+func loadRateLimitConfig() (*RateLimitConfig, error) {
+	config := DefaultRateLimitConfig()
+	if err := config.LoadEnv(); err != nil {
+		return nil, err
+	}
+	return config, config.Validate()
+}
+
+func TestRateLimitConfig_LoadEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT_FILE", "")
+	t.Setenv("RATE_LIMIT_TRUST_FORWARDED_FOR", "")
+	config, err := loadRateLimitConfig()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRateLimitConfig(), config)
+
+	t.Setenv("RATE_LIMIT_TRUST_FORWARDED_FOR", "1")
+	config, err = loadRateLimitConfig()
+	require.NoError(t, err)
+	assert.True(t, config.TrustForwardedFor)
+
+	t.Setenv("RATE_LIMIT_TRUST_FORWARDED_FOR", "yes")
+	_, err = loadRateLimitConfig()
+	assert.EqualError(t, err, `invalid RATE_LIMIT_TRUST_FORWARDED_FOR: "yes"`)
+}
+
+func TestRateLimitConfig_LoadEnv_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimit.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"routes": {"GET /api/coupons/{name}": [{"key": "ip", "requests": 30, "per": "10s", "burst": 5}]},
+		"trust_forwarded_for": true
+	}`), 0o600))
+	t.Setenv("RATE_LIMIT_FILE", path)
+	t.Setenv("RATE_LIMIT_TRUST_FORWARDED_FOR", "")
+
+	config, err := loadRateLimitConfig()
+	require.NoError(t, err)
+	assert.True(t, config.TrustForwardedFor)
+	assert.Equal(t, []RateLimitRule{{Key: RateLimitByIP, Requests: 30, Per: 10 * time.Second, Burst: 5}},
+		config.Routes["GET /api/coupons/{name}"])
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"routes": {"POST /api/coupons/claim": [{"key": "device", "requests": 1, "per": "1s"}]}}`), 0o600))
+	_, err = loadRateLimitConfig()
+	assert.EqualError(t, err, `route POST /api/coupons/claim: unknown rate limit key "device"`)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"routes": {"POST /api/coupons/claim": [{"key": "ip", "requests": 1, "per": "soon"}]}}`), 0o600))
+	_, err = loadRateLimitConfig()
+	assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/pkg/couponcode"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+)
+
+// Roles a caller can hold, read from the token's roles claim
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleCustomer = "customer"
+	RoleReadOnly = "read-only"
+)
+
+// Permission names an operation a route requires
+type Permission string
+
+// Permissions declared by routes. PermCouponsClaimAttributes is checked by AuthorizeClaim.
+const (
+	PermCouponsCreate          Permission = "coupons:create"
+	PermCouponsUpdate          Permission = "coupons:update"
+	PermCouponsRead            Permission = "coupons:read"
+	PermCouponsClaim           Permission = "coupons:claim"
+	PermCouponsClaimAttributes Permission = "coupons:claim_attributes"
+	PermCouponsExport          Permission = "coupons:export"
+	PermCouponsImport          Permission = "coupons:import"
+	PermCodesRead              Permission = "codes:read"
+	PermCodesRedeem            Permission = "codes:redeem"
+	PermAccessListsRead        Permission = "access_lists:read"
+	PermAccessListsManage      Permission = "access_lists:manage"
+	PermAPIKeysManage          Permission = "api_keys:manage"
+	PermLotteryDraw            Permission = "lottery:draw"
+	PermLogsManage             Permission = "logs:manage"
+)
+
+// rolePermissions grants each role its permissions. Admins may do everything.
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermCouponsCreate, PermCouponsUpdate, PermCouponsRead, PermCouponsClaim,
+		PermCouponsClaimAttributes, PermCouponsExport, PermCouponsImport, PermCodesRead, PermCodesRedeem,
+		PermAccessListsRead, PermAccessListsManage, PermAPIKeysManage, PermLotteryDraw,
+		PermLogsManage,
+	},
+	RoleOperator: {
+		PermCouponsRead, PermCouponsExport, PermCodesRead, PermCodesRedeem, PermAccessListsRead,
+	},
+	RoleCustomer: {
+		PermCouponsRead, PermCouponsClaim,
+	},
+	RoleReadOnly: {
+		PermCouponsRead, PermCouponsExport, PermCodesRead, PermAccessListsRead,
+	},
+}
+
+// Error codes returned with 401 and 403 responses
+const (
+	ErrorCodeUnauthenticated = "UNAUTHENTICATED"
+	ErrorCodeInvalidToken    = "INVALID_TOKEN"
+	ErrorCodeInvalidAPIKey   = "INVALID_API_KEY"
+	ErrorCodeForbidden       = "FORBIDDEN"
+)
+
+// IsPermission reports whether scope names a permission granted by some role, which
+// makes it a valid API key scope
+func IsPermission(scope string) bool {
+	return HasPermission([]string{RoleAdmin}, Permission(scope))
+}
+
+// HasPermission reports whether any of roles grants perm. Unknown roles grant nothing.
+func HasPermission(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// couponScopedInHandler lists permissions whose handlers check the coupon themselves
+// because it is not part of the path
+var couponScopedInHandler = map[Permission]bool{
+	PermCouponsClaim: true,
+}
+
+// RequirePermission only lets through authenticated callers whose roles or scopes grant perm.
+// Callers restricted to specific coupons must also be allowed the coupon named by the
+// {name} or {code} path variable; routes without one are closed to them.
+func RequirePermission(perm Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := IdentityFromContext(r.Context())
+			if !ok {
+				logger.Print(r.Context(), logger.LevelWarn, "Unauthenticated request")
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				pkgRest.RespondWithError(w, http.StatusUnauthorized, ErrorCodeUnauthenticated, "Authentication required")
+				return
+			}
+
+			if !identity.HasPermission(perm) {
+				logger.Print(r.Context(), logger.LevelWarn, "Permission denied: "+string(perm))
+				pkgRest.RespondWithError(w, http.StatusForbidden, ErrorCodeForbidden, "Missing permission "+string(perm))
+				return
+			}
+
+			if len(identity.CouponNames) > 0 && !couponScopedInHandler[perm] && !allowsRouteCoupon(identity, r) {
+				logger.Print(r.Context(), logger.LevelWarn, "Coupon not in api key scope")
+				pkgRest.RespondWithError(w, http.StatusForbidden, ErrorCodeForbidden, "Coupon is outside this API key's scope")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowsRouteCoupon checks the coupon named by the route's path variables against the caller's scope
+func allowsRouteCoupon(identity *Identity, r *http.Request) bool {
+	vars := mux.Vars(r)
+	if name, ok := vars["name"]; ok {
+		return identity.AllowsCoupon(name)
+	}
+	if code, ok := vars["code"]; ok {
+		prefix, err := couponcode.Prefix(code)
+		return err == nil && identity.AllowsCoupon(prefix)
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/pkg/couponcode"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+func TestHasPermission(t *testing.T) {
+	assert.True(t, HasPermission([]string{RoleAdmin}, PermCouponsCreate))
+	assert.False(t, HasPermission([]string{RoleOperator}, PermCouponsCreate))
+	assert.True(t, HasPermission([]string{RoleCustomer, RoleOperator}, PermCodesRedeem))
+	assert.False(t, HasPermission([]string{"superuser"}, PermCouponsRead))
+	assert.False(t, HasPermission(nil, PermCouponsRead))
+}
+
+func TestRequirePermission(t *testing.T) {
+	logger.Init()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := RequirePermission(PermCouponsCreate)(next)
+
+	tests := []struct {
+		name     string
+		identity *Identity
+		status   int
+	}{
+		{"unauthenticated", nil, http.StatusUnauthorized},
+		{"customer", &Identity{Subject: "user1", Roles: []string{RoleCustomer}}, http.StatusForbidden},
+		{"admin", &Identity{Subject: "admin1", Roles: []string{RoleAdmin}}, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons", nil)
+			if tt.identity != nil {
+				req = req.WithContext(WithIdentity(req.Context(), tt.identity))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestRequirePermission_CouponScopedAPIKey(t *testing.T) {
+	logger.Init()
+
+	identity := &Identity{
+		APIKeyID:    7,
+		Permissions: []Permission{PermCouponsRead, PermCodesRedeem, PermCouponsExport},
+		CouponNames: []string{"FLASH25"},
+	}
+
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		})
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.Handle("/coupons/export", RequirePermission(PermCouponsExport)(ok))
+	router.Handle("/coupons/{name}", RequirePermission(PermCouponsRead)(ok))
+	router.Handle("/codes/{code}/redeem", RequirePermission(PermCodesRedeem)(ok))
+
+	inScope, _ := couponcode.Generate("FLASH25")
+	outOfScope, _ := couponcode.Generate("OTHER")
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/coupons/FLASH25", http.StatusNoContent},
+		{"/coupons/OTHER", http.StatusForbidden},
+		{"/coupons/export", http.StatusForbidden},
+		{"/codes/" + inScope + "/redeem", http.StatusNoContent},
+		{"/codes/" + outOfScope + "/redeem", http.StatusForbidden},
+		{"/codes/FLASH25-typo/redeem", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestIsPermission(t *testing.T) {
+	assert.True(t, IsPermission("coupons:claim"))
+	assert.False(t, IsPermission("coupons:delete"))
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// unknownRoute labels requests whose route has no path template, such as those no route
+// matches
+const unknownRoute = "unknown"
+
+// routeMatchKey is the context key of the route MatchRoute resolved for a request
+type routeMatchKey struct{}
+
+// routeMatch is the route template and variables of a request. pathTemplate is the
+// template of the route matching the path, set even when the method did not match.
+type routeMatch struct {
+	template     string
+	pathTemplate string
+	vars         map[string]string
+}
+
+// MatchRoute resolves the route router will serve each request with before passing it on,
+// so middleware wrapping the whole router can name the route and redact its variables.
+// Wrapping the router, instead of installing middleware with Router.Use, is what lets
+// requests no route matches be traced, logged and counted too.
+func MatchRoute(router *mux.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			matched := &routeMatch{template: unknownRoute}
+			var match mux.RouteMatch
+			if router.Match(r, &match) && match.Route != nil {
+				if template, err := match.Route.GetPathTemplate(); err == nil {
+					matched.template = template
+					matched.pathTemplate = template
+				}
+				matched.vars = match.Vars
+			} else if match.MatchErr == mux.ErrMethodMismatch {
+				matched.pathTemplate, matched.vars = mismatchedRoute(router, r)
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeMatchKey{}, matched)))
+		})
+	}
+}
+
+// errRouteFound stops the walk of mismatchedRoute
+var errRouteFound = errors.New("route found")
+
+// mismatchedRoute returns the path template and variables of the route matching the path
+// of r but not its method. The router does not resolve them on a method mismatch, yet the
+// path still carries them and must be redacted all the same.
+func mismatchedRoute(router *mux.Router, r *http.Request) (string, map[string]string) {
+	var (
+		template string
+		vars     map[string]string
+	)
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil || len(methods) == 0 {
+			return nil
+		}
+		probe := *r
+		probe.Method = methods[0]
+		var match mux.RouteMatch
+		if route.Match(&probe, &match) {
+			template, _ = route.GetPathTemplate()
+			vars = match.Vars
+			return errRouteFound
+		}
+		return nil
+	})
+	return template, vars
+}
+
+// routeTemplate returns the path template of the route matched for r, or unknownRoute
+func routeTemplate(r *http.Request) string {
+	if matched, ok := r.Context().Value(routeMatchKey{}).(*routeMatch); ok {
+		return matched.template
+	}
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return unknownRoute
+}
+
+// routePathTemplate returns the path template of the route matching the path of r, even
+// when its method did not match, or "" when no route does
+func routePathTemplate(r *http.Request) string {
+	if matched, ok := r.Context().Value(routeMatchKey{}).(*routeMatch); ok {
+		return matched.pathTemplate
+	}
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return ""
+}
+
+// expandTemplate replaces each {name} or {name:pattern} variable of template with
+// value(name). Patterns may contain braces of their own, as in {code:[0-9]{4}}.
+func expandTemplate(template string, value func(name string) string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			b.WriteString(template)
+			return b.String()
+		}
+		end, depth := start, 0
+		for ; end < len(template); end++ {
+			if template[end] == '{' {
+				depth++
+			} else if template[end] == '}' {
+				if depth--; depth == 0 {
+					break
+				}
+			}
+		}
+		if end == len(template) {
+			b.WriteString(template)
+			return b.String()
+		}
+
+		name, _, _ := strings.Cut(template[start+1:end], ":")
+		b.WriteString(template[:start])
+		b.WriteString(value(name))
+		template = template[end+1:]
+	}
+}
+
+// routeVars returns the variables of the route matched for r
+func routeVars(r *http.Request) map[string]string {
+	if matched, ok := r.Context().Value(routeMatchKey{}).(*routeMatch); ok {
+		return matched.vars
+	}
+	return mux.Vars(r)
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wazadio/coupon-system/internal/metrics"
+)
+
+// wrapRouter wraps router the way the API server does
+func wrapRouter(router *mux.Router) http.Handler {
+	var handler http.Handler = router
+	handler = MetricsMiddleware(handler)
+	handler = LoggingMiddleware(nil)(handler)
+	handler = TracingMiddleware(handler)
+	return MatchRoute(router)(handler)
+}
+
+func TestMatchRoute_UnmatchedRequests(t *testing.T) {
+	logs := observeLogs(t)
+	recorder := recordSpans()
+
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	router.HandleFunc("/api/admin/denylist/{user_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods("DELETE")
+	handler := wrapRouter(router)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		route  string
+	}{
+		{"no route", http.MethodGet, "/api/nowhere", http.StatusNotFound, unknownRoute},
+		{"wrong method", http.MethodGet, "/api/admin/denylist/user-42", http.StatusMethodNotAllowed, unknownRoute},
+		{"matched", http.MethodDelete, "/api/admin/denylist/user-42", http.StatusNoContent, "/api/admin/denylist/{user_id}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.HTTPRequests.WithLabelValues(tt.route, tt.method, strconv.Itoa(tt.status))
+			before := testutil.ToFloat64(counter)
+			logsBefore := logs.FilterMessage("Request completed").Len()
+			spansBefore := len(recorder.Ended())
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.NotEmpty(t, rec.Header().Get("X-Trace-ID"))
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+
+			entries := logs.FilterMessage("Request completed").All()
+			require.Len(t, entries, logsBefore+1)
+			assert.Equal(t, tt.route, entries[logsBefore].ContextMap()["route"])
+
+			// The path is redacted whenever a route matches it, whatever the method
+			assert.NotContains(t, entries[logsBefore].ContextMap()["path"], "user-42")
+
+			spans := recorder.Ended()
+			require.Len(t, spans, spansBefore+1)
+			if tt.route == unknownRoute {
+				assert.Equal(t, tt.method, spans[spansBefore].Name())
+			} else {
+				assert.Equal(t, tt.method+" "+tt.route, spans[spansBefore].Name())
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spanRecorder = tracetest.NewSpanRecorder()
+	tracingOnce  sync.Once
+)
+
+// recordSpans installs a tracer provider recording into spanRecorder. The global provider
+// can only be replaced once for tracers already handed out, so it is shared by every test.
+func recordSpans() *tracetest.SpanRecorder {
+	tracingOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return spanRecorder
+}
+
+func TestTracingMiddleware_ContinuesCallerTrace(t *testing.T) {
+	logger.Init()
+	recorder := recordSpans()
+
+	router := mux.NewRouter()
+	router.Use(TracingMiddleware)
+	router.Use(LoggingMiddleware(nil))
+	router.HandleFunc("/api/coupons/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods("GET")
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/FLASH25", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	req.Header.Set("X-Trace-ID", "ignored-when-traced")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	// The logs, and so the response, carry the trace ID of the caller's trace
+	assert.Equal(t, traceID, rec.Header().Get("X-Trace-ID"))
+
+	var span sdktrace.ReadOnlySpan
+	for _, ended := range recorder.Ended() {
+		if ended.SpanContext().TraceID().String() == traceID {
+			span = ended
+		}
+	}
+	require.NotNil(t, span)
+	assert.Equal(t, "GET /api/coupons/{name}", span.Name())
+	assert.Equal(t, parentSpanID, span.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusInternalServerError))
+	assert.Contains(t, span.Attributes(), attribute.String("url.path", "/api/coupons/FLASH25"))
+}
+
+func TestTracingMiddleware_StartsTraceWithoutTraceparent(t *testing.T) {
+	logger.Init()
+	recordSpans()
+
+	router := mux.NewRouter()
+	router.Use(TracingMiddleware)
+	router.Use(LoggingMiddleware(nil))
+	router.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+
+	// A new W3C trace ID, not a UUID
+	assert.Regexp(t, "^[0-9a-f]{32}$", rec.Header().Get("X-Trace-ID"))
+}
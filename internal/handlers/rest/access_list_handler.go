@@ -0,0 +1,147 @@
+package rest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/service"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+)
+
+// AccessListHandler handles admin HTTP requests for coupon allowlists and the user denylist
+type AccessListHandler struct {
+	service service.AccessListService
+}
+
+// NewAccessListHandler creates a new AccessListHandler with injected service
+func NewAccessListHandler(service service.AccessListService) *AccessListHandler {
+	return &AccessListHandler{
+		service: service,
+	}
+}
+
+// AddToAllowlist handles POST /api/admin/coupons/{name}/allowlist
+func (h *AccessListHandler) AddToAllowlist(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	req, err := parseAccessListRequest(w, r)
+	if err != nil {
+		RespondWithInvalidBody(w, r, err)
+		return
+	}
+
+	resp, err := h.service.AddToAllowlist(r.Context(), name, req.UserIDs)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// ListAllowlist handles GET /api/admin/coupons/{name}/allowlist
+func (h *AccessListHandler) ListAllowlist(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	entries, err := h.service.ListAllowlist(r.Context(), name)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, entries)
+}
+
+// RemoveFromAllowlist handles DELETE /api/admin/coupons/{name}/allowlist/{user_id}
+func (h *AccessListHandler) RemoveFromAllowlist(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.service.RemoveFromAllowlist(r.Context(), vars["name"], vars["user_id"]); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, models.MessageResponse{Message: "User removed from allowlist"})
+}
+
+// AddToDenylist handles POST /api/admin/denylist
+func (h *AccessListHandler) AddToDenylist(w http.ResponseWriter, r *http.Request) {
+	req, err := parseAccessListRequest(w, r)
+	if err != nil {
+		RespondWithInvalidBody(w, r, err)
+		return
+	}
+
+	resp, err := h.service.AddToDenylist(r.Context(), req.UserIDs, req.Reason)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// ListDenylist handles GET /api/admin/denylist
+func (h *AccessListHandler) ListDenylist(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.service.ListDenylist(r.Context())
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, entries)
+}
+
+// RemoveFromDenylist handles DELETE /api/admin/denylist/{user_id}
+func (h *AccessListHandler) RemoveFromDenylist(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.RemoveFromDenylist(r.Context(), mux.Vars(r)["user_id"]); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, models.MessageResponse{Message: "User removed from denylist"})
+}
+
+// parseAccessListRequest reads a bulk upload either as JSON ({"user_ids": [...], "reason": "..."})
+// or as a CSV file with one user ID in the first column of each row. A CSV header row named
+// user_id is skipped, and the denylist reason can be passed as the ?reason= query parameter.
+func parseAccessListRequest(w http.ResponseWriter, r *http.Request) (*models.AccessListRequest, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType != contentTypeCSV {
+		var req models.AccessListRequest
+		if err := pkgRest.DecodeJSON(w, r, &req); err != nil {
+			return nil, err
+		}
+		return &req, nil
+	}
+
+	reader := csv.NewReader(r.Body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	req := &models.AccessListRequest{Reason: r.URL.Query().Get("reason")}
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+
+		id := strings.TrimSpace(record[0])
+		if first && strings.EqualFold(id, "user_id") {
+			continue
+		}
+		req.UserIDs = append(req.UserIDs, id)
+	}
+
+	return req, nil
+}
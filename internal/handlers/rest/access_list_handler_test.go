@@ -0,0 +1,190 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+// MockAccessListService is a mock implementation of AccessListService
+type MockAccessListService struct {
+	mock.Mock
+}
+
+func (m *MockAccessListService) AddToAllowlist(ctx context.Context, couponName string, userIDs []string) (*models.AccessListResponse, error) {
+	args := m.Called(couponName, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AccessListResponse), args.Error(1)
+}
+
+func (m *MockAccessListService) ListAllowlist(ctx context.Context, couponName string) ([]models.AllowlistEntry, error) {
+	args := m.Called(couponName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AllowlistEntry), args.Error(1)
+}
+
+func (m *MockAccessListService) RemoveFromAllowlist(ctx context.Context, couponName, userID string) error {
+	args := m.Called(couponName, userID)
+	return args.Error(0)
+}
+
+func (m *MockAccessListService) AddToDenylist(ctx context.Context, userIDs []string, reason string) (*models.AccessListResponse, error) {
+	args := m.Called(userIDs, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AccessListResponse), args.Error(1)
+}
+
+func (m *MockAccessListService) ListDenylist(ctx context.Context) ([]models.DenylistEntry, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DenylistEntry), args.Error(1)
+}
+
+func (m *MockAccessListService) RemoveFromDenylist(ctx context.Context, userID string) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func serveAccessList(handler *AccessListHandler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router := newTestRouter(handler, middleware.RoleAdmin)
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAddToAllowlist_Handler_JSON(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockAccessListService)
+	handler := NewAccessListHandler(mockService)
+
+	mockService.On("AddToAllowlist", "B2B", []string{"user1", "user2"}).
+		Return(&models.AccessListResponse{Submitted: 2, Added: 2}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/coupons/B2B/allowlist", strings.NewReader(`{"user_ids":["user1","user2"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	rec := serveAccessList(handler, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"submitted":2,"added":2}`, rec.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestAddToAllowlist_Handler_CSV(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockAccessListService)
+	handler := NewAccessListHandler(mockService)
+
+	mockService.On("AddToAllowlist", "B2B", []string{"user1", "user2"}).
+		Return(&models.AccessListResponse{Submitted: 2, Added: 1}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/coupons/B2B/allowlist", strings.NewReader("user_id\nuser1\nuser2\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := serveAccessList(handler, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAddToAllowlist_Handler_CouponNotFound(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockAccessListService)
+	handler := NewAccessListHandler(mockService)
+
+	mockService.On("AddToAllowlist", "NONEXISTENT", []string{"user1"}).Return(nil, repository.ErrCouponNotFound)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/coupons/NONEXISTENT/allowlist", strings.NewReader(`{"user_ids":["user1"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := serveAccessList(handler, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAddToAllowlist_Handler_InvalidJSON(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockAccessListService)
+	handler := NewAccessListHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/coupons/B2B/allowlist", strings.NewReader(`{invalid`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := serveAccessList(handler, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "AddToAllowlist")
+}
+
+func TestRemoveFromAllowlist_Handler_NotListed(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockAccessListService)
+	handler := NewAccessListHandler(mockService)
+
+	mockService.On("RemoveFromAllowlist", "B2B", "user1").Return(service.ErrUserNotListed)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/coupons/B2B/allowlist/user1", nil)
+	rec := serveAccessList(handler, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAddToDenylist_Handler_CSVWithReason(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockAccessListService)
+	handler := NewAccessListHandler(mockService)
+
+	mockService.On("AddToDenylist", []string{"abuser1", "abuser2"}, "fraud").
+		Return(&models.AccessListResponse{Submitted: 2, Added: 2}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/denylist?reason=fraud", strings.NewReader("abuser1\nabuser2\n"))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	rec := serveAccessList(handler, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestListDenylist_Handler_Success(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockAccessListService)
+	handler := NewAccessListHandler(mockService)
+
+	mockService.On("ListDenylist").Return([]models.DenylistEntry{{UserID: "abuser", Reason: "fraud"}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/denylist", nil)
+	rec := serveAccessList(handler, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response []models.DenylistEntry
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Len(t, response, 1)
+	assert.Equal(t, "abuser", response[0].UserID)
+	mockService.AssertExpectations(t)
+}
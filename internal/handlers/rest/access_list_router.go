@@ -0,0 +1,18 @@
+package rest
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+)
+
+// SetupRouter creates and configures the HTTP router with injected dependencies
+func (h *AccessListHandler) SetupRouter(router *mux.Router) {
+	api := router.PathPrefix("/admin").Subrouter()
+
+	handle(api, "/coupons/{name}/allowlist", middleware.PermAccessListsRead, h.ListAllowlist, "GET")
+	handle(api, "/coupons/{name}/allowlist", middleware.PermAccessListsManage, h.AddToAllowlist, "POST")
+	handle(api, "/coupons/{name}/allowlist/{user_id}", middleware.PermAccessListsManage, h.RemoveFromAllowlist, "DELETE")
+	handle(api, "/denylist", middleware.PermAccessListsRead, h.ListDenylist, "GET")
+	handle(api, "/denylist", middleware.PermAccessListsManage, h.AddToDenylist, "POST")
+	handle(api, "/denylist/{user_id}", middleware.PermAccessListsManage, h.RemoveFromDenylist, "DELETE")
+}
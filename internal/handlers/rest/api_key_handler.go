@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/service"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+)
+
+// APIKeyHandler handles admin HTTP requests for API keys
+type APIKeyHandler struct {
+	service service.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler with injected service
+func NewAPIKeyHandler(service service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+	}
+}
+
+// CreateAPIKey handles POST /api/admin/api-keys
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIKeyRequest
+
+	if err := pkgRest.DecodeJSON(w, r, &req); err != nil {
+		RespondWithInvalidBody(w, r, err)
+		return
+	}
+
+	resp, err := h.service.CreateAPIKey(r.Context(), &req)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusCreated, resp)
+}
+
+// ListAPIKeys handles GET /api/admin/api-keys
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListAPIKeys(r.Context())
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, keys)
+}
+
+// RevokeAPIKey handles DELETE /api/admin/api-keys/{id}
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		pkgRest.RespondWithProblem(w, &pkgRest.Problem{
+			Status: http.StatusBadRequest,
+			Code:   ErrorCodeValidationFailed,
+			Detail: "Invalid API key ID",
+			Errors: []pkgRest.FieldError{{Field: "id", Message: "must be an integer"}},
+		})
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), id); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, models.MessageResponse{Message: "API key revoked"})
+}
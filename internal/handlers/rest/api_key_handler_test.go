@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/validation"
+)
+
+// MockAPIKeyService is a mock implementation of APIKeyService
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) CreateAPIKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreateAPIKeyResponse), args.Error(1)
+}
+
+func (m *MockAPIKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RevokeAPIKey(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func TestCreateAPIKey_Handler_Success(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	reqBody := &models.CreateAPIKeyRequest{Name: "checkout", Scopes: []string{"coupons:claim"}}
+	mockService.On("CreateAPIKey", reqBody).Return(&models.CreateAPIKeyResponse{
+		APIKey: models.APIKey{ID: 5, Name: "checkout", Prefix: "a1b2c3", Scopes: []string{"coupons:claim"}},
+		Key:    "cpk_a1b2c3_secret",
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/api-keys", strings.NewReader(`{"name":"checkout","scopes":["coupons:claim"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	newTestRouter(handler, middleware.RoleAdmin).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var response models.CreateAPIKeyResponse
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, "cpk_a1b2c3_secret", response.Key)
+	assert.Equal(t, int64(5), response.ID)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateAPIKey_Handler_InvalidScope(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	reqBody := &models.CreateAPIKeyRequest{Name: "checkout", Scopes: []string{"coupons:delete"}}
+	mockService.On("CreateAPIKey", reqBody).Return(nil, validation.Invalid("scopes", `unknown scope "coupons:delete"`))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/api-keys", strings.NewReader(`{"name":"checkout","scopes":["coupons:delete"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	newTestRouter(handler, middleware.RoleAdmin).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRevokeAPIKey_Handler(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	mockService.On("RevokeAPIKey", int64(5)).Return(nil)
+	mockService.On("RevokeAPIKey", int64(9)).Return(repository.ErrAPIKeyNotFound)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/api/admin/api-keys/5", http.StatusOK},
+		{"/api/admin/api-keys/9", http.StatusNotFound},
+		{"/api/admin/api-keys/abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		newTestRouter(handler, middleware.RoleAdmin).ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, tt.path, nil))
+		assert.Equal(t, tt.status, rec.Code, tt.path)
+	}
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,15 @@
+package rest
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+)
+
+// SetupRouter creates and configures the HTTP router with injected dependencies
+func (h *APIKeyHandler) SetupRouter(router *mux.Router) {
+	api := router.PathPrefix("/admin/api-keys").Subrouter()
+
+	handle(api, "", middleware.PermAPIKeysManage, h.ListAPIKeys, "GET")
+	handle(api, "", middleware.PermAPIKeysManage, h.CreateAPIKey, "POST")
+	handle(api, "/{id}", middleware.PermAPIKeysManage, h.RevokeAPIKey, "DELETE")
+}
@@ -3,6 +3,7 @@ package rest
 import (
 	"net/http"
 
+	"github.com/wazadio/coupon-system/internal/models"
 	"github.com/wazadio/coupon-system/pkg/rest"
 )
 
@@ -10,5 +11,5 @@ type BaseHandler struct{}
 
 // healthCheck endpoint to verify the service is running
 func (h *BaseHandler) healthCheck(w http.ResponseWriter, r *http.Request) {
-	rest.RespondWithJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+	rest.RespondWithJSON(w, http.StatusOK, models.HealthResponse{Status: "healthy"})
 }
@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/service"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+)
+
+// CodeHandler handles HTTP requests for generated single-use coupon codes
+type CodeHandler struct {
+	service service.CouponService
+}
+
+// NewCodeHandler creates a new CodeHandler with injected service
+func NewCodeHandler(service service.CouponService) *CodeHandler {
+	return &CodeHandler{
+		service: service,
+	}
+}
+
+// LookupCode handles GET /api/codes/{code}
+func (h *CodeHandler) LookupCode(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+
+	details, err := h.service.LookupCode(r.Context(), code)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, details)
+}
+
+// RedeemCode handles POST /api/codes/{code}/redeem
+func (h *CodeHandler) RedeemCode(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+
+	redemption, err := h.service.RedeemCode(r.Context(), code)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, redemption)
+}
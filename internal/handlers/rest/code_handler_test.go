@@ -0,0 +1,145 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/couponcode"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+func TestLookupCode_Handler_Success(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCodeHandler(mockService)
+
+	expected := &models.CodeLookupResponse{
+		Code:   "FLASH25-7KQ2-M9XD",
+		Coupon: models.Coupon{ID: 1, Name: "FLASH25", Amount: 100, RemainingAmount: 99, UniqueCodes: true},
+		Claim:  &models.Claim{ID: 7, UserID: "user1", CouponName: "FLASH25", Code: "FLASH25-7KQ2-M9XD"},
+	}
+	mockService.On("LookupCode", "FLASH25-7KQ2-M9XD").Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/codes/FLASH25-7KQ2-M9XD", nil)
+	rec := httptest.NewRecorder()
+
+	router := newTestRouter(handler, middleware.RoleAdmin)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.CodeLookupResponse
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, "FLASH25", response.Coupon.Name)
+	assert.Equal(t, "user1", response.Claim.UserID)
+	mockService.AssertExpectations(t)
+}
+
+func TestLookupCode_Handler_NotFound(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCodeHandler(mockService)
+
+	mockService.On("LookupCode", "UNKNOWN").Return(nil, repository.ErrCodeNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/codes/UNKNOWN", nil)
+	rec := httptest.NewRecorder()
+
+	router := newTestRouter(handler, middleware.RoleAdmin)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Code not found", problem.Detail)
+	mockService.AssertExpectations(t)
+}
+
+func TestLookupCode_Handler_InternalError(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCodeHandler(mockService)
+
+	mockService.On("LookupCode", "FLASH25-7KQ2-M9XD").Return(nil, errors.New("database error"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/codes/FLASH25-7KQ2-M9XD", nil)
+	rec := httptest.NewRecorder()
+
+	router := newTestRouter(handler, middleware.RoleAdmin)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRedeemCode_Handler_Success(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCodeHandler(mockService)
+
+	expected := &models.CodeRedemption{Code: "FLASH25-7KQ2-M9XD-H4TC", CouponName: "FLASH25", UserID: "user1"}
+	mockService.On("RedeemCode", "FLASH25-7KQ2-M9XD-H4TC").Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/codes/FLASH25-7KQ2-M9XD-H4TC/redeem", nil)
+	rec := httptest.NewRecorder()
+
+	router := newTestRouter(handler, middleware.RoleAdmin)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.CodeRedemption
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, "user1", response.UserID)
+	mockService.AssertExpectations(t)
+}
+
+func TestRedeemCode_Handler_Errors(t *testing.T) {
+	tests := []struct {
+		err            error
+		expectedStatus int
+		expectedError  string
+	}{
+		{couponcode.ErrInvalidFormat, http.StatusBadRequest, "Malformed code"},
+		{couponcode.ErrInvalidChecksum, http.StatusBadRequest, "Code checksum mismatch, check the code for typos"},
+		{repository.ErrCodeNotFound, http.StatusNotFound, "Code not found"},
+		{repository.ErrCodeNotClaimed, http.StatusConflict, "Code has not been claimed"},
+		{repository.ErrCodeAlreadyRedeemed, http.StatusConflict, "Code already redeemed"},
+		{repository.ErrCodeExpired, http.StatusGone, "Code has expired"},
+		{errors.New("database error"), http.StatusInternalServerError, "An unexpected error occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expectedError, func(t *testing.T) {
+			logger.Init()
+
+			mockService := new(MockCouponService)
+			handler := NewCodeHandler(mockService)
+
+			mockService.On("RedeemCode", "FLASH25-7KQ2-M9XD-H4TC").Return(nil, tt.err)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/codes/FLASH25-7KQ2-M9XD-H4TC/redeem", nil)
+			rec := httptest.NewRecorder()
+
+			router := newTestRouter(handler, middleware.RoleAdmin)
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			problem := decodeProblem(t, rec)
+			assert.Equal(t, tt.expectedError, problem.Detail)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package rest
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+)
+
+// SetupRouter creates and configures the HTTP router with injected dependencies
+func (h *CodeHandler) SetupRouter(router *mux.Router) {
+	api := router.PathPrefix("/codes").Subrouter()
+
+	handle(api, "/{code}", middleware.PermCodesRead, h.LookupCode, "GET")
+	handle(api, "/{code}/redeem", middleware.PermCodesRedeem, h.RedeemCode, "POST")
+}
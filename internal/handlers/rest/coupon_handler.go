@@ -1,12 +1,12 @@
 package rest
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
 	"github.com/wazadio/coupon-system/internal/models"
-	"github.com/wazadio/coupon-system/internal/repository"
 	"github.com/wazadio/coupon-system/internal/service"
 	"github.com/wazadio/coupon-system/pkg/logger"
 	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
@@ -29,28 +29,19 @@ func (h *CouponHandler) CreateCoupon(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateCouponRequest
 
 	// Parse request body
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Print(r.Context(), logger.LevelError, err.Error())
-		pkgRest.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+	if err := pkgRest.DecodeJSON(w, r, &req); err != nil {
+		RespondWithInvalidBody(w, r, err)
 		return
 	}
 
 	// Create coupon
-	err := h.service.CreateCoupon(&req)
-	if err != nil {
-		if err == repository.ErrCouponAlreadyExists {
-			logger.Print(r.Context(), logger.LevelError, "Coupon already exists")
-			pkgRest.RespondWithError(w, http.StatusConflict, "Coupon already exists")
-			return
-		}
-
-		logger.Print(r.Context(), logger.LevelError, err.Error())
-		pkgRest.RespondWithError(w, http.StatusBadRequest, err.Error())
+	if err := h.service.CreateCoupon(r.Context(), &req); err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
 	// Return 201 Created
-	pkgRest.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": "Coupon created successfully"})
+	pkgRest.RespondWithJSON(w, http.StatusCreated, models.MessageResponse{Message: "Coupon created successfully"})
 }
 
 // ClaimCoupon handles POST /api/coupons/claim
@@ -58,37 +49,55 @@ func (h *CouponHandler) ClaimCoupon(w http.ResponseWriter, r *http.Request) {
 	var req models.ClaimCouponRequest
 
 	// Parse request body
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Print(r.Context(), logger.LevelError, err.Error())
-		pkgRest.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+	if err := pkgRest.DecodeJSON(w, r, &req); err != nil {
+		RespondWithInvalidBody(w, r, err)
+		return
+	}
+
+	if !authorizeClaimant(w, r, &req) {
 		return
 	}
 
 	// Attempt to claim coupon
-	err := h.service.ClaimCoupon(&req)
+	claim, err := h.service.ClaimCoupon(r.Context(), &req)
 	if err != nil {
-		switch err {
-		case repository.ErrAlreadyClaimed:
-			logger.Print(r.Context(), logger.LevelError, "User already claimed this coupon")
-			pkgRest.RespondWithError(w, http.StatusConflict, "User already claimed this coupon")
-			return
-		case repository.ErrNoStockAvailable:
-			logger.Print(r.Context(), logger.LevelError, "No stock available for this coupon")
-			pkgRest.RespondWithError(w, http.StatusBadRequest, "No stock available")
-			return
-		case repository.ErrCouponNotFound:
-			logger.Print(r.Context(), logger.LevelError, "Coupon not found")
-			pkgRest.RespondWithError(w, http.StatusNotFound, "Coupon not found")
-			return
-		default:
-			logger.Print(r.Context(), logger.LevelError, err.Error())
-			pkgRest.RespondWithError(w, http.StatusBadRequest, err.Error())
-			return
-		}
+		respondWithError(w, r, err)
+		return
+	}
+
+	// Lottery entries are accepted now and decided by the draw
+	if claim.Entry {
+		pkgRest.RespondWithJSON(w, http.StatusAccepted, models.ClaimCouponResponse{
+			Message: "Lottery entry recorded, winners are drawn when entries close",
+		})
+		return
 	}
 
-	// Return 200 OK
-	pkgRest.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Coupon claimed successfully"})
+	// Return 200 OK, including the assigned code for unique-code coupons
+	pkgRest.RespondWithJSON(w, http.StatusOK, models.ClaimCouponResponse{
+		Message: "Coupon claimed successfully",
+		Code:    claim.Code,
+	})
+}
+
+// authorizeClaimant sets the claiming user and attributes to the authenticated subject's and
+// checks API key coupon scopes, responding with 403 when the claim is not allowed
+func authorizeClaimant(w http.ResponseWriter, r *http.Request, req *models.ClaimCouponRequest) bool {
+	switch err := middleware.AuthorizeClaim(r.Context(), req); {
+	case errors.Is(err, middleware.ErrUserMismatch):
+		logger.Print(r.Context(), logger.LevelError, "user_id does not match the authenticated user")
+		pkgRest.RespondWithError(w, http.StatusForbidden, ErrorCodeUserMismatch, "user_id does not match the authenticated user")
+		return false
+	case errors.Is(err, middleware.ErrAttributesNotAllowed):
+		logger.Print(r.Context(), logger.LevelError, "attributes cannot be sent by this caller")
+		pkgRest.RespondWithError(w, http.StatusForbidden, ErrorCodeAttributesNotAllowed, "attributes cannot be sent by this caller")
+		return false
+	case errors.Is(err, middleware.ErrCouponOutOfScope):
+		logger.Print(r.Context(), logger.LevelError, "Coupon is outside this API key's scope")
+		pkgRest.RespondWithError(w, http.StatusForbidden, middleware.ErrorCodeForbidden, "Coupon is outside this API key's scope")
+		return false
+	}
+	return true
 }
 
 // GetCouponDetails handles GET /api/coupons/{name}
@@ -98,15 +107,9 @@ func (h *CouponHandler) GetCouponDetails(w http.ResponseWriter, r *http.Request)
 	name := vars["name"]
 
 	// Get coupon details
-	details, err := h.service.GetCouponDetails(name)
+	details, err := h.service.GetCouponDetails(r.Context(), name)
 	if err != nil {
-		if err == repository.ErrCouponNotFound {
-			logger.Print(r.Context(), logger.LevelError, "Coupon not found")
-			pkgRest.RespondWithError(w, http.StatusNotFound, "Coupon not found")
-			return
-		}
-		logger.Print(r.Context(), logger.LevelError, err.Error())
-		pkgRest.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -120,21 +123,15 @@ func (h *CouponHandler) UpdateCoupon(w http.ResponseWriter, r *http.Request) {
 	name := vars["name"]
 
 	// Update coupon
-	rowsAffected, err := h.service.UpdateCoupon(name)
+	rowsAffected, err := h.service.UpdateCoupon(r.Context(), name)
 	if err != nil {
-		if err == repository.ErrCouponNotFound {
-			logger.Print(r.Context(), logger.LevelError, "Coupon not found")
-			pkgRest.RespondWithError(w, http.StatusNotFound, "Coupon not found")
-			return
-		}
-		logger.Print(r.Context(), logger.LevelError, err.Error())
-		pkgRest.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
 	// Return update result
-	pkgRest.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
-		"message":       "Coupon updated successfully",
-		"rows_affected": rowsAffected,
+	pkgRest.RespondWithJSON(w, http.StatusOK, models.UpdateCouponResponse{
+		Message:      "Coupon updated successfully",
+		RowsAffected: rowsAffected,
 	})
 }
@@ -2,18 +2,24 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
 	"github.com/wazadio/coupon-system/internal/models"
 	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/service"
 	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+	"github.com/wazadio/coupon-system/pkg/validation"
 )
 
 // MockCouponService is a mock implementation of CouponService
@@ -21,17 +27,20 @@ type MockCouponService struct {
 	mock.Mock
 }
 
-func (m *MockCouponService) CreateCoupon(req *models.CreateCouponRequest) error {
+func (m *MockCouponService) CreateCoupon(ctx context.Context, req *models.CreateCouponRequest) error {
 	args := m.Called(req)
 	return args.Error(0)
 }
 
-func (m *MockCouponService) ClaimCoupon(req *models.ClaimCouponRequest) error {
+func (m *MockCouponService) ClaimCoupon(ctx context.Context, req *models.ClaimCouponRequest) (*models.Claim, error) {
 	args := m.Called(req)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Claim), args.Error(1)
 }
 
-func (m *MockCouponService) GetCouponDetails(name string) (*models.CouponDetailResponse, error) {
+func (m *MockCouponService) GetCouponDetails(ctx context.Context, name string) (*models.CouponDetailResponse, error) {
 	args := m.Called(name)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -39,11 +48,74 @@ func (m *MockCouponService) GetCouponDetails(name string) (*models.CouponDetailR
 	return args.Get(0).(*models.CouponDetailResponse), args.Error(1)
 }
 
-func (m *MockCouponService) UpdateCoupon(name string) (int64, error) {
+func (m *MockCouponService) UpdateCoupon(ctx context.Context, name string) (int64, error) {
 	args := m.Called(name)
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockCouponService) ImportCoupons(ctx context.Context, rows []models.ImportCouponRow) *models.ImportCouponsResponse {
+	args := m.Called(rows)
+	return args.Get(0).(*models.ImportCouponsResponse)
+}
+
+func (m *MockCouponService) ExportCoupons(ctx context.Context, fn func(*models.Coupon) error) error {
+	args := m.Called(fn)
+	return args.Error(0)
+}
+
+func (m *MockCouponService) ExportClaims(ctx context.Context, couponName string, fn func(*models.Claim) error) error {
+	args := m.Called(couponName, fn)
+	return args.Error(0)
+}
+
+func (m *MockCouponService) ListCoupons(ctx context.Context, page models.Page) (*models.CouponPage, error) {
+	args := m.Called(page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CouponPage), args.Error(1)
+}
+
+func (m *MockCouponService) GetCouponsByNames(ctx context.Context, names []string) (map[string]*models.Coupon, error) {
+	args := m.Called(names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponService) ListClaimsByCoupons(ctx context.Context, couponNames []string, page models.Page) (map[string]*models.ClaimPage, error) {
+	args := m.Called(couponNames, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.ClaimPage), args.Error(1)
+}
+
+func (m *MockCouponService) ListClaimsByUsers(ctx context.Context, userIDs []string, page models.Page) (map[string]*models.ClaimPage, error) {
+	args := m.Called(userIDs, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.ClaimPage), args.Error(1)
+}
+
+func (m *MockCouponService) LookupCode(ctx context.Context, code string) (*models.CodeLookupResponse, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeLookupResponse), args.Error(1)
+}
+
+func (m *MockCouponService) RedeemCode(ctx context.Context, code string) (*models.CodeRedemption, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeRedemption), args.Error(1)
+}
+
 func TestCreateCoupon_Handler_Success(t *testing.T) {
 	logger.Init()
 	mockService := new(MockCouponService)
@@ -58,6 +130,7 @@ func TestCreateCoupon_Handler_Success(t *testing.T) {
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
 	handler.CreateCoupon(rec, req)
@@ -77,15 +150,74 @@ func TestCreateCoupon_Handler_InvalidJSON(t *testing.T) {
 	handler := NewCouponHandler(mockService)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBuffer([]byte("invalid json")))
+	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
 	handler.CreateCoupon(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "Invalid request body", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Request body contains malformed JSON at position 1", problem.Detail)
+}
+
+func TestCreateCoupon_Handler_StrictBody(t *testing.T) {
+	logger.Init()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        string
+		detail      string
+	}{
+		{"unknown field", "application/json", `{"name":"FLASH25","ammount":10}`, http.StatusBadRequest, ErrorCodeInvalidRequestBody, `Request body contains unknown field "ammount"`},
+		{"wrong type", "application/json", `{"name":"FLASH25","amount":"10"}`, http.StatusBadRequest, ErrorCodeInvalidRequestBody, `Field "amount" must be a number, got string`},
+		{"trailing data", "application/json", `{"name":"FLASH25","amount":10}{"name":"X"}`, http.StatusBadRequest, ErrorCodeInvalidRequestBody, "Request body must contain a single JSON value"},
+		{"empty body", "application/json", "", http.StatusBadRequest, ErrorCodeInvalidRequestBody, "Request body must not be empty"},
+		{"missing content type", "", `{"name":"FLASH25","amount":10}`, http.StatusUnsupportedMediaType, ErrorCodeUnsupportedMediaType, "Content-Type must be application/json"},
+		{"form content type", "application/x-www-form-urlencoded", `name=FLASH25`, http.StatusUnsupportedMediaType, ErrorCodeUnsupportedMediaType, "Content-Type must be application/json"},
+		{"too large", "application/json", `{"name":"` + strings.Repeat("A", pkgRest.MaxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, ErrorCodeRequestTooLarge, "Request body must not be larger than 1048576 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCouponService)
+			handler := NewCouponHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.CreateCoupon(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			problem := decodeProblem(t, rec)
+			assert.Equal(t, tt.code, problem.Code)
+			assert.Equal(t, tt.detail, problem.Detail)
+			mockService.AssertNotCalled(t, "CreateCoupon", mock.Anything)
+		})
+	}
+}
+
+func TestCreateCoupon_Handler_AcceptsCharset(t *testing.T) {
+	logger.Init()
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	mockService.On("CreateCoupon", &models.CreateCouponRequest{Name: "FLASH25", Amount: 10}).Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", strings.NewReader(`{"name":"FLASH25","amount":10}`+"\n"))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	rec := httptest.NewRecorder()
+
+	handler.CreateCoupon(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockService.AssertExpectations(t)
 }
 
 func TestCreateCoupon_Handler_AlreadyExists(t *testing.T) {
@@ -102,15 +234,15 @@ func TestCreateCoupon_Handler_AlreadyExists(t *testing.T) {
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
 	handler.CreateCoupon(rec, req)
 
 	assert.Equal(t, http.StatusConflict, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "Coupon already exists", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Coupon already exists", problem.Detail)
 
 	mockService.AssertExpectations(t)
 }
@@ -125,19 +257,21 @@ func TestCreateCoupon_Handler_ValidationError(t *testing.T) {
 		Amount: 100,
 	}
 
-	mockService.On("CreateCoupon", reqBody).Return(errors.New("coupon name is required"))
+	mockService.On("CreateCoupon", reqBody).Return(validation.Invalid("name", "name is required"))
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/coupons", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
 	handler.CreateCoupon(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "coupon name is required", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "name is required", problem.Detail)
+	assert.Equal(t, ErrorCodeValidationFailed, problem.Code)
+	assert.Equal(t, []pkgRest.FieldError{{Field: "name", Message: "name is required"}}, problem.Errors)
 
 	mockService.AssertExpectations(t)
 }
@@ -152,10 +286,11 @@ func TestClaimCoupon_Handler_Success(t *testing.T) {
 		CouponName: "FLASH25",
 	}
 
-	mockService.On("ClaimCoupon", reqBody).Return(nil)
+	mockService.On("ClaimCoupon", reqBody).Return(&models.Claim{ID: 1, UserID: "user1", CouponName: "FLASH25"}, nil)
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
 	handler.ClaimCoupon(rec, req)
@@ -175,15 +310,15 @@ func TestClaimCoupon_Handler_InvalidJSON(t *testing.T) {
 	handler := NewCouponHandler(mockService)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer([]byte("invalid json")))
+	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
 	handler.ClaimCoupon(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "Invalid request body", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Request body contains malformed JSON at position 1", problem.Detail)
 }
 
 func TestClaimCoupon_Handler_AlreadyClaimed(t *testing.T) {
@@ -196,19 +331,19 @@ func TestClaimCoupon_Handler_AlreadyClaimed(t *testing.T) {
 		CouponName: "FLASH25",
 	}
 
-	mockService.On("ClaimCoupon", reqBody).Return(repository.ErrAlreadyClaimed)
+	mockService.On("ClaimCoupon", reqBody).Return(nil, repository.ErrAlreadyClaimed)
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
 	handler.ClaimCoupon(rec, req)
 
 	assert.Equal(t, http.StatusConflict, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "User already claimed this coupon", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "User already claimed this coupon", problem.Detail)
 
 	mockService.AssertExpectations(t)
 }
@@ -223,19 +358,19 @@ func TestClaimCoupon_Handler_NoStockAvailable(t *testing.T) {
 		CouponName: "FLASH25",
 	}
 
-	mockService.On("ClaimCoupon", reqBody).Return(repository.ErrNoStockAvailable)
+	mockService.On("ClaimCoupon", reqBody).Return(nil, repository.ErrNoStockAvailable)
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
 	handler.ClaimCoupon(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "No stock available", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "No stock available", problem.Detail)
 
 	mockService.AssertExpectations(t)
 }
@@ -250,19 +385,19 @@ func TestClaimCoupon_Handler_CouponNotFound(t *testing.T) {
 		CouponName: "NONEXISTENT",
 	}
 
-	mockService.On("ClaimCoupon", reqBody).Return(repository.ErrCouponNotFound)
+	mockService.On("ClaimCoupon", reqBody).Return(nil, repository.ErrCouponNotFound)
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
 	handler.ClaimCoupon(rec, req)
 
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "Coupon not found", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Coupon not found", problem.Detail)
 
 	mockService.AssertExpectations(t)
 }
@@ -277,19 +412,19 @@ func TestClaimCoupon_Handler_ValidationError(t *testing.T) {
 		CouponName: "FLASH25",
 	}
 
-	mockService.On("ClaimCoupon", reqBody).Return(errors.New("user_id is required"))
+	mockService.On("ClaimCoupon", reqBody).Return(nil, validation.Invalid("user_id", "user_id is required"))
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
 	handler.ClaimCoupon(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "user_id is required", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "user_id is required", problem.Detail)
 
 	mockService.AssertExpectations(t)
 }
@@ -344,9 +479,8 @@ func TestGetCouponDetails_Handler_NotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "Coupon not found", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Coupon not found", problem.Detail)
 
 	mockService.AssertExpectations(t)
 }
@@ -368,9 +502,8 @@ func TestGetCouponDetails_Handler_InternalError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "database error", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "An unexpected error occurred", problem.Detail)
 
 	mockService.AssertExpectations(t)
 }
@@ -417,9 +550,8 @@ func TestUpdateCoupon_Handler_NotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 
-	var response map[string]string
-	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "Coupon not found", response["error"])
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Coupon not found", problem.Detail)
 
 	mockService.AssertExpectations(t)
 }
@@ -441,9 +573,356 @@ func TestUpdateCoupon_Handler_InternalError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "An unexpected error occurred", problem.Detail)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestClaimCoupon_Handler_ReturnsUniqueCode(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	reqBody := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+	}
+
+	mockService.On("ClaimCoupon", reqBody).Return(&models.Claim{ID: 1, Code: "FLASH25-7KQ2-M9XD"}, nil)
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	handler.ClaimCoupon(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"message":"Coupon claimed successfully","code":"FLASH25-7KQ2-M9XD"}`, rec.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestClaimCoupon_Handler_CouponExpired(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	reqBody := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+	}
+
+	mockService.On("ClaimCoupon", reqBody).Return(nil, repository.ErrCouponExpired)
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	handler.ClaimCoupon(rec, req)
+
+	assert.Equal(t, http.StatusGone, rec.Code)
+
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Coupon has expired", problem.Detail)
+	mockService.AssertExpectations(t)
+}
+
+func TestClaimCoupon_Handler_QueueRequired(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	reqBody := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+	}
+
+	mockService.On("ClaimCoupon", reqBody).Return(nil, service.ErrQueueRequired)
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	handler.ClaimCoupon(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Coupon is in queue mode, join its queue instead", problem.Detail)
+	mockService.AssertExpectations(t)
+}
+
+func TestClaimCoupon_Handler_LotteryEntry(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	reqBody := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+	}
+
+	mockService.On("ClaimCoupon", reqBody).Return(&models.Claim{ID: 1, UserID: "user1", CouponName: "FLASH25", Entry: true}, nil)
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	handler.ClaimCoupon(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
 	var response map[string]string
 	json.Unmarshal(rec.Body.Bytes(), &response)
-	assert.Equal(t, "database error", response["error"])
+	assert.Equal(t, "Lottery entry recorded, winners are drawn when entries close", response["message"])
+	mockService.AssertExpectations(t)
+}
+
+func TestClaimCoupon_Handler_LotteryEntriesClosed(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	reqBody := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+	}
+
+	mockService.On("ClaimCoupon", reqBody).Return(nil, repository.ErrEntriesClosed)
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	handler.ClaimCoupon(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Lottery entries are closed", problem.Detail)
+	mockService.AssertExpectations(t)
+}
+
+func TestClaimCoupon_Handler_NotEligible(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	reqBody := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+		Attributes: map[string]interface{}{"tier": "silver"},
+	}
+
+	mockService.On("ClaimCoupon", reqBody).Return(nil, &service.EligibilityError{Rule: "gold_or_above"})
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	handler.ClaimCoupon(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, `user is not eligible for this coupon: rule "gold_or_above" failed`, problem.Detail)
+	mockService.AssertExpectations(t)
+}
+
+func TestClaimCoupon_Handler_AccessDenied(t *testing.T) {
+	logger.Init()
+
+	tests := []struct {
+		err     error
+		message string
+	}{
+		{repository.ErrUserDenied, "User is not allowed to claim coupons"},
+		{repository.ErrUserNotAllowed, "User is not on this coupon's allowlist"},
+	}
+
+	for _, tt := range tests {
+		mockService := new(MockCouponService)
+		handler := NewCouponHandler(mockService)
+
+		reqBody := &models.ClaimCouponRequest{
+			UserID:     "user1",
+			CouponName: "B2B",
+		}
+
+		mockService.On("ClaimCoupon", reqBody).Return(nil, tt.err)
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		handler.ClaimCoupon(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		problem := decodeProblem(t, rec)
+		assert.Equal(t, tt.message, problem.Detail)
+		mockService.AssertExpectations(t)
+	}
+}
+
+func TestClaimCoupon_Handler_UsesAuthenticatedUser(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	expected := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+	}
+
+	mockService.On("ClaimCoupon", expected).Return(&models.Claim{UserID: "user1", CouponName: "FLASH25"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(`{"coupon_name":"FLASH25"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.WithIdentity(req.Context(), &middleware.Identity{Subject: "user1"}))
+	rec := httptest.NewRecorder()
+
+	handler.ClaimCoupon(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestClaimCoupon_Handler_MismatchedUser(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(`{"user_id":"victim","coupon_name":"FLASH25"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.WithIdentity(req.Context(), &middleware.Identity{Subject: "user1"}))
+	rec := httptest.NewRecorder()
+
+	handler.ClaimCoupon(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "user_id does not match the authenticated user", problem.Detail)
+	mockService.AssertNotCalled(t, "ClaimCoupon", mock.Anything)
+}
+
+func TestClaimCoupon_Handler_APIKey(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	identity := &middleware.Identity{
+		APIKeyID:    7,
+		Permissions: []middleware.Permission{middleware.PermCouponsClaim},
+		CouponNames: []string{"FLASH25"},
+	}
+
+	// API keys act for backend services, so the user ID comes from the body
+	expected := &models.ClaimCouponRequest{UserID: "user1", CouponName: "FLASH25"}
+	mockService.On("ClaimCoupon", expected).Return(&models.Claim{UserID: "user1", CouponName: "FLASH25"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(`{"user_id":"user1","coupon_name":"FLASH25"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.WithIdentity(req.Context(), identity))
+	rec := httptest.NewRecorder()
+	handler.ClaimCoupon(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(`{"user_id":"user1","coupon_name":"OTHER"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.WithIdentity(req.Context(), identity))
+	rec = httptest.NewRecorder()
+	handler.ClaimCoupon(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
 
 	mockService.AssertExpectations(t)
 }
+
+func TestClaimCoupon_Handler_AttributesFromToken(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	customer := &middleware.Identity{Subject: "user1", Attributes: map[string]interface{}{"tier": "silver"}}
+	expected := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+		Attributes: map[string]interface{}{"tier": "silver"},
+	}
+	mockService.On("ClaimCoupon", expected).Return(&models.Claim{UserID: "user1", CouponName: "FLASH25"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(`{"coupon_name":"FLASH25"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.WithIdentity(req.Context(), customer))
+	rec := httptest.NewRecorder()
+	handler.ClaimCoupon(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Users cannot describe themselves, or they could pass any eligibility rule
+	req = httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(`{"coupon_name":"FLASH25","attributes":{"tier":"gold"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.WithIdentity(req.Context(), customer))
+	rec = httptest.NewRecorder()
+	handler.ClaimCoupon(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, ErrorCodeAttributesNotAllowed, decodeProblem(t, rec).Code)
+
+	mockService.AssertNumberOfCalls(t, "ClaimCoupon", 1)
+}
+
+func TestClaimCoupon_Handler_APIKeyAttributes(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	expected := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+		Attributes: map[string]interface{}{"tier": "gold"},
+	}
+	mockService.On("ClaimCoupon", expected).Return(&models.Claim{UserID: "user1", CouponName: "FLASH25"}, nil)
+
+	tests := []struct {
+		name        string
+		permissions []middleware.Permission
+		status      int
+	}{
+		{"without permission", []middleware.Permission{middleware.PermCouponsClaim}, http.StatusForbidden},
+		{"with permission", []middleware.Permission{middleware.PermCouponsClaim, middleware.PermCouponsClaimAttributes}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity := &middleware.Identity{APIKeyID: 7, Permissions: tt.permissions}
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", bytes.NewBufferString(`{"user_id":"user1","coupon_name":"FLASH25","attributes":{"tier":"gold"}}`))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(middleware.WithIdentity(req.Context(), identity))
+			rec := httptest.NewRecorder()
+
+			handler.ClaimCoupon(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+
+	mockService.AssertNumberOfCalls(t, "ClaimCoupon", 1)
+}
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
 )
 
 // SetupRouter creates and configures the HTTP router with injected dependencies
@@ -10,8 +11,14 @@ func (h *CouponHandler) SetupRouter(router *mux.Router) {
 	api := router.PathPrefix("/coupons").Subrouter()
 
 	// Coupon routes
-	api.HandleFunc("", h.CreateCoupon).Methods("POST")
-	api.HandleFunc("/claim", h.ClaimCoupon).Methods("POST")
-	api.HandleFunc("/{name}", h.GetCouponDetails).Methods("GET")
-	api.HandleFunc("/{name}", h.UpdateCoupon).Methods("PUT", "PATCH")
+	handle(api, "", middleware.PermCouponsCreate, h.CreateCoupon, "POST")
+	handle(api, "/claim", middleware.PermCouponsClaim, h.ClaimCoupon, "POST")
+
+	// Bulk import/export routes, registered before /{name} so they are not shadowed
+	handle(api, "/export", middleware.PermCouponsExport, h.ExportCoupons, "GET")
+	handle(api, "/import", middleware.PermCouponsImport, h.ImportCoupons, "POST")
+	handle(api, "/{name}/claims/export", middleware.PermCouponsExport, h.ExportClaims, "GET")
+
+	handle(api, "/{name}", middleware.PermCouponsRead, h.GetCouponDetails, "GET")
+	handle(api, "/{name}", middleware.PermCouponsUpdate, h.UpdateCoupon, "PUT", "PATCH")
 }
@@ -0,0 +1,438 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+	"github.com/wazadio/coupon-system/pkg/validation"
+	"go.uber.org/zap"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+
+	// exportFlushEvery controls how many records are buffered before flushing to the client
+	exportFlushEvery = 100
+
+	// maxImportBytes caps the size of an import file, about 100k coupon rows
+	maxImportBytes = 10 << 20
+)
+
+var (
+	couponExportHeader = []string{"id", "name", "amount", "remaining_amount", "unique_codes", "expires_at", "eligibility_rules", "allowlist_only", "queue_mode", "lottery_closes_at", "lottery_seed_hash", "created_at", "updated_at"}
+	claimExportHeader  = []string{"id", "user_id", "coupon_name", "code", "claimed_at"}
+)
+
+// ExportCoupons handles GET /api/coupons/export
+func (h *CouponHandler) ExportCoupons(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormatFromRequest(r)
+	if !ok {
+		pkgRest.RespondWithError(w, http.StatusBadRequest, ErrorCodeUnsupportedFormat, "Unsupported export format")
+		return
+	}
+
+	ew := newExportWriter(w, format, "coupons", couponExportHeader)
+	err := h.service.ExportCoupons(r.Context(), func(c *models.Coupon) error {
+		rules, err := formatEligibilityRules(c.EligibilityRules)
+		if err != nil {
+			return err
+		}
+		return ew.write(c, []string{
+			strconv.FormatInt(c.ID, 10),
+			c.Name,
+			strconv.Itoa(c.Amount),
+			strconv.Itoa(c.RemainingAmount),
+			strconv.FormatBool(c.UniqueCodes),
+			formatOptionalTime(c.ExpiresAt),
+			rules,
+			strconv.FormatBool(c.AllowlistOnly),
+			strconv.FormatBool(c.QueueMode),
+			formatOptionalTime(c.LotteryClosesAt),
+			c.LotterySeedHash,
+			c.CreatedAt.Format(time.RFC3339),
+			c.UpdatedAt.Format(time.RFC3339),
+		})
+	})
+	h.finishExport(w, r, ew, err)
+}
+
+// ExportClaims handles GET /api/coupons/{name}/claims/export
+func (h *CouponHandler) ExportClaims(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	format, ok := exportFormatFromRequest(r)
+	if !ok {
+		pkgRest.RespondWithError(w, http.StatusBadRequest, ErrorCodeUnsupportedFormat, "Unsupported export format")
+		return
+	}
+
+	ew := newExportWriter(w, format, name+"-claims", claimExportHeader)
+	err := h.service.ExportClaims(r.Context(), name, func(c *models.Claim) error {
+		return ew.write(c, []string{
+			strconv.Itoa(c.ID),
+			c.UserID,
+			c.CouponName,
+			c.Code,
+			c.ClaimedAt.Format(time.RFC3339),
+		})
+	})
+	h.finishExport(w, r, ew, err)
+}
+
+// ImportCoupons handles POST /api/coupons/import
+func (h *CouponHandler) ImportCoupons(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var (
+		rows []models.ImportCouponRow
+		err  error
+		body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	)
+	switch mediaType {
+	case contentTypeCSV:
+		rows, err = parseCouponCSV(body)
+	case contentTypeNDJSON:
+		rows, err = parseCouponNDJSON(body)
+	default:
+		pkgRest.RespondWithError(w, http.StatusUnsupportedMediaType, ErrorCodeUnsupportedMediaType, "Content-Type must be text/csv or application/x-ndjson")
+		return
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		logger.Print(r.Context(), logger.LevelWarn, err.Error())
+		pkgRest.RespondWithError(w, http.StatusRequestEntityTooLarge, ErrorCodeRequestTooLarge, fmt.Sprintf("Import file must not be larger than %d bytes", maxBytesErr.Limit))
+		return
+	}
+	if err != nil {
+		logger.Print(r.Context(), logger.LevelWarn, err.Error())
+		pkgRest.RespondWithError(w, http.StatusBadRequest, ErrorCodeInvalidImportFile, err.Error())
+		return
+	}
+
+	response := h.service.ImportCoupons(r.Context(), rows)
+	for i := range response.Errors {
+		rowErr := &response.Errors[i]
+		problem := ProblemFor(rowErr.Err)
+		if problem.Status >= http.StatusInternalServerError {
+			logger.Print(r.Context(), logger.LevelError, "Import row failed", zap.Int("row", rowErr.Row), zap.Error(rowErr.Err))
+		}
+		rowErr.Error = problem.Detail
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, response)
+}
+
+// finishExport reports err to the client if nothing was streamed yet, otherwise it can only be logged
+func (h *CouponHandler) finishExport(w http.ResponseWriter, r *http.Request, ew *exportWriter, err error) {
+	if err == nil {
+		err = ew.finish()
+		if err != nil {
+			logger.Print(r.Context(), logger.LevelError, err.Error())
+		}
+		return
+	}
+
+	if ew.started {
+		logger.Print(r.Context(), logger.LevelError, err.Error())
+		return
+	}
+	respondWithError(w, r, err)
+}
+
+// exportFormatFromRequest reads the ?format= query parameter, defaulting to CSV
+func exportFormatFromRequest(r *http.Request) (string, bool) {
+	switch format := strings.ToLower(r.URL.Query().Get("format")); format {
+	case "", exportFormatCSV:
+		return exportFormatCSV, true
+	case exportFormatNDJSON:
+		return exportFormatNDJSON, true
+	default:
+		return "", false
+	}
+}
+
+// formatOptionalTime formats t as RFC 3339, or as an empty CSV field when unset
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatEligibilityRules encodes rules as a JSON CSV field, or an empty field when there are none
+func formatEligibilityRules(rules models.EligibilityRules) (string, error) {
+	if len(rules) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(rules)
+	if err != nil {
+		return "", fmt.Errorf("error encoding eligibility rules: %v", err)
+	}
+	return string(b), nil
+}
+
+// exportWriter streams records as CSV or NDJSON. Headers are only sent with the
+// first record so that errors occurring before any data can still be reported.
+type exportWriter struct {
+	w        http.ResponseWriter
+	format   string
+	filename string
+	header   []string
+	csv      *csv.Writer
+	json     *json.Encoder
+	started  bool
+	written  int
+}
+
+func newExportWriter(w http.ResponseWriter, format, filename string, header []string) *exportWriter {
+	return &exportWriter{
+		w:        w,
+		format:   format,
+		filename: filename,
+		header:   header,
+	}
+}
+
+func (e *exportWriter) start() error {
+	e.started = true
+
+	contentType := contentTypeCSV + "; charset=utf-8"
+	if e.format == exportFormatNDJSON {
+		contentType = contentTypeNDJSON
+	}
+	e.w.Header().Set("Content-Type", contentType)
+	e.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.filename+"."+e.format))
+	e.w.WriteHeader(http.StatusOK)
+
+	if e.format == exportFormatNDJSON {
+		e.json = json.NewEncoder(e.w)
+		return nil
+	}
+	e.csv = csv.NewWriter(e.w)
+	return e.csv.Write(e.header)
+}
+
+// write emits value as a JSON line or record as a CSV row depending on the format
+func (e *exportWriter) write(value interface{}, record []string) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if e.format == exportFormatNDJSON {
+		err = e.json.Encode(value)
+	} else {
+		err = e.csv.Write(escapeFormulas(record))
+	}
+	if err != nil {
+		return fmt.Errorf("error writing export: %v", err)
+	}
+
+	e.written++
+	if e.written%exportFlushEvery == 0 {
+		e.flush()
+	}
+	return nil
+}
+
+// finish sends headers for empty exports and flushes any buffered records
+func (e *exportWriter) finish() error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	e.flush()
+	if e.csv != nil {
+		return e.csv.Error()
+	}
+	return nil
+}
+
+// escapeFormulas prefixes cells that a spreadsheet would evaluate as a formula with a
+// single quote, so a coupon name or user ID such as =HYPERLINK(...) is shown as text
+func escapeFormulas(record []string) []string {
+	for i, cell := range record {
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			record[i] = "'" + cell
+		}
+	}
+	return record
+}
+
+func (e *exportWriter) flush() {
+	if e.csv != nil {
+		e.csv.Flush()
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// parseCouponCSV reads coupon definitions from a CSV file with a name,amount header and
+// optional unique_codes, expires_at, eligibility_rules (JSON), allowlist_only, queue_mode,
+// lottery_closes_at and lottery_seed_hash columns. Row numbers are the line numbers in the uploaded file. A row
+// that cannot be read is returned with Err set, so the rows around it are still imported;
+// only an unreadable header or file fails the whole import.
+func parseCouponCSV(body io.Reader) ([]models.ImportCouponRow, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("import file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("CSV header must contain name and amount columns")
+	}
+	if _, ok := columns["amount"]; !ok {
+		return nil, errors.New("CSV header must contain name and amount columns")
+	}
+
+	rows := []models.ImportCouponRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, models.ImportCouponRow{
+				Row: parseErr.StartLine,
+				Err: validation.Invalid("row", "invalid CSV: %v", parseErr.Err),
+			})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading import file: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, parseCouponRecord(line, record, columns))
+	}
+
+	return rows, nil
+}
+
+// parseCouponRecord reads the coupon in one CSV record, reporting every invalid cell
+func parseCouponRecord(line int, record []string, columns map[string]int) models.ImportCouponRow {
+	var violations validation.Errors
+	cell := func(column string) string {
+		if i, ok := columns[column]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	boolean := func(column string) bool {
+		value := cell(column)
+		if value == "" {
+			return false
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			violations.Add(column, "%s must be true or false", column)
+		}
+		return b
+	}
+	timestamp := func(column string) *time.Time {
+		value := cell(column)
+		if value == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			violations.Add(column, "%s must be an RFC 3339 timestamp", column)
+			return nil
+		}
+		return &t
+	}
+
+	req := models.CreateCouponRequest{
+		Name:            record[columns["name"]],
+		UniqueCodes:     boolean("unique_codes"),
+		ExpiresAt:       timestamp("expires_at"),
+		AllowlistOnly:   boolean("allowlist_only"),
+		QueueMode:       boolean("queue_mode"),
+		LotteryClosesAt: timestamp("lottery_closes_at"),
+		LotterySeedHash: cell("lottery_seed_hash"),
+	}
+
+	amount, err := strconv.Atoi(cell("amount"))
+	if err != nil {
+		violations.Add("amount", "amount must be an integer")
+	}
+	req.Amount = amount
+
+	if rules := cell("eligibility_rules"); rules != "" {
+		if err := json.Unmarshal([]byte(rules), &req.EligibilityRules); err != nil {
+			violations.Add("eligibility_rules", "eligibility_rules must be a JSON array of rules")
+		}
+	}
+
+	row := models.ImportCouponRow{Row: line, CreateCouponRequest: req}
+	if len(violations) > 0 {
+		row.Err = violations
+	}
+	return row
+}
+
+// parseCouponNDJSON reads one JSON coupon definition per line, skipping blank lines. Lines
+// are decoded as strictly as JSON request bodies, so unknown fields are rejected. Row numbers
+// are the line numbers in the uploaded file, and a line that cannot be decoded is returned
+// with Err set.
+func parseCouponNDJSON(body io.Reader) ([]models.ImportCouponRow, error) {
+	scanner := bufio.NewScanner(body)
+	// A line may be as long as the whole file, such as one with many eligibility rules
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportBytes)
+	rows := []models.ImportCouponRow{}
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		row := models.ImportCouponRow{Row: line}
+		if err := pkgRest.Unmarshal(text, &row.CreateCouponRequest); err != nil {
+			row.CreateCouponRequest = models.CreateCouponRequest{}
+			row.Err = validation.Invalid("row", "%v", err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading import file: %w", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("import file is empty")
+	}
+
+	return rows, nil
+}
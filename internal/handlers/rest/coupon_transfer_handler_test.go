@@ -0,0 +1,341 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/validation"
+)
+
+func TestExportCoupons_Handler_CSV(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockService.On("ExportCoupons", mock.Anything).Run(func(args mock.Arguments) {
+		fn := args.Get(0).(func(*models.Coupon) error)
+		fn(&models.Coupon{ID: 1, Name: "FLASH25", Amount: 100, RemainingAmount: 75, CreatedAt: created, UpdatedAt: created})
+	}).Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/export", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ExportCoupons(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "id,name,amount,remaining_amount,unique_codes,expires_at,eligibility_rules,allowlist_only,queue_mode,lottery_closes_at,lottery_seed_hash,created_at,updated_at\n"+
+		"1,FLASH25,100,75,false,,,false,false,,,2024-01-02T03:04:05Z,2024-01-02T03:04:05Z\n", rec.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestExportCoupons_Handler_NDJSON(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	mockService.On("ExportCoupons", mock.Anything).Run(func(args mock.Arguments) {
+		fn := args.Get(0).(func(*models.Coupon) error)
+		fn(&models.Coupon{ID: 1, Name: "FLASH25", Amount: 100, RemainingAmount: 75})
+		fn(&models.Coupon{ID: 2, Name: "PROMO", Amount: 10, RemainingAmount: 10})
+	}).Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/export?format=ndjson", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ExportCoupons(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var coupon models.Coupon
+	json.Unmarshal([]byte(lines[1]), &coupon)
+	assert.Equal(t, "PROMO", coupon.Name)
+	mockService.AssertExpectations(t)
+}
+
+func TestExportCoupons_Handler_UnsupportedFormat(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/export?format=xml", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ExportCoupons(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "ExportCoupons", mock.Anything)
+}
+
+func TestExportClaims_Handler_EmptyCSV(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	mockService.On("ExportClaims", "FLASH25", mock.Anything).Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/FLASH25/claims/export", nil)
+	rec := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/coupons/{name}/claims/export", handler.ExportClaims)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "id,user_id,coupon_name,code,claimed_at\n", rec.Body.String())
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "FLASH25-claims.csv")
+	mockService.AssertExpectations(t)
+}
+
+func TestExportClaims_Handler_EscapesFormulas(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	claimed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockService.On("ExportClaims", "FLASH25", mock.Anything).Run(func(args mock.Arguments) {
+		fn := args.Get(1).(func(*models.Claim) error)
+		fn(&models.Claim{ID: 1, UserID: "=HYPERLINK(\"http://evil\")", CouponName: "FLASH25", ClaimedAt: claimed})
+		fn(&models.Claim{ID: 2, UserID: "-1+1", CouponName: "FLASH25", ClaimedAt: claimed})
+	}).Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/FLASH25/claims/export", nil)
+	rec := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/coupons/{name}/claims/export", handler.ExportClaims)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "id,user_id,coupon_name,code,claimed_at\n"+
+		"1,\"'=HYPERLINK(\"\"http://evil\"\")\",FLASH25,,2024-01-02T03:04:05Z\n"+
+		"2,'-1+1,FLASH25,,2024-01-02T03:04:05Z\n", rec.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestExportClaims_Handler_NotFound(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	mockService.On("ExportClaims", "NONEXISTENT", mock.Anything).Return(repository.ErrCouponNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/coupons/NONEXISTENT/claims/export", nil)
+	rec := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/coupons/{name}/claims/export", handler.ExportClaims)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Coupon not found", problem.Detail)
+	mockService.AssertExpectations(t)
+}
+
+func TestImportCoupons_Handler_CSV(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	expectedRows := []models.ImportCouponRow{
+		{Row: 2, CreateCouponRequest: models.CreateCouponRequest{Name: "FLASH25", Amount: 100}},
+		{Row: 3, CreateCouponRequest: models.CreateCouponRequest{Name: "", Amount: 5}},
+		{Row: 4, CreateCouponRequest: models.CreateCouponRequest{Name: "DUPLICATE", Amount: 5}},
+		{Row: 5, CreateCouponRequest: models.CreateCouponRequest{Name: "BROKEN", Amount: 5}},
+	}
+	result := &models.ImportCouponsResponse{
+		Total:    4,
+		Imported: 1,
+		Failed:   3,
+		Errors: []models.ImportRowError{
+			{Row: 3, Err: validation.Invalid("name", "name is required")},
+			{Row: 4, Name: "DUPLICATE", Err: repository.ErrCouponAlreadyExists},
+			{Row: 5, Name: "BROKEN", Err: errors.New(`pq: relation "coupons" does not exist`)},
+		},
+	}
+	mockService.On("ImportCoupons", expectedRows).Return(result)
+
+	body := "name,amount\nFLASH25,100\n,5\nDUPLICATE,5\nBROKEN,5\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+
+	handler.ImportCoupons(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.ImportCouponsResponse
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, models.ImportCouponsResponse{
+		Total:    4,
+		Imported: 1,
+		Failed:   3,
+		Errors: []models.ImportRowError{
+			{Row: 3, Error: "name is required"},
+			{Row: 4, Name: "DUPLICATE", Error: "Coupon already exists"},
+			// Unexpected errors are reported without their internal message
+			{Row: 5, Name: "BROKEN", Error: "An unexpected error occurred"},
+		},
+	}, response)
+	assert.NotContains(t, rec.Body.String(), "pq:")
+	mockService.AssertExpectations(t)
+}
+
+func TestImportCoupons_Handler_NDJSON(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	expectedRows := []models.ImportCouponRow{
+		{Row: 1, CreateCouponRequest: models.CreateCouponRequest{Name: "FLASH25", Amount: 100}},
+		{Row: 3, CreateCouponRequest: models.CreateCouponRequest{Name: "PROMO", Amount: 10}},
+	}
+	mockService.On("ImportCoupons", expectedRows).Return(&models.ImportCouponsResponse{Total: 2, Imported: 2})
+
+	body := "{\"name\":\"FLASH25\",\"amount\":100}\n\n{\"name\":\"PROMO\",\"amount\":10}\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+
+	handler.ImportCoupons(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestImportCoupons_Handler_MalformedRow(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	expectedRows := []models.ImportCouponRow{
+		{Row: 2, CreateCouponRequest: models.CreateCouponRequest{Name: "FLASH25", Amount: 100}},
+		{Row: 3, CreateCouponRequest: models.CreateCouponRequest{Name: "PROMO"}, Err: validation.Errors{
+			{Field: "queue_mode", Message: "queue_mode must be true or false"},
+			{Field: "amount", Message: "amount must be an integer"},
+		}},
+		{Row: 4, Err: validation.Invalid("row", "invalid CSV: %v", csv.ErrFieldCount)},
+		{Row: 5, CreateCouponRequest: models.CreateCouponRequest{Name: "LAST", Amount: 1}},
+	}
+	mockService.On("ImportCoupons", expectedRows).Return(&models.ImportCouponsResponse{Total: 4, Imported: 2, Failed: 2})
+
+	body := "name,amount,queue_mode\nFLASH25,100,\nPROMO,ten,maybe\nSHORT,1\nLAST,1,\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+
+	handler.ImportCoupons(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestImportCoupons_Handler_NDJSONStrict(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	expectedRows := []models.ImportCouponRow{
+		{Row: 1, Err: validation.Invalid("row", "Request body contains unknown field \"ammount\"")},
+		{Row: 2, CreateCouponRequest: models.CreateCouponRequest{Name: "PROMO", Amount: 10}},
+	}
+	mockService.On("ImportCoupons", expectedRows).Return(&models.ImportCouponsResponse{Total: 2, Imported: 1, Failed: 1})
+
+	body := "{\"name\":\"FLASH25\",\"ammount\":100}\n{\"name\":\"PROMO\",\"amount\":10}\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+
+	handler.ImportCoupons(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestImportCoupons_Handler_NDJSONLongLine(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	// Longer than the 64KB a bufio.Scanner reads by default
+	longName := strings.Repeat("A", 100*1024)
+	expectedRows := []models.ImportCouponRow{
+		{Row: 1, CreateCouponRequest: models.CreateCouponRequest{Name: longName, Amount: 100}},
+		{Row: 2, CreateCouponRequest: models.CreateCouponRequest{Name: "PROMO", Amount: 10}},
+	}
+	mockService.On("ImportCoupons", expectedRows).Return(&models.ImportCouponsResponse{Total: 2, Imported: 1, Failed: 1})
+
+	body := "{\"name\":\"" + longName + "\",\"amount\":100}\n{\"name\":\"PROMO\",\"amount\":10}\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+
+	handler.ImportCoupons(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestImportCoupons_Handler_TooLarge(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	body := "name,amount\n" + strings.Repeat("FLASH25,100\n", maxImportBytes/12+1)
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+
+	handler.ImportCoupons(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, ErrorCodeRequestTooLarge, decodeProblem(t, rec).Code)
+	mockService.AssertNotCalled(t, "ImportCoupons", mock.Anything)
+}
+
+func TestImportCoupons_Handler_UnsupportedMediaType(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/import", bytes.NewBufferString("{}"))
+	req.Header.Set("Content-Type", "application/xml")
+	rec := httptest.NewRecorder()
+
+	handler.ImportCoupons(rec, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
@@ -0,0 +1,258 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wazadio/coupon-system/internal/models"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+)
+
+// schemaModels maps every schema in openapi.json to the type the API encodes or decodes
+var schemaModels = map[string]interface{}{
+	"HealthResponse":        models.HealthResponse{},
+	"ReadinessResponse":     models.ReadinessResponse{},
+	"ReadinessCheck":        models.ReadinessCheck{},
+	"MessageResponse":       models.MessageResponse{},
+	"Problem":               pkgRest.Problem{},
+	"FieldError":            pkgRest.FieldError{},
+	"EligibilityRule":       models.EligibilityRule{},
+	"CreateCouponRequest":   models.CreateCouponRequest{},
+	"Coupon":                models.Coupon{},
+	"CouponDetailResponse":  models.CouponDetailResponse{},
+	"UpdateCouponResponse":  models.UpdateCouponResponse{},
+	"ClaimCouponRequest":    models.ClaimCouponRequest{},
+	"ClaimCouponResponse":   models.ClaimCouponResponse{},
+	"Claim":                 models.Claim{},
+	"ImportRowError":        models.ImportRowError{},
+	"ImportCouponsResponse": models.ImportCouponsResponse{},
+	"CodeLookupResponse":    models.CodeLookupResponse{},
+	"CodeRedemption":        models.CodeRedemption{},
+	"AccessListRequest":     models.AccessListRequest{},
+	"AccessListResponse":    models.AccessListResponse{},
+	"AllowlistEntry":        models.AllowlistEntry{},
+	"DenylistEntry":         models.DenylistEntry{},
+	"APIKey":                models.APIKey{},
+	"CreateAPIKeyRequest":   models.CreateAPIKeyRequest{},
+	"CreateAPIKeyResponse":  models.CreateAPIKeyResponse{},
+	"QueueTicket":           models.QueueTicket{},
+	"DrawLotteryRequest":    models.DrawLotteryRequest{},
+	"LotteryDraw":           models.LotteryDraw{},
+	"LogLevel":              models.LogLevel{},
+}
+
+type openAPIDoc struct {
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	Parameters []struct {
+		Name string `json:"name"`
+		In   string `json:"in"`
+	} `json:"parameters"`
+}
+
+type openAPISchema struct {
+	Ref        string                   `json:"$ref"`
+	AllOf      []openAPISchema          `json:"allOf"`
+	Type       string                   `json:"type"`
+	Format     string                   `json:"format"`
+	Items      *openAPISchema           `json:"items"`
+	Properties map[string]openAPISchema `json:"properties"`
+	Required   []string                 `json:"required"`
+}
+
+func loadOpenAPI(t *testing.T) *openAPIDoc {
+	var doc openAPIDoc
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+	return &doc
+}
+
+func TestOpenAPI_DeclaresPathParameters(t *testing.T) {
+	doc := loadOpenAPI(t)
+	variable := regexp.MustCompile(`\{([^}]+)\}`)
+
+	for path, operations := range doc.Paths {
+		for method, operation := range operations {
+			declared := map[string]bool{}
+			for _, param := range operation.Parameters {
+				if param.In == "path" {
+					declared[param.Name] = true
+				}
+			}
+			for _, match := range variable.FindAllStringSubmatch(path, -1) {
+				assert.True(t, declared[match[1]], "%s %s does not declare path parameter %s", method, path, match[1])
+			}
+		}
+	}
+}
+
+func TestOpenAPI_SchemasMatchModels(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	for name := range doc.Components.Schemas {
+		assert.Contains(t, schemaModels, name, "schema %s is not mapped to a model in schemaModels", name)
+	}
+
+	for name, model := range schemaModels {
+		schema, ok := doc.Components.Schemas[name]
+		if !assert.True(t, ok, "openapi.json has no schema %s", name) {
+			continue
+		}
+		checkObjectSchema(t, name, schema, reflect.TypeOf(model))
+	}
+}
+
+func TestOpenAPI_ReferencesResolve(t *testing.T) {
+	var raw interface{}
+	require.NoError(t, json.Unmarshal(openAPISpec, &raw))
+	doc := raw.(map[string]interface{})
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				target := interface{}(doc)
+				for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					m, _ := target.(map[string]interface{})
+					target = m[part]
+				}
+				assert.NotNil(t, target, "unresolved $ref %s", ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
+
+func TestDocsHandler_ServesSpecAndUI(t *testing.T) {
+	router := newTestRouter(&DocsHandler{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+
+	req = httptest.NewRequest(http.MethodGet, "/api/docs", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, rec.Body.String(), `url: "openapi.json"`)
+}
+
+// checkObjectSchema asserts schema has exactly the JSON fields of typ, each with a matching type
+func checkObjectSchema(t *testing.T, path string, schema openAPISchema, typ reflect.Type) {
+	fields := jsonFields(typ)
+
+	for name := range schema.Properties {
+		_, ok := fields[name]
+		assert.True(t, ok, "%s.%s is in openapi.json but not in %s", path, name, typ)
+	}
+	for name, field := range fields {
+		prop, ok := schema.Properties[name]
+		if !assert.True(t, ok, "%s.%s is in %s but not in openapi.json", path, name, typ) {
+			continue
+		}
+		checkSchema(t, path+"."+name, prop, field)
+	}
+	for _, name := range schema.Required {
+		_, ok := schema.Properties[name]
+		assert.True(t, ok, "%s requires unknown property %s", path, name)
+	}
+}
+
+// checkSchema asserts schema describes values of typ. Named structs must be referenced by
+// the schema mapped to them, so a model used in two places cannot drift in only one.
+func checkSchema(t *testing.T, path string, schema openAPISchema, typ reflect.Type) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if len(schema.AllOf) == 1 {
+		schema = schema.AllOf[0]
+	}
+
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		model, ok := schemaModels[name]
+		if assert.True(t, ok, "%s references unmapped schema %s", path, name) {
+			assert.Equal(t, reflect.TypeOf(model), typ, "%s references %s", path, name)
+		}
+		return
+	}
+
+	switch {
+	case typ == reflect.TypeOf(time.Time{}):
+		assert.Equal(t, "string", schema.Type, path)
+		assert.Equal(t, "date-time", schema.Format, path)
+	case typ.Kind() == reflect.String:
+		assert.Equal(t, "string", schema.Type, path)
+	case typ.Kind() == reflect.Bool:
+		assert.Equal(t, "boolean", schema.Type, path)
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Uint64:
+		assert.Equal(t, "integer", schema.Type, path)
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		assert.Equal(t, "number", schema.Type, path)
+	case typ.Kind() == reflect.Slice:
+		if assert.Equal(t, "array", schema.Type, path) && assert.NotNil(t, schema.Items, path) {
+			checkSchema(t, path+"[]", *schema.Items, typ.Elem())
+		}
+	case typ.Kind() == reflect.Map:
+		assert.Equal(t, "object", schema.Type, path)
+	case typ.Kind() == reflect.Interface:
+		assert.Empty(t, schema.Type, "%s holds any JSON value", path)
+	case typ.Kind() == reflect.Struct:
+		t.Errorf("%s is a %s, reference its schema instead of describing it inline", path, typ)
+	default:
+		t.Errorf("%s has unsupported type %s", path, typ)
+	}
+}
+
+// jsonFields returns the fields encoding/json uses for typ by name, flattening embedded structs
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embedded, typ := range jsonFields(field.Type) {
+				fields[embedded] = typ
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
@@ -0,0 +1,11 @@
+package rest
+
+import (
+	"github.com/gorilla/mux"
+)
+
+// SetupRouter registers the documentation routes, which are public
+func (h *DocsHandler) SetupRouter(router *mux.Router) {
+	router.HandleFunc("/openapi.json", h.OpenAPI).Methods("GET")
+	router.HandleFunc("/docs", h.Docs).Methods("GET")
+}
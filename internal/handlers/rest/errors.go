@@ -0,0 +1,167 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/couponcode"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+	"github.com/wazadio/coupon-system/pkg/validation"
+)
+
+// Error codes returned in the code field of problem responses. Clients switch on them,
+// so a code must never change meaning once released.
+const (
+	ErrorCodeInvalidRequestBody    = "INVALID_REQUEST_BODY"
+	ErrorCodeRequestTooLarge       = "REQUEST_TOO_LARGE"
+	ErrorCodeValidationFailed      = "VALIDATION_FAILED"
+	ErrorCodeUnsupportedMediaType  = "UNSUPPORTED_MEDIA_TYPE"
+	ErrorCodeUnsupportedFormat     = "UNSUPPORTED_FORMAT"
+	ErrorCodeInvalidImportFile     = "INVALID_IMPORT_FILE"
+	ErrorCodeRouteNotFound         = "ROUTE_NOT_FOUND"
+	ErrorCodeMethodNotAllowed      = "METHOD_NOT_ALLOWED"
+	ErrorCodeInternal              = "INTERNAL_ERROR"
+	ErrorCodeUserMismatch          = "USER_ID_MISMATCH"
+	ErrorCodeAttributesNotAllowed  = "ATTRIBUTES_NOT_ALLOWED"
+	ErrorCodeCouponNotFound        = "COUPON_NOT_FOUND"
+	ErrorCodeCouponAlreadyExists   = "COUPON_ALREADY_EXISTS"
+	ErrorCodeCouponAlreadyClaimed  = "COUPON_ALREADY_CLAIMED"
+	ErrorCodeCouponSoldOut         = "COUPON_SOLD_OUT"
+	ErrorCodeCouponExpired         = "COUPON_EXPIRED"
+	ErrorCodeUserDenied            = "USER_DENIED"
+	ErrorCodeUserNotAllowlisted    = "USER_NOT_ALLOWLISTED"
+	ErrorCodeUserNotEligible       = "USER_NOT_ELIGIBLE"
+	ErrorCodeUserNotListed         = "USER_NOT_LISTED"
+	ErrorCodeQueueRequired         = "QUEUE_REQUIRED"
+	ErrorCodeQueueNotEnabled       = "QUEUE_NOT_ENABLED"
+	ErrorCodeTicketNotFound        = "QUEUE_TICKET_NOT_FOUND"
+	ErrorCodeNotLottery            = "COUPON_NOT_LOTTERY"
+	ErrorCodeLotteryAlreadyEntered = "LOTTERY_ALREADY_ENTERED"
+	ErrorCodeLotteryEntriesClosed  = "LOTTERY_ENTRIES_CLOSED"
+	ErrorCodeLotteryEntriesOpen    = "LOTTERY_ENTRIES_OPEN"
+	ErrorCodeLotteryAlreadyDrawn   = "LOTTERY_ALREADY_DRAWN"
+	ErrorCodeLotteryDrawNotFound   = "LOTTERY_DRAW_NOT_FOUND"
+	ErrorCodeLotterySeedMismatch   = "LOTTERY_SEED_MISMATCH"
+	ErrorCodeCodeMalformed         = "CODE_MALFORMED"
+	ErrorCodeCodeChecksumMismatch  = "CODE_CHECKSUM_MISMATCH"
+	ErrorCodeCodeNotFound          = "CODE_NOT_FOUND"
+	ErrorCodeCodeNotClaimed        = "CODE_NOT_CLAIMED"
+	ErrorCodeCodeAlreadyRedeemed   = "CODE_ALREADY_REDEEMED"
+	ErrorCodeCodeExpired           = "CODE_EXPIRED"
+	ErrorCodeAPIKeyNotFound        = "API_KEY_NOT_FOUND"
+)
+
+// errorMapping describes the response for a repository or service sentinel error. An empty
+// detail uses the error's own message, for errors that carry specifics such as a rule name.
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+	detail string
+}
+
+// errorMappings is the single place sentinel errors are turned into HTTP responses
+var errorMappings = []errorMapping{
+	{repository.ErrCouponNotFound, http.StatusNotFound, ErrorCodeCouponNotFound, "Coupon not found"},
+	{repository.ErrCouponAlreadyExists, http.StatusConflict, ErrorCodeCouponAlreadyExists, "Coupon already exists"},
+	{repository.ErrAlreadyClaimed, http.StatusConflict, ErrorCodeCouponAlreadyClaimed, "User already claimed this coupon"},
+	{repository.ErrNoStockAvailable, http.StatusBadRequest, ErrorCodeCouponSoldOut, "No stock available"},
+	{repository.ErrCouponExpired, http.StatusGone, ErrorCodeCouponExpired, "Coupon has expired"},
+	{repository.ErrUserDenied, http.StatusForbidden, ErrorCodeUserDenied, "User is not allowed to claim coupons"},
+	{repository.ErrUserNotAllowed, http.StatusForbidden, ErrorCodeUserNotAllowlisted, "User is not on this coupon's allowlist"},
+	{service.ErrNotEligible, http.StatusForbidden, ErrorCodeUserNotEligible, ""},
+	{service.ErrUserNotListed, http.StatusNotFound, ErrorCodeUserNotListed, "User is not on the list"},
+	{service.ErrQueueRequired, http.StatusConflict, ErrorCodeQueueRequired, "Coupon is in queue mode, join its queue instead"},
+	{service.ErrQueueNotEnabled, http.StatusConflict, ErrorCodeQueueNotEnabled, "Coupon is not in queue mode, claim it directly"},
+	{repository.ErrTicketNotFound, http.StatusNotFound, ErrorCodeTicketNotFound, "Queue ticket not found"},
+	{service.ErrNotLottery, http.StatusConflict, ErrorCodeNotLottery, "Coupon is not a lottery"},
+	{repository.ErrAlreadyEntered, http.StatusConflict, ErrorCodeLotteryAlreadyEntered, "User already entered this lottery"},
+	{repository.ErrEntriesClosed, http.StatusConflict, ErrorCodeLotteryEntriesClosed, "Lottery entries are closed"},
+	{service.ErrEntriesOpen, http.StatusConflict, ErrorCodeLotteryEntriesOpen, "Lottery entries are still open"},
+	{repository.ErrLotteryAlreadyDrawn, http.StatusConflict, ErrorCodeLotteryAlreadyDrawn, "Lottery already drawn"},
+	{service.ErrSeedMismatch, http.StatusBadRequest, ErrorCodeLotterySeedMismatch, "Seed does not match the coupon's lottery_seed_hash"},
+	{repository.ErrDrawNotFound, http.StatusNotFound, ErrorCodeLotteryDrawNotFound, "Lottery has not been drawn"},
+	{couponcode.ErrInvalidFormat, http.StatusBadRequest, ErrorCodeCodeMalformed, "Malformed code"},
+	{couponcode.ErrInvalidChecksum, http.StatusBadRequest, ErrorCodeCodeChecksumMismatch, "Code checksum mismatch, check the code for typos"},
+	{repository.ErrCodeNotFound, http.StatusNotFound, ErrorCodeCodeNotFound, "Code not found"},
+	{repository.ErrCodeNotClaimed, http.StatusConflict, ErrorCodeCodeNotClaimed, "Code has not been claimed"},
+	{repository.ErrCodeAlreadyRedeemed, http.StatusConflict, ErrorCodeCodeAlreadyRedeemed, "Code already redeemed"},
+	{repository.ErrCodeExpired, http.StatusGone, ErrorCodeCodeExpired, "Code has expired"},
+	{repository.ErrAPIKeyNotFound, http.StatusNotFound, ErrorCodeAPIKeyNotFound, "API key not found"},
+}
+
+// ProblemFor builds the response for err. Validation errors report every offending field,
+// and unknown errors become a 500 without their message, which may hold database details.
+// Other transports use it to report errors with the same codes.
+func ProblemFor(err error) *pkgRest.Problem {
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		fieldErrors := make([]pkgRest.FieldError, len(violations))
+		for i, v := range violations {
+			fieldErrors[i] = pkgRest.FieldError{Field: v.Field, Message: v.Message}
+		}
+		return &pkgRest.Problem{
+			Status: http.StatusBadRequest,
+			Code:   ErrorCodeValidationFailed,
+			Detail: violations.Error(),
+			Errors: fieldErrors,
+		}
+	}
+
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.err) {
+			detail := mapping.detail
+			if detail == "" {
+				detail = err.Error()
+			}
+			return &pkgRest.Problem{Status: mapping.status, Code: mapping.code, Detail: detail}
+		}
+	}
+
+	return &pkgRest.Problem{
+		Status: http.StatusInternalServerError,
+		Code:   ErrorCodeInternal,
+		Detail: "An unexpected error occurred",
+	}
+}
+
+// respondWithError logs err and sends its problem response
+func respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Print(r.Context(), logger.LevelError, err.Error())
+	pkgRest.RespondWithProblem(w, ProblemFor(err))
+}
+
+// RespondWithInvalidBody reports a request body that could not be decoded. Decode errors
+// keep their status, so oversized bodies get 413 and wrong content types 415.
+func RespondWithInvalidBody(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Print(r.Context(), logger.LevelWarn, err.Error())
+
+	var decodeErr *pkgRest.DecodeError
+	if !errors.As(err, &decodeErr) {
+		pkgRest.RespondWithError(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	code := ErrorCodeInvalidRequestBody
+	switch decodeErr.Status {
+	case http.StatusRequestEntityTooLarge:
+		code = ErrorCodeRequestTooLarge
+	case http.StatusUnsupportedMediaType:
+		code = ErrorCodeUnsupportedMediaType
+	}
+	pkgRest.RespondWithError(w, decodeErr.Status, code, decodeErr.Detail)
+}
+
+// NotFound answers requests that match no route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	pkgRest.RespondWithError(w, http.StatusNotFound, ErrorCodeRouteNotFound, "No route matches "+middleware.RedactedPath(r))
+}
+
+// MethodNotAllowed answers requests for a route that does not accept their method
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	pkgRest.RespondWithError(w, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed, "Method "+r.Method+" is not allowed on "+middleware.RedactedPath(r))
+}
@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+	"github.com/wazadio/coupon-system/pkg/validation"
+)
+
+// decodeProblem reads a problem+json response body
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) pkgRest.Problem {
+	t.Helper()
+
+	assert.Equal(t, pkgRest.ContentTypeProblem, rec.Header().Get("Content-Type"))
+
+	var problem pkgRest.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, rec.Code, problem.Status)
+	return problem
+}
+
+func TestRespondWithError_MappedSentinel(t *testing.T) {
+	logger.Init()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", nil)
+	rec := httptest.NewRecorder()
+	rec.Header().Set(pkgRest.TraceIDHeader, "trace-123")
+
+	// Wrapped sentinels are matched too
+	respondWithError(rec, req, fmt.Errorf("claiming: %w", repository.ErrNoStockAvailable))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Bad Request", problem.Title)
+	assert.Equal(t, ErrorCodeCouponSoldOut, problem.Code)
+	assert.Equal(t, "No stock available", problem.Detail)
+	assert.Equal(t, "trace-123", problem.TraceID)
+}
+
+func TestRespondWithError_ValidationError(t *testing.T) {
+	logger.Init()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", nil)
+	rec := httptest.NewRecorder()
+
+	respondWithError(rec, req, validation.Invalid("amount", "amount must be at least 1"))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, ErrorCodeValidationFailed, problem.Code)
+	assert.Equal(t, []pkgRest.FieldError{{Field: "amount", Message: "amount must be at least 1"}}, problem.Errors)
+}
+
+func TestRespondWithError_ValidationErrorsListEveryField(t *testing.T) {
+	logger.Init()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons", nil)
+	rec := httptest.NewRecorder()
+
+	var errs validation.Errors
+	errs.Add("name", "name is required")
+	errs.Add("amount", "amount must be at least 1")
+	respondWithError(rec, req, fmt.Errorf("create coupon: %w", errs))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, ErrorCodeValidationFailed, problem.Code)
+	assert.Equal(t, "name is required; amount must be at least 1", problem.Detail)
+	assert.Equal(t, []pkgRest.FieldError{
+		{Field: "name", Message: "name is required"},
+		{Field: "amount", Message: "amount must be at least 1"},
+	}, problem.Errors)
+}
+
+func TestRespondWithError_UnknownErrorIsNotLeaked(t *testing.T) {
+	logger.Init()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", nil)
+	rec := httptest.NewRecorder()
+
+	respondWithError(rec, req, errors.New(`error claiming coupon: pq: relation "claims" does not exist`))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, ErrorCodeInternal, problem.Code)
+	assert.Equal(t, "An unexpected error occurred", problem.Detail)
+	assert.NotContains(t, rec.Body.String(), "pq:")
+}
+
+func TestErrorMappings_UniqueCodes(t *testing.T) {
+	seen := make(map[string]bool, len(errorMappings))
+	for _, mapping := range errorMappings {
+		assert.False(t, seen[mapping.code], "code %s is mapped twice", mapping.code)
+		seen[mapping.code] = true
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NotFound(rec, httptest.NewRequest(http.MethodGet, "/api/nope", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, ErrorCodeRouteNotFound, decodeProblem(t, rec).Code)
+}
@@ -0,0 +1,65 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+	"github.com/wazadio/coupon-system/pkg/validation"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogLevelHandler lets admins read and change the log level without a restart
+type LogLevelHandler struct{}
+
+// logLevels are the levels an admin may set. Higher levels would hide errors.
+var logLevels = []zapcore.Level{logger.LevelDebug, logger.LevelInfo, logger.LevelWarn, logger.LevelError}
+
+// GetLogLevel handles GET /api/admin/log-level
+func (h *LogLevelHandler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	pkgRest.RespondWithJSON(w, http.StatusOK, models.LogLevel{Level: logger.Level().String()})
+}
+
+// SetLogLevel handles PUT /api/admin/log-level. The level applies to every logger at once
+// and lasts until the next change or restart.
+func (h *LogLevelHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req models.LogLevel
+
+	if err := pkgRest.DecodeJSON(w, r, &req); err != nil {
+		RespondWithInvalidBody(w, r, err)
+		return
+	}
+
+	level, err := parseLogLevel(&req)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	// Logged before the change, so it is not filtered out by a higher level
+	logger.Print(r.Context(), logger.LevelWarn, "Log level changed",
+		zap.String("from", logger.Level().String()),
+		zap.String("to", level.String()),
+	)
+	logger.SetLevel(level)
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, models.LogLevel{Level: level.String()})
+}
+
+func parseLogLevel(req *models.LogLevel) (zapcore.Level, error) {
+	if err := validation.Struct(req).Err(); err != nil {
+		return 0, err
+	}
+
+	level, err := zapcore.ParseLevel(req.Level)
+	if err == nil {
+		for _, allowed := range logLevels {
+			if level == allowed {
+				return level, nil
+			}
+		}
+	}
+	return 0, validation.Invalid("level", "level must be debug, info, warn or error")
+}
@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+func TestLogLevel_Handler_SetAndGet(t *testing.T) {
+	logger.Init()
+	t.Cleanup(func() { logger.SetLevel(logger.LevelInfo) })
+
+	router := newTestRouter(&LogLevelHandler{}, middleware.RoleAdmin)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/log-level", strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, rec.Body.String())
+	assert.Equal(t, logger.LevelDebug, logger.Level())
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/log-level", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, rec.Body.String())
+}
+
+func TestLogLevel_Handler_RejectsUnknownLevels(t *testing.T) {
+	logger.Init()
+
+	router := newTestRouter(&LogLevelHandler{}, middleware.RoleAdmin)
+
+	for _, body := range []string{`{"level":"verbose"}`, `{"level":"fatal"}`, `{"level":""}`} {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/log-level", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		assert.Contains(t, rec.Body.String(), `"field":"level"`, body)
+	}
+	assert.Equal(t, logger.LevelInfo, logger.Level())
+}
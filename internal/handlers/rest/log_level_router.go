@@ -0,0 +1,12 @@
+package rest
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+)
+
+// SetupRouter registers the log level admin routes
+func (h *LogLevelHandler) SetupRouter(router *mux.Router) {
+	handle(router, "/admin/log-level", middleware.PermLogsManage, h.GetLogLevel, "GET")
+	handle(router, "/admin/log-level", middleware.PermLogsManage, h.SetLogLevel, "PUT")
+}
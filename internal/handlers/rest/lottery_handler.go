@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/service"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+)
+
+// LotteryHandler handles admin HTTP requests for lottery draws
+type LotteryHandler struct {
+	service service.LotteryService
+}
+
+// NewLotteryHandler creates a new LotteryHandler with injected service
+func NewLotteryHandler(service service.LotteryService) *LotteryHandler {
+	return &LotteryHandler{
+		service: service,
+	}
+}
+
+// DrawLottery handles POST /api/admin/coupons/{name}/draw
+func (h *LotteryHandler) DrawLottery(w http.ResponseWriter, r *http.Request) {
+	var req models.DrawLotteryRequest
+
+	if err := pkgRest.DecodeJSON(w, r, &req); err != nil {
+		RespondWithInvalidBody(w, r, err)
+		return
+	}
+
+	draw, err := h.service.DrawLottery(r.Context(), mux.Vars(r)["name"], &req)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, draw)
+}
+
+// GetDraw handles GET /api/admin/coupons/{name}/draw
+func (h *LotteryHandler) GetDraw(w http.ResponseWriter, r *http.Request) {
+	draw, err := h.service.GetDraw(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, draw)
+}
@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+// MockLotteryService is a mock implementation of LotteryService
+type MockLotteryService struct {
+	mock.Mock
+}
+
+func (m *MockLotteryService) DrawLottery(ctx context.Context, couponName string, req *models.DrawLotteryRequest) (*models.LotteryDraw, error) {
+	args := m.Called(couponName, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LotteryDraw), args.Error(1)
+}
+
+func (m *MockLotteryService) GetDraw(ctx context.Context, couponName string) (*models.LotteryDraw, error) {
+	args := m.Called(couponName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LotteryDraw), args.Error(1)
+}
+
+func TestDrawLottery_Handler_Success(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockLotteryService)
+	router := newTestRouter(NewLotteryHandler(mockService), middleware.RoleAdmin)
+
+	draw := &models.LotteryDraw{
+		CouponName: "FLASH25",
+		Seed:       "seed",
+		SeedHash:   "19b25856e1c150ca834cffc8b59b23adbd0ec0389e58eb22b3b64768098d002b",
+		Entries:    4,
+		Entrants:   []string{"alice", "bob", "carol", "dave"},
+		Winners:    []string{"carol", "alice"},
+		DrawnAt:    time.Now(),
+	}
+	mockService.On("DrawLottery", "FLASH25", &models.DrawLotteryRequest{Seed: "seed"}).Return(draw, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/coupons/FLASH25/draw", strings.NewReader(`{"seed":"seed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.LotteryDraw
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, "seed", response.Seed)
+	assert.Equal(t, draw.SeedHash, response.SeedHash)
+	assert.Equal(t, draw.Entrants, response.Entrants)
+	assert.Equal(t, []string{"carol", "alice"}, response.Winners)
+	mockService.AssertExpectations(t)
+}
+
+func TestDrawLottery_Handler_EmptyBody(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockLotteryService)
+	router := newTestRouter(NewLotteryHandler(mockService), middleware.RoleAdmin)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/coupons/FLASH25/draw", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "DrawLottery", mock.Anything, mock.Anything)
+}
+
+func TestDrawLottery_Handler_Errors(t *testing.T) {
+	logger.Init()
+
+	tests := []struct {
+		err     error
+		status  int
+		message string
+	}{
+		{repository.ErrCouponNotFound, http.StatusNotFound, "Coupon not found"},
+		{service.ErrNotLottery, http.StatusConflict, "Coupon is not a lottery"},
+		{service.ErrEntriesOpen, http.StatusConflict, "Lottery entries are still open"},
+		{repository.ErrLotteryAlreadyDrawn, http.StatusConflict, "Lottery already drawn"},
+		{service.ErrSeedMismatch, http.StatusBadRequest, "Seed does not match the coupon's lottery_seed_hash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			mockService := new(MockLotteryService)
+			router := newTestRouter(NewLotteryHandler(mockService), middleware.RoleAdmin)
+
+			mockService.On("DrawLottery", "FLASH25", mock.Anything).Return(nil, tt.err)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/coupons/FLASH25/draw", strings.NewReader(`{"seed":"seed"}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+
+			problem := decodeProblem(t, rec)
+			assert.Equal(t, tt.message, problem.Detail)
+		})
+	}
+}
+
+func TestGetDraw_Handler_NotDrawn(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockLotteryService)
+	router := newTestRouter(NewLotteryHandler(mockService), middleware.RoleOperator)
+
+	mockService.On("GetDraw", "FLASH25").Return(nil, repository.ErrDrawNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/coupons/FLASH25/draw", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,14 @@
+package rest
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+)
+
+// SetupRouter creates and configures the HTTP router with injected dependencies
+func (h *LotteryHandler) SetupRouter(router *mux.Router) {
+	api := router.PathPrefix("/admin/coupons/{name}/draw").Subrouter()
+
+	handle(api, "", middleware.PermLotteryDraw, h.DrawLottery, "POST")
+	handle(api, "", middleware.PermCouponsExport, h.GetDraw, "GET")
+}
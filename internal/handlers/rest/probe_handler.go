@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+	"go.uber.org/zap"
+)
+
+// ReadinessChecker reports whether the instance can serve traffic, see health.Checker
+type ReadinessChecker interface {
+	Ready(ctx context.Context) (models.ReadinessResponse, bool)
+}
+
+// ProbeHandler serves the liveness and readiness probes
+type ProbeHandler struct {
+	checker ReadinessChecker
+}
+
+// NewProbeHandler creates a probe handler reporting the readiness of checker
+func NewProbeHandler(checker ReadinessChecker) *ProbeHandler {
+	return &ProbeHandler{checker: checker}
+}
+
+// Livez handles GET /livez. It only shows the process is serving requests, never the state
+// of its dependencies, so a database outage does not get every instance restarted.
+func (h *ProbeHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	pkgRest.RespondWithJSON(w, http.StatusOK, models.HealthResponse{Status: "alive"})
+}
+
+// Readyz handles GET /readyz, responding 503 with the failing checks while the instance
+// should take no traffic
+func (h *ProbeHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report, ready := h.checker.Ready(r.Context())
+	if !ready {
+		logger.Print(r.Context(), logger.LevelWarn, "Not ready", zap.Any("checks", report.Checks))
+		pkgRest.RespondWithJSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+	pkgRest.RespondWithJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+// stubChecker reports a fixed readiness
+type stubChecker struct {
+	report models.ReadinessResponse
+	ready  bool
+}
+
+func (c stubChecker) Ready(ctx context.Context) (models.ReadinessResponse, bool) {
+	return c.report, c.ready
+}
+
+func TestProbeHandler_Livez(t *testing.T) {
+	router := mux.NewRouter()
+	NewProbeHandler(stubChecker{}).SetupRouter(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	// Alive even though not ready
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"alive"}`, rec.Body.String())
+}
+
+func TestProbeHandler_Readyz(t *testing.T) {
+	logger.Init()
+
+	tests := []struct {
+		name       string
+		checker    stubChecker
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "ready",
+			checker: stubChecker{ready: true, report: models.ReadinessResponse{
+				Status: "ready",
+				Checks: map[string]models.ReadinessCheck{"database": {Status: "up", LatencyMS: 0.4}},
+			}},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"ready","checks":{"database":{"status":"up","latency_ms":0.4}}}`,
+		},
+		{
+			name: "check down",
+			checker: stubChecker{report: models.ReadinessResponse{
+				Status: "unready",
+				Checks: map[string]models.ReadinessCheck{"database": {Status: "down", LatencyMS: 2000, Error: "context deadline exceeded"}},
+			}},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"status":"unready","checks":{"database":{"status":"down","latency_ms":2000,"error":"context deadline exceeded"}}}`,
+		},
+		{
+			name:       "shutting down",
+			checker:    stubChecker{report: models.ReadinessResponse{Status: "shutting_down", Checks: map[string]models.ReadinessCheck{}}},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"status":"shutting_down","checks":{}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			NewProbeHandler(tt.checker).SetupRouter(router)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
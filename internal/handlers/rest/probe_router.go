@@ -0,0 +1,12 @@
+package rest
+
+import (
+	"github.com/gorilla/mux"
+)
+
+// SetupRouter registers the probes, which are public. Mount them outside /api, like
+// /metrics, so they are never authenticated or rate limited.
+func (h *ProbeHandler) SetupRouter(router *mux.Router) {
+	router.HandleFunc("/livez", h.Livez).Methods("GET")
+	router.HandleFunc("/readyz", h.Readyz).Methods("GET")
+}
@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	pkgRest "github.com/wazadio/coupon-system/pkg/rest"
+)
+
+// QueueHandler handles HTTP requests for the waiting room of queue-mode coupons
+type QueueHandler struct {
+	service service.QueueService
+}
+
+// NewQueueHandler creates a new QueueHandler with injected service
+func NewQueueHandler(service service.QueueService) *QueueHandler {
+	return &QueueHandler{
+		service: service,
+	}
+}
+
+// JoinQueue handles POST /api/coupons/{name}/queue. The body is optional for
+// authenticated users and takes the same user_id and attributes as a claim.
+func (h *QueueHandler) JoinQueue(w http.ResponseWriter, r *http.Request) {
+	var req models.ClaimCouponRequest
+
+	if err := pkgRest.DecodeJSON(w, r, &req); err != nil && err != pkgRest.ErrEmptyBody {
+		RespondWithInvalidBody(w, r, err)
+		return
+	}
+	req.CouponName = mux.Vars(r)["name"]
+
+	if !authorizeClaimant(w, r, &req) {
+		return
+	}
+
+	ticket, err := h.service.JoinQueue(r.Context(), &req)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/queue/"+ticket.Ticket)
+	pkgRest.RespondWithJSON(w, http.StatusAccepted, ticket)
+}
+
+// GetTicket handles GET /api/queue/{ticket}. Callers only see their own tickets; others
+// are reported as not found so ticket IDs cannot be probed.
+func (h *QueueHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
+	ticket, err := h.service.GetTicket(r.Context(), mux.Vars(r)["ticket"])
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	if identity, ok := middleware.IdentityFromContext(r.Context()); ok {
+		if (identity.Subject != "" && identity.Subject != ticket.UserID) || !identity.AllowsCoupon(ticket.CouponName) {
+			logger.Print(r.Context(), logger.LevelError, "Queue ticket belongs to another caller")
+			respondWithError(w, r, repository.ErrTicketNotFound)
+			return
+		}
+	}
+
+	pkgRest.RespondWithJSON(w, http.StatusOK, ticket)
+}
@@ -0,0 +1,254 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/validation"
+)
+
+const testTicket = "5f1d7c1e-7a51-4d7b-9d55-0c7bb7f0a9b1"
+
+// MockQueueService is a mock implementation of QueueService
+type MockQueueService struct {
+	mock.Mock
+}
+
+func (m *MockQueueService) JoinQueue(ctx context.Context, req *models.ClaimCouponRequest) (*models.QueueTicket, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.QueueTicket), args.Error(1)
+}
+
+func (m *MockQueueService) GetTicket(ctx context.Context, ticket string) (*models.QueueTicket, error) {
+	args := m.Called(ticket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.QueueTicket), args.Error(1)
+}
+
+func (m *MockQueueService) ProcessNext(ctx context.Context, lease time.Duration) (bool, error) {
+	args := m.Called(lease)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockQueueService) Run(ctx context.Context, config service.QueueConfig) {
+	m.Called(ctx, config)
+}
+
+func TestJoinQueue_Handler_Success(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockQueueService)
+	handler := NewQueueHandler(mockService)
+
+	expected := &models.QueueTicket{Ticket: testTicket, CouponName: "FLASH25", UserID: "user1", Status: models.QueueStatusWaiting, Position: 3}
+	mockService.On("JoinQueue", &models.ClaimCouponRequest{UserID: "user1", CouponName: "FLASH25"}).Return(expected, nil)
+
+	body := `{"user_id":"user1"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/FLASH25/queue", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	router := newTestRouter(handler, middleware.RoleCustomer)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "/api/queue/"+testTicket, rec.Header().Get("Location"))
+
+	var response models.QueueTicket
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, *expected, response)
+	mockService.AssertExpectations(t)
+}
+
+func TestJoinQueue_Handler_AttributesFromToken(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockQueueService)
+	handler := NewQueueHandler(mockService)
+
+	mockService.On("JoinQueue", &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+		Attributes: map[string]interface{}{"country": "ID"},
+	}).Return(&models.QueueTicket{Ticket: testTicket}, nil)
+
+	customer := &middleware.Identity{Subject: "user1", Attributes: map[string]interface{}{"country": "ID"}}
+	join := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons/FLASH25/queue", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = mux.SetURLVars(req, map[string]string{"name": "FLASH25"})
+		req = req.WithContext(middleware.WithIdentity(req.Context(), customer))
+		rec := httptest.NewRecorder()
+		handler.JoinQueue(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusAccepted, join(`{}`).Code)
+
+	rec := join(`{"attributes":{"country":"SG"}}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, ErrorCodeAttributesNotAllowed, decodeProblem(t, rec).Code)
+
+	mockService.AssertNumberOfCalls(t, "JoinQueue", 1)
+}
+
+func TestJoinQueue_Handler_EmptyBody(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockQueueService)
+	handler := NewQueueHandler(mockService)
+
+	mockService.On("JoinQueue", &models.ClaimCouponRequest{UserID: "user1", CouponName: "FLASH25"}).
+		Return(&models.QueueTicket{Ticket: testTicket}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/FLASH25/queue", nil)
+	rec := httptest.NewRecorder()
+
+	router := newTestRouter(handler, middleware.RoleCustomer)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestJoinQueue_Handler_UserIDMismatch(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockQueueService)
+	handler := NewQueueHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/coupons/FLASH25/queue", bytes.NewBufferString(`{"user_id":"user2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	router := newTestRouter(handler, middleware.RoleCustomer)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockService.AssertNotCalled(t, "JoinQueue", mock.Anything)
+}
+
+func TestJoinQueue_Handler_Errors(t *testing.T) {
+	tests := []struct {
+		err            error
+		expectedStatus int
+		expectedError  string
+	}{
+		{service.ErrQueueNotEnabled, http.StatusConflict, "Coupon is not in queue mode, claim it directly"},
+		{repository.ErrCouponNotFound, http.StatusNotFound, "Coupon not found"},
+		{&service.EligibilityError{Rule: "new_users_only"}, http.StatusForbidden, `user is not eligible for this coupon: rule "new_users_only" failed`},
+		{validation.Invalid("user_id", "user_id is required"), http.StatusBadRequest, "user_id is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expectedError, func(t *testing.T) {
+			logger.Init()
+
+			mockService := new(MockQueueService)
+			handler := NewQueueHandler(mockService)
+
+			mockService.On("JoinQueue", mock.Anything).Return(nil, tt.err)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/coupons/FLASH25/queue", nil)
+			rec := httptest.NewRecorder()
+
+			router := newTestRouter(handler, middleware.RoleAdmin)
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			problem := decodeProblem(t, rec)
+			assert.Equal(t, tt.expectedError, problem.Detail)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTicket_Handler_Success(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockQueueService)
+	handler := NewQueueHandler(mockService)
+
+	processed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	expected := &models.QueueTicket{
+		Ticket:      testTicket,
+		CouponName:  "FLASH25",
+		UserID:      "user1",
+		Status:      models.QueueStatusFailed,
+		Error:       "no stock available",
+		ProcessedAt: &processed,
+	}
+	mockService.On("GetTicket", testTicket).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/queue/"+testTicket, nil)
+	rec := httptest.NewRecorder()
+
+	router := newTestRouter(handler, middleware.RoleCustomer)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response models.QueueTicket
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, "failed", response.Status)
+	assert.Equal(t, "no stock available", response.Error)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetTicket_Handler_OtherUsersTicket(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockQueueService)
+	handler := NewQueueHandler(mockService)
+
+	mockService.On("GetTicket", testTicket).Return(&models.QueueTicket{Ticket: testTicket, CouponName: "FLASH25", UserID: "user2"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/queue/"+testTicket, nil)
+	rec := httptest.NewRecorder()
+
+	router := newTestRouter(handler, middleware.RoleCustomer)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetTicket_Handler_NotFound(t *testing.T) {
+	logger.Init()
+
+	mockService := new(MockQueueService)
+	handler := NewQueueHandler(mockService)
+
+	mockService.On("GetTicket", "unknown").Return(nil, repository.ErrTicketNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/queue/unknown", nil)
+	rec := httptest.NewRecorder()
+
+	router := newTestRouter(handler, middleware.RoleCustomer)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Queue ticket not found", problem.Detail)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,12 @@
+package rest
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+)
+
+// SetupRouter creates and configures the HTTP router with injected dependencies
+func (h *QueueHandler) SetupRouter(router *mux.Router) {
+	handle(router, "/coupons/{name}/queue", middleware.PermCouponsClaim, h.JoinQueue, "POST")
+	handle(router, "/queue/{ticket}", middleware.PermCouponsClaim, h.GetTicket, "GET")
+}
@@ -0,0 +1,13 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+)
+
+// handle registers fn for path and methods, only letting through callers whose role grants perm
+func handle(router *mux.Router, path string, perm middleware.Permission, fn http.HandlerFunc, methods ...string) {
+	router.Handle(path, middleware.RequirePermission(perm)(fn)).Methods(methods...)
+}
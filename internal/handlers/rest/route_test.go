@@ -0,0 +1,248 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/service"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+type routerSetup interface {
+	SetupRouter(*mux.Router)
+}
+
+// newTestRouter mounts handlers under /api with every request authenticated with roles.
+// Without roles requests are unauthenticated.
+func newTestRouter(h routerSetup, roles ...string) *mux.Router {
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api").Subrouter()
+	if len(roles) > 0 {
+		api.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := middleware.WithIdentity(r.Context(), &middleware.Identity{Subject: "user1", Roles: roles})
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+		})
+	}
+	h.SetupRouter(api)
+	return router
+}
+
+// errStub is returned by the stub services so that permitted requests end in a
+// handler error response rather than reaching a database
+var errStub = errors.New("stub")
+
+type stubCouponService struct{}
+
+func (stubCouponService) CreateCoupon(context.Context, *models.CreateCouponRequest) error {
+	return errStub
+}
+func (stubCouponService) ClaimCoupon(context.Context, *models.ClaimCouponRequest) (*models.Claim, error) {
+	return nil, errStub
+}
+func (stubCouponService) GetCouponDetails(context.Context, string) (*models.CouponDetailResponse, error) {
+	return nil, errStub
+}
+func (stubCouponService) UpdateCoupon(context.Context, string) (int64, error) { return 0, errStub }
+func (stubCouponService) ImportCoupons(context.Context, []models.ImportCouponRow) *models.ImportCouponsResponse {
+	return &models.ImportCouponsResponse{}
+}
+func (stubCouponService) ExportCoupons(context.Context, func(*models.Coupon) error) error {
+	return errStub
+}
+func (stubCouponService) ExportClaims(context.Context, string, func(*models.Claim) error) error {
+	return errStub
+}
+func (stubCouponService) ListCoupons(context.Context, models.Page) (*models.CouponPage, error) {
+	return nil, errStub
+}
+func (stubCouponService) GetCouponsByNames(context.Context, []string) (map[string]*models.Coupon, error) {
+	return nil, errStub
+}
+func (stubCouponService) ListClaimsByCoupons(context.Context, []string, models.Page) (map[string]*models.ClaimPage, error) {
+	return nil, errStub
+}
+func (stubCouponService) ListClaimsByUsers(context.Context, []string, models.Page) (map[string]*models.ClaimPage, error) {
+	return nil, errStub
+}
+func (stubCouponService) LookupCode(context.Context, string) (*models.CodeLookupResponse, error) {
+	return nil, errStub
+}
+func (stubCouponService) RedeemCode(context.Context, string) (*models.CodeRedemption, error) {
+	return nil, errStub
+}
+
+type stubAccessListService struct{}
+
+func (stubAccessListService) AddToAllowlist(context.Context, string, []string) (*models.AccessListResponse, error) {
+	return nil, errStub
+}
+func (stubAccessListService) ListAllowlist(context.Context, string) ([]models.AllowlistEntry, error) {
+	return nil, errStub
+}
+func (stubAccessListService) RemoveFromAllowlist(context.Context, string, string) error {
+	return errStub
+}
+func (stubAccessListService) AddToDenylist(context.Context, []string, string) (*models.AccessListResponse, error) {
+	return nil, errStub
+}
+func (stubAccessListService) ListDenylist(context.Context) ([]models.DenylistEntry, error) {
+	return nil, errStub
+}
+func (stubAccessListService) RemoveFromDenylist(context.Context, string) error { return errStub }
+
+type stubAPIKeyService struct{}
+
+func (stubAPIKeyService) CreateAPIKey(context.Context, *models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error) {
+	return nil, errStub
+}
+func (stubAPIKeyService) ListAPIKeys(context.Context) ([]models.APIKey, error) { return nil, errStub }
+func (stubAPIKeyService) RevokeAPIKey(context.Context, int64) error            { return errStub }
+func (stubAPIKeyService) AuthenticateAPIKey(context.Context, string) (*models.APIKey, error) {
+	return nil, errStub
+}
+
+type stubQueueService struct{}
+
+func (stubQueueService) JoinQueue(context.Context, *models.ClaimCouponRequest) (*models.QueueTicket, error) {
+	return nil, errStub
+}
+func (stubQueueService) GetTicket(context.Context, string) (*models.QueueTicket, error) {
+	return nil, errStub
+}
+func (stubQueueService) ProcessNext(context.Context, time.Duration) (bool, error) {
+	return false, errStub
+}
+func (stubQueueService) Run(context.Context, service.QueueConfig) {}
+
+type stubLotteryService struct{}
+
+func (stubLotteryService) DrawLottery(context.Context, string, *models.DrawLotteryRequest) (*models.LotteryDraw, error) {
+	return nil, errStub
+}
+func (stubLotteryService) GetDraw(context.Context, string) (*models.LotteryDraw, error) {
+	return nil, errStub
+}
+
+type allHandlers []routerSetup
+
+func (hs allHandlers) SetupRouter(router *mux.Router) {
+	for _, h := range hs {
+		h.SetupRouter(router)
+	}
+}
+
+// stubHandlers returns every authenticated handler, as registered in cmd/api/http, backed by stubs
+func stubHandlers() allHandlers {
+	return allHandlers{
+		NewCouponHandler(stubCouponService{}),
+		NewCodeHandler(stubCouponService{}),
+		NewAccessListHandler(stubAccessListService{}),
+		NewAPIKeyHandler(stubAPIKeyService{}),
+		NewQueueHandler(stubQueueService{}),
+		NewLotteryHandler(stubLotteryService{}),
+		&LogLevelHandler{},
+	}
+}
+
+func TestRoutes_RolePermissions(t *testing.T) {
+	logger.Init()
+
+	handlers := stubHandlers()
+
+	const (
+		admin    = middleware.RoleAdmin
+		operator = middleware.RoleOperator
+		customer = middleware.RoleCustomer
+		readOnly = middleware.RoleReadOnly
+	)
+
+	routes := []struct {
+		method  string
+		path    string
+		body    string
+		allowed []string
+	}{
+		{http.MethodPost, "/api/coupons", `{"name":"FLASH25","amount":1}`, []string{admin}},
+		{http.MethodPost, "/api/coupons/claim", `{"coupon_name":"FLASH25"}`, []string{admin, customer}},
+		{http.MethodGet, "/api/coupons/export", "", []string{admin, operator, readOnly}},
+		{http.MethodPost, "/api/coupons/import", "name,amount\nFLASH25,1\n", []string{admin}},
+		{http.MethodGet, "/api/coupons/FLASH25/claims/export", "", []string{admin, operator, readOnly}},
+		{http.MethodGet, "/api/coupons/FLASH25", "", []string{admin, operator, customer, readOnly}},
+		{http.MethodPut, "/api/coupons/FLASH25", "", []string{admin}},
+		{http.MethodPatch, "/api/coupons/FLASH25", "", []string{admin}},
+		{http.MethodGet, "/api/codes/FLASH25-7KQ2-M9XD-H4TC", "", []string{admin, operator, readOnly}},
+		{http.MethodPost, "/api/codes/FLASH25-7KQ2-M9XD-H4TC/redeem", "", []string{admin, operator}},
+		{http.MethodGet, "/api/admin/coupons/FLASH25/allowlist", "", []string{admin, operator, readOnly}},
+		{http.MethodPost, "/api/admin/coupons/FLASH25/allowlist", `{"user_ids":["user1"]}`, []string{admin}},
+		{http.MethodDelete, "/api/admin/coupons/FLASH25/allowlist/user1", "", []string{admin}},
+		{http.MethodGet, "/api/admin/denylist", "", []string{admin, operator, readOnly}},
+		{http.MethodPost, "/api/admin/denylist", `{"user_ids":["user1"]}`, []string{admin}},
+		{http.MethodDelete, "/api/admin/denylist/user1", "", []string{admin}},
+		{http.MethodGet, "/api/admin/api-keys", "", []string{admin}},
+		{http.MethodPost, "/api/admin/api-keys", `{"name":"checkout","scopes":["coupons:claim"]}`, []string{admin}},
+		{http.MethodDelete, "/api/admin/api-keys/1", "", []string{admin}},
+		{http.MethodPost, "/api/coupons/FLASH25/queue", "", []string{admin, customer}},
+		{http.MethodGet, "/api/queue/5f1d7c1e-7a51-4d7b-9d55-0c7bb7f0a9b1", "", []string{admin, customer}},
+		{http.MethodGet, "/api/admin/log-level", "", []string{admin}},
+		{http.MethodPut, "/api/admin/log-level", `{"level":"nope"}`, []string{admin}},
+	}
+
+	for _, route := range routes {
+		for _, role := range []string{"", admin, operator, customer, readOnly} {
+			name := route.method + " " + route.path + " as " + role
+			if role == "" {
+				name = route.method + " " + route.path + " unauthenticated"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				var router *mux.Router
+				if role == "" {
+					router = newTestRouter(handlers)
+				} else {
+					router = newTestRouter(handlers, role)
+				}
+
+				req := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+				if route.path == "/api/coupons/import" {
+					req.Header.Set("Content-Type", "text/csv")
+				} else if route.body != "" {
+					req.Header.Set("Content-Type", "application/json")
+				}
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				switch {
+				case role == "":
+					assert.Equal(t, http.StatusUnauthorized, rec.Code)
+					assert.Contains(t, rec.Body.String(), `"code":"UNAUTHENTICATED"`)
+				case contains(route.allowed, role):
+					assert.NotEqual(t, http.StatusUnauthorized, rec.Code)
+					assert.NotEqual(t, http.StatusForbidden, rec.Code)
+				default:
+					assert.Equal(t, http.StatusForbidden, rec.Code)
+					assert.Contains(t, rec.Body.String(), `"code":"FORBIDDEN"`)
+				}
+			})
+		}
+	}
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/wazadio/coupon-system/internal/database"
+)
+
+var errNotConnected = errors.New("not connected to the database")
+
+// DatabaseCheck pings the database
+func DatabaseCheck(db *sql.DB) Check {
+	return Check{Name: "database", Run: func(ctx context.Context) error {
+		if db == nil {
+			return errNotConnected
+		}
+		return db.PingContext(ctx)
+	}}
+}
+
+// SchemaCheck fails until the database schema reaches database.SchemaVersion, so an
+// instance deployed ahead of its migration takes no traffic
+func SchemaCheck(db *sql.DB) Check {
+	return Check{Name: "schema", Run: func(ctx context.Context) error {
+		if db == nil {
+			return errNotConnected
+		}
+		var version int
+		if err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+			return fmt.Errorf("error reading schema version: %v", err)
+		}
+		if version < database.SchemaVersion {
+			return fmt.Errorf("schema version is %d, %d is required", version, database.SchemaVersion)
+		}
+		return nil
+	}}
+}
+
+// PoolCheck fails while the share of open connections in use reaches saturation
+func PoolCheck(db *sql.DB, saturation float64) Check {
+	return Check{Name: "connection_pool", Run: func(ctx context.Context) error {
+		if db == nil {
+			return errNotConnected
+		}
+		stats := db.Stats()
+		if stats.MaxOpenConnections > 0 && float64(stats.InUse) >= saturation*float64(stats.MaxOpenConnections) {
+			return fmt.Errorf("%d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wazadio/coupon-system/internal/database"
+)
+
+func TestSchemaCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		wantErr bool
+	}{
+		{"current", database.SchemaVersion, false},
+		{"newer", database.SchemaVersion + 1, false},
+		{"behind", database.SchemaVersion - 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT COALESCE\\(MAX\\(version\\), 0\\) FROM schema_version").
+				WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(tt.version))
+
+			err = SchemaCheck(db).Run(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDatabaseChecks_NotConnected(t *testing.T) {
+	for _, check := range []Check{DatabaseCheck(nil), SchemaCheck(nil), PoolCheck(nil, 0.9)} {
+		assert.ErrorIs(t, check.Run(context.Background()), errNotConnected, check.Name)
+	}
+}
+
+func TestPoolCheck(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// An idle pool with no limit on open connections is never saturated
+	assert.NoError(t, PoolCheck(db, 0.9).Run(context.Background()))
+
+	db.SetMaxOpenConns(1)
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.EqualError(t, PoolCheck(db, 0.9).Run(context.Background()), "1 of 1 connections in use")
+}
@@ -0,0 +1,164 @@
+// Package health runs the readiness checks behind /readyz. An instance is ready while
+// every check passes and it is not shutting down, so load balancers stop routing to it as
+// soon as its database is unreachable or it begins to stop.
+package health
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+// Statuses reported by Checker.Ready
+const (
+	StatusReady        = "ready"
+	StatusUnready      = "unready"
+	StatusShuttingDown = "shutting_down"
+	StatusUp           = "up"
+	StatusDown         = "down"
+)
+
+// Config holds readiness configuration
+type Config struct {
+	// Timeout bounds each check, so a hung database fails the probe instead of stalling it
+	Timeout time.Duration `yaml:"timeout"`
+	// PoolSaturation is the share of the connection pool in use at which the instance
+	// reports itself unready, shedding traffic to instances with connections to spare
+	PoolSaturation float64 `yaml:"pool_saturation"`
+	// DrainDelay is how long the server keeps serving once unready on shutdown, so load
+	// balancers notice before connections are closed
+	DrainDelay time.Duration `yaml:"drain_delay"`
+}
+
+// DefaultConfig times checks out after 2s and is unready with 90% of the pool in use
+func DefaultConfig() *Config {
+	return &Config{
+		Timeout:        2 * time.Second,
+		PoolSaturation: 0.9,
+	}
+}
+
+// LoadEnv overrides the settings whose environment variable is set
+func (c *Config) LoadEnv() error {
+	for _, setting := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"READINESS_TIMEOUT", &c.Timeout},
+		{"SHUTDOWN_DRAIN_DELAY", &c.DrainDelay},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q", setting.name, value)
+			}
+			*setting.value = d
+		}
+	}
+	if saturation := os.Getenv("READINESS_POOL_SATURATION"); saturation != "" {
+		s, err := strconv.ParseFloat(saturation, 64)
+		if err != nil {
+			return fmt.Errorf("invalid READINESS_POOL_SATURATION: %q", saturation)
+		}
+		c.PoolSaturation = s
+	}
+	return nil
+}
+
+// Validate checks the readiness settings are in range
+func (c *Config) Validate() error {
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got %s", c.Timeout)
+	}
+	if c.DrainDelay < 0 {
+		return fmt.Errorf("drain_delay must not be negative, got %s", c.DrainDelay)
+	}
+	if c.PoolSaturation <= 0 || c.PoolSaturation > 1 {
+		return fmt.Errorf("pool_saturation must be above 0 and at most 1, got %v", c.PoolSaturation)
+	}
+	return nil
+}
+
+// Check is one named readiness check. Run returns an error when the check fails.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Checker runs readiness checks and tracks whether the server is shutting down
+type Checker struct {
+	checks       []Check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// NewChecker creates a checker running checks, each bounded by timeout
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Ready runs every check concurrently and reports each one's status and latency. ready is
+// false when a check fails or once Shutdown was called, in which case no check is run.
+func (c *Checker) Ready(ctx context.Context) (report models.ReadinessResponse, ready bool) {
+	report.Checks = make(map[string]models.ReadinessCheck, len(c.checks))
+	if c.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+		return report, false
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	ready = true
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusUp {
+				ready = false
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	report.Status = StatusReady
+	if !ready {
+		report.Status = StatusUnready
+	}
+	return report, ready
+}
+
+// run runs check within the checker's timeout
+func (c *Checker) run(ctx context.Context, check Check) models.ReadinessCheck {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := models.ReadinessCheck{
+		Status:    StatusUp,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Shutdown makes every later Ready report the instance unready
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
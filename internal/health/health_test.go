@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func check(name string, err error) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error { return err }}
+}
+
+func TestChecker_Ready(t *testing.T) {
+	report, ready := NewChecker(time.Second, check("database", nil), check("schema", nil)).Ready(context.Background())
+
+	assert.True(t, ready)
+	assert.Equal(t, StatusReady, report.Status)
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, StatusUp, report.Checks["database"].Status)
+	assert.Empty(t, report.Checks["database"].Error)
+}
+
+func TestChecker_FailingCheck(t *testing.T) {
+	checker := NewChecker(time.Second, check("database", nil), check("schema", errors.New("schema version is 0, 1 is required")))
+	report, ready := checker.Ready(context.Background())
+
+	assert.False(t, ready)
+	assert.Equal(t, StatusUnready, report.Status)
+	assert.Equal(t, StatusUp, report.Checks["database"].Status)
+	assert.Equal(t, StatusDown, report.Checks["schema"].Status)
+	assert.Equal(t, "schema version is 0, 1 is required", report.Checks["schema"].Error)
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	hung := Check{Name: "database", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	start := time.Now()
+	report, ready := NewChecker(20*time.Millisecond, hung).Ready(context.Background())
+
+	assert.False(t, ready)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, StatusDown, report.Checks["database"].Status)
+	assert.GreaterOrEqual(t, report.Checks["database"].LatencyMS, 20.0)
+}
+
+func TestChecker_Shutdown(t *testing.T) {
+	ran := false
+	checker := NewChecker(time.Second, Check{Name: "database", Run: func(ctx context.Context) error {
+		ran = true
+		return nil
+	}})
+	checker.Shutdown()
+
+	report, ready := checker.Ready(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, StatusShuttingDown, report.Status)
+	assert.False(t, ran, "no check runs once shutting down")
+}
+
+// loadConfig loads the default config as the API does, from the environment
+func loadConfig() (*Config, error) {
+	config := DefaultConfig()
+	if err := config.LoadEnv(); err != nil {
+		return nil, err
+	}
+	return config, config.Validate()
+}
+
+func TestConfig_LoadEnv(t *testing.T) {
+	for _, name := range []string{"READINESS_TIMEOUT", "READINESS_POOL_SATURATION", "SHUTDOWN_DRAIN_DELAY"} {
+		t.Setenv(name, "")
+	}
+	config, err := loadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), config)
+
+	t.Setenv("READINESS_TIMEOUT", "500ms")
+	t.Setenv("READINESS_POOL_SATURATION", "1")
+	t.Setenv("SHUTDOWN_DRAIN_DELAY", "10s")
+	config, err = loadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Timeout: 500 * time.Millisecond, PoolSaturation: 1, DrainDelay: 10 * time.Second}, config)
+
+	tests := []struct {
+		name, value string
+	}{
+		{"READINESS_TIMEOUT", "0s"},
+		{"READINESS_TIMEOUT", "soon"},
+		{"READINESS_POOL_SATURATION", "0"},
+		{"READINESS_POOL_SATURATION", "1.5"},
+		{"SHUTDOWN_DRAIN_DELAY", "-1s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			_, err := loadConfig()
+			assert.Error(t, err)
+		})
+	}
+}
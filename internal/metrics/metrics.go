@@ -0,0 +1,83 @@
+// Package metrics defines the Prometheus collectors of the coupon system and serves them.
+// Collectors live in their own registry, so /metrics only exposes what is defined here
+// plus the Go runtime and process collectors.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric defined here
+const namespace = "coupon"
+
+// Registry holds every collector served by Handler
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	// HTTPRequests counts HTTP requests by route template, method and status code.
+	// Routes are templates such as /api/coupons/{name}, so coupon names do not become labels.
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration observes how long HTTP requests take by route template and method
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// ClaimOutcomes counts claim attempts by outcome, such as success or sold_out
+	ClaimOutcomes = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "claims_total",
+		Help:      "Claim attempts by outcome.",
+	}, []string{"outcome"})
+
+	// TransactionDuration observes how long database transactions take by operation,
+	// including the time spent waiting for row locks
+	TransactionDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "transaction_duration_seconds",
+		Help:      "Database transaction duration by operation, including lock waits.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the collectors of Registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDB exposes the connection pool statistics of db, read from sql.DB.Stats on each scrape
+func RegisterDB(db *sql.DB, dbName string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// ObserveTransaction records the duration of a transaction of operation started at start.
+// Call it deferred right after beginning the transaction.
+func ObserveTransaction(operation string, start time.Time) {
+	TransactionDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
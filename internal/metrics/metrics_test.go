@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ExposesCollectors(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, RegisterDB(db, "coupons_test"))
+
+	ObserveTransaction("test_operation", time.Now().Add(-time.Second))
+	ClaimOutcomes.WithLabelValues("success").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	for _, want := range []string{
+		`coupon_db_transaction_duration_seconds_count{operation="test_operation"} 1`,
+		`coupon_claims_total{outcome="success"}`,
+		`go_sql_max_open_connections{db_name="coupons_test"}`,
+		`go_goroutines`,
+	} {
+		assert.True(t, strings.Contains(body, want), "missing %s", want)
+	}
+}
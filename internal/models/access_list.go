@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// AllowlistEntry is a user allowed to claim an allowlist-only coupon
+type AllowlistEntry struct {
+	CouponName string    `json:"coupon_name"`
+	UserID     string    `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DenylistEntry is a user blocked from claiming any coupon
+type DenylistEntry struct {
+	UserID    string    `json:"user_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccessListRequest is the request body for bulk adding users to an allowlist or the denylist.
+// Reason is only stored for denylist entries.
+type AccessListRequest struct {
+	UserIDs []string `json:"user_ids"`
+	Reason  string   `json:"reason,omitempty"`
+}
+
+// AccessListResponse reports how many of the submitted user IDs were newly added
+type AccessListResponse struct {
+	Submitted int   `json:"submitted"`
+	Added     int64 `json:"added"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// APIKey is a credential for backend services. Scopes are the permissions it grants and
+// CouponNames, when not empty, restricts it to those coupons. The secret key itself is never stored.
+type APIKey struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Scopes      []string   `json:"scopes"`
+	CouponNames []string   `json:"coupon_names"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreateAPIKeyRequest is the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name        string   `json:"name" validate:"required,max=255"`
+	Scopes      []string `json:"scopes" validate:"required"`
+	CouponNames []string `json:"coupon_names,omitempty"`
+}
+
+// CreateAPIKeyResponse returns the new key. Key is only ever shown in this response.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -4,38 +4,128 @@ import "time"
 
 // Coupon represents a coupon in the system
 type Coupon struct {
-	ID              int64     `json:"id"`
-	Name            string    `json:"name"`
-	Amount          int       `json:"amount"`
-	RemainingAmount int       `json:"remaining_amount"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID               int64            `json:"id"`
+	Name             string           `json:"name"`
+	Amount           int              `json:"amount"`
+	RemainingAmount  int              `json:"remaining_amount"`
+	UniqueCodes      bool             `json:"unique_codes"`
+	ExpiresAt        *time.Time       `json:"expires_at,omitempty"`
+	EligibilityRules EligibilityRules `json:"eligibility_rules,omitempty"`
+	AllowlistOnly    bool             `json:"allowlist_only"`
+	QueueMode        bool             `json:"queue_mode"`
+	LotteryClosesAt  *time.Time       `json:"lottery_closes_at,omitempty"`
+	LotterySeedHash  string           `json:"lottery_seed_hash,omitempty"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
 }
 
-// Claim represents a user's claim of a coupon
+// Claim represents a user's claim of a coupon.
+// Entry is set when a lottery coupon recorded the claim as an entry instead of granting it.
 type Claim struct {
 	ID         int       `json:"id"`
 	UserID     string    `json:"user_id"`
 	CouponName string    `json:"coupon_name"`
+	Code       string    `json:"code,omitempty"`
 	ClaimedAt  time.Time `json:"claimed_at"`
+	Entry      bool      `json:"-"`
 }
 
 // CreateCouponRequest is the request body for creating a coupon
 type CreateCouponRequest struct {
-	Name   string `json:"name"`
-	Amount int    `json:"amount"`
+	Name             string           `json:"name" validate:"required,trimmed,max=255,name"`
+	Amount           int              `json:"amount" validate:"min=1"`
+	UniqueCodes      bool             `json:"unique_codes"`
+	ExpiresAt        *time.Time       `json:"expires_at,omitempty"`
+	EligibilityRules EligibilityRules `json:"eligibility_rules,omitempty"`
+	AllowlistOnly    bool             `json:"allowlist_only"`
+	QueueMode        bool             `json:"queue_mode"`
+	LotteryClosesAt  *time.Time       `json:"lottery_closes_at,omitempty"`
+	LotterySeedHash  string           `json:"lottery_seed_hash,omitempty"`
 }
 
-// ClaimCouponRequest is the request body for claiming a coupon
+// ClaimCouponRequest is the request body for claiming a coupon.
+// Attributes describe the claimant and are checked against the coupon's eligibility rules,
+// so they must be trusted: middleware.AuthorizeClaim replaces them with the token's for end
+// users and only accepts them from API keys allowed to send them.
 type ClaimCouponRequest struct {
-	UserID     string `json:"user_id"`
-	CouponName string `json:"coupon_name"`
+	UserID     string                 `json:"user_id" validate:"required,trimmed,max=255"`
+	CouponName string                 `json:"coupon_name" validate:"required,max=255"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// ClaimPolicy holds the coupon settings checked before a claim reaches the row lock
+type ClaimPolicy struct {
+	EligibilityRules EligibilityRules
+	QueueMode        bool
+	LotteryClosesAt  *time.Time
+}
+
+// ClaimCouponResponse is the response for a successful claim.
+// Code is only set for coupons created with unique codes.
+type ClaimCouponResponse struct {
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// UpdateCouponResponse is the response for updating a coupon
+type UpdateCouponResponse struct {
+	Message      string `json:"message"`
+	RowsAffected int64  `json:"rows_affected"`
 }
 
 // CouponDetailResponse is the response for getting coupon details
 type CouponDetailResponse struct {
-	Name            string   `json:"name"`
-	Amount          int      `json:"amount"`
-	RemainingAmount int      `json:"remaining_amount"`
-	ClaimedBy       []string `json:"claimed_by"`
+	Name             string           `json:"name"`
+	Amount           int              `json:"amount"`
+	RemainingAmount  int              `json:"remaining_amount"`
+	UniqueCodes      bool             `json:"unique_codes"`
+	ExpiresAt        *time.Time       `json:"expires_at,omitempty"`
+	EligibilityRules EligibilityRules `json:"eligibility_rules,omitempty"`
+	AllowlistOnly    bool             `json:"allowlist_only"`
+	QueueMode        bool             `json:"queue_mode"`
+	LotteryClosesAt  *time.Time       `json:"lottery_closes_at,omitempty"`
+	LotterySeedHash  string           `json:"lottery_seed_hash,omitempty"`
+	ClaimedBy        []string         `json:"claimed_by"`
+}
+
+// ImportCouponRow is a single coupon definition read from a bulk import file.
+// Err is set when the row could not be read; it is then reported and not created.
+type ImportCouponRow struct {
+	Row int
+	CreateCouponRequest
+	Err error
+}
+
+// ImportRowError describes why a single row of a bulk import was rejected. Err is the
+// error the row failed with; Error is the message reported for it, which the transport
+// maps from Err so internal errors are not exposed.
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+	Err   error  `json:"-"`
+}
+
+// ImportCouponsResponse is the response for a bulk coupon import
+type ImportCouponsResponse struct {
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// CodeLookupResponse resolves a generated code back to its coupon and, once assigned, its claim
+type CodeLookupResponse struct {
+	Code       string     `json:"code"`
+	Coupon     Coupon     `json:"coupon"`
+	Claim      *Claim     `json:"claim"`
+	RedeemedAt *time.Time `json:"redeemed_at"`
+}
+
+// CodeRedemption is the response for a successfully redeemed code
+type CodeRedemption struct {
+	Code       string    `json:"code"`
+	CouponName string    `json:"coupon_name"`
+	UserID     string    `json:"user_id"`
+	RedeemedAt time.Time `json:"redeemed_at"`
 }
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Eligibility rule operators
+const (
+	OperatorEq    = "eq"
+	OperatorNeq   = "neq"
+	OperatorIn    = "in"
+	OperatorNotIn = "not_in"
+	OperatorGt    = "gt"
+	OperatorGte   = "gte"
+	OperatorLt    = "lt"
+	OperatorLte   = "lte"
+)
+
+// EligibilityRule is a single named condition on a claimant attribute, e.g.
+// {"name": "gold_or_above", "attribute": "tier", "operator": "gte", "value": "gold",
+// "order": ["bronze", "silver", "gold", "platinum"]}.
+// Order ranks string values for the gt/gte/lt/lte operators.
+type EligibilityRule struct {
+	Name      string      `json:"name" validate:"required"`
+	Attribute string      `json:"attribute" validate:"required"`
+	Operator  string      `json:"operator"`
+	Value     interface{} `json:"value"`
+	Order     []string    `json:"order,omitempty"`
+}
+
+// EligibilityRules is the list of rules stored on a coupon. A claimant must satisfy all of them.
+type EligibilityRules []EligibilityRule
+
+// Value stores the rules as JSON, or NULL when there are none
+func (r EligibilityRules) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+// Scan reads rules stored as JSON
+func (r *EligibilityRules) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("cannot scan %T into EligibilityRules", src)
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEligibilityRules_ValueEmpty(t *testing.T) {
+	value, err := EligibilityRules(nil).Value()
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func TestEligibilityRules_RoundTrip(t *testing.T) {
+	rules := EligibilityRules{
+		{Name: "gold_or_above", Attribute: "tier", Operator: OperatorGte, Value: "gold", Order: []string{"silver", "gold"}},
+	}
+
+	value, err := rules.Value()
+	assert.NoError(t, err)
+
+	var scanned EligibilityRules
+	assert.NoError(t, scanned.Scan(value))
+	assert.Equal(t, rules, scanned)
+}
+
+func TestEligibilityRules_ScanNull(t *testing.T) {
+	rules := EligibilityRules{{Name: "stale"}}
+	assert.NoError(t, rules.Scan(nil))
+	assert.Nil(t, rules)
+}
+
+func TestEligibilityRules_ScanUnsupportedType(t *testing.T) {
+	var rules EligibilityRules
+	assert.Error(t, rules.Scan(42))
+}
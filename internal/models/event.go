@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types written to the coupon_events outbox
+const (
+	EventLotteryLost = "lottery.lost"
+)
+
+// CouponEvent is an event from the coupon_events outbox, as delivered to the events webhook.
+// IDs are unique and increasing, so receivers can drop events delivered more than once.
+type CouponEvent struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	CouponName string          `json:"coupon_name"`
+	UserID     string          `json:"user_id"`
+	Payload    json.RawMessage `json:"payload"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
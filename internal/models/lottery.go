@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Results recorded on lottery entries by the draw
+const (
+	LotteryResultWon  = "won"
+	LotteryResultLost = "lost"
+)
+
+// DrawLotteryRequest is the request body for drawing a lottery. Seed reveals the seed the
+// coupon's lottery_seed_hash committed to.
+type DrawLotteryRequest struct {
+	Seed string `json:"seed" validate:"required"`
+}
+
+// LotteryDraw is the auditable record of a lottery draw. Re-running the draw with Seed over
+// Entrants gives the same Winners, in the same order, and SeedHash is the commitment to Seed
+// published when the coupon was created.
+type LotteryDraw struct {
+	CouponName string    `json:"coupon_name"`
+	Seed       string    `json:"seed"`
+	SeedHash   string    `json:"seed_hash"`
+	Entries    int       `json:"entries"`
+	Entrants   []string  `json:"entrants"`
+	Winners    []string  `json:"winners"`
+	DrawnAt    time.Time `json:"drawn_at"`
+}
+
+// LotteryEntrants are the users who entered a lottery coupon, read in the transaction that
+// draws it
+type LotteryEntrants struct {
+	CouponName      string
+	RemainingAmount int
+	LotteryClosesAt *time.Time
+	SeedHash        string
+	Drawn           bool
+	UserIDs         []string
+}
@@ -0,0 +1,20 @@
+package models
+
+// Page selects up to Limit items with an ID greater than AfterID, in ID order. IDs only
+// grow, so a page boundary stays put while rows are added.
+type Page struct {
+	AfterID int64
+	Limit   int
+}
+
+// CouponPage is one page of coupons. HasNextPage reports whether more follow the last one.
+type CouponPage struct {
+	Coupons     []Coupon
+	HasNextPage bool
+}
+
+// ClaimPage is one page of the claims of a coupon or a user
+type ClaimPage struct {
+	Claims      []Claim
+	HasNextPage bool
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Statuses a queue ticket moves through, from waiting to claimed or failed
+const (
+	QueueStatusWaiting    = "waiting"
+	QueueStatusProcessing = "processing"
+	QueueStatusClaimed    = "claimed"
+	QueueStatusFailed     = "failed"
+)
+
+// QueueTicket is a user's place in a coupon's waiting room.
+// Position counts from 1 and is only set while the ticket is waiting; Error explains a
+// failed claim and Code is the assigned code of a claimed unique-code coupon.
+type QueueTicket struct {
+	ID          int64      `json:"-"`
+	Ticket      string     `json:"ticket"`
+	CouponName  string     `json:"coupon_name"`
+	UserID      string     `json:"user_id"`
+	Status      string     `json:"status"`
+	Position    int        `json:"position,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Code        string     `json:"code,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
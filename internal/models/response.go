@@ -0,0 +1,30 @@
+package models
+
+// MessageResponse is the response of endpoints that only confirm an action
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// HealthResponse is the response of the health check
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadinessResponse is the response of the readiness probe. Status is ready, unready when
+// a check is down, or shutting_down once the server has begun to stop.
+type ReadinessResponse struct {
+	Status string                    `json:"status"`
+	Checks map[string]ReadinessCheck `json:"checks"`
+}
+
+// ReadinessCheck is the outcome of one readiness check and how long it took
+type ReadinessCheck struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// LogLevel is the minimum level the API logs, read and changed by admins at runtime
+type LogLevel struct {
+	Level string `json:"level" validate:"required"`
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+// AccessListRepository defines the interface for coupon allowlist and user denylist operations
+type AccessListRepository interface {
+	AddToAllowlist(ctx context.Context, couponName string, userIDs []string) (added int64, err error)
+	ListAllowlist(ctx context.Context, couponName string) ([]models.AllowlistEntry, error)
+	RemoveFromAllowlist(ctx context.Context, couponName, userID string) (rowsAffected int64, err error)
+	AddToDenylist(ctx context.Context, userIDs []string, reason string) (added int64, err error)
+	ListDenylist(ctx context.Context) ([]models.DenylistEntry, error)
+	RemoveFromDenylist(ctx context.Context, userID string) (rowsAffected int64, err error)
+}
+
+// accessListRepository handles database operations for allowlists and the denylist
+type accessListRepository struct {
+	db *sql.DB
+}
+
+// NewAccessListRepository creates a new AccessListRepository with injected database connection
+func NewAccessListRepository(db *sql.DB) AccessListRepository {
+	return &accessListRepository{
+		db: db,
+	}
+}
+
+// AddToAllowlist adds users to a coupon's allowlist. Users already on it are skipped.
+func (r *accessListRepository) AddToAllowlist(ctx context.Context, couponName string, userIDs []string) (int64, error) {
+	query := `
+		INSERT INTO coupon_allowlist (coupon_name, user_id)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING
+	`
+
+	result, err := execContext(ctx, r.db, "INSERT coupon_allowlist", query, couponName, pq.Array(userIDs))
+	if err != nil {
+		// Foreign key violation means the coupon does not exist
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return 0, ErrCouponNotFound
+		}
+		return 0, fmt.Errorf("error adding to allowlist: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// ListAllowlist returns the users on a coupon's allowlist
+func (r *accessListRepository) ListAllowlist(ctx context.Context, couponName string) ([]models.AllowlistEntry, error) {
+	var exists bool
+	err := queryRowContext(ctx, r.db, "SELECT coupons", `SELECT EXISTS(SELECT 1 FROM coupons WHERE name = $1)`, couponName).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("error checking coupon: %v", err)
+	}
+	if !exists {
+		return nil, ErrCouponNotFound
+	}
+
+	query := `
+		SELECT coupon_name, user_id, created_at
+		FROM coupon_allowlist
+		WHERE coupon_name = $1
+		ORDER BY user_id ASC
+	`
+
+	rows, err := queryContext(ctx, r.db, "SELECT coupon_allowlist", query, couponName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching allowlist: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AllowlistEntry{}
+	for rows.Next() {
+		var entry models.AllowlistEntry
+		if err := rows.Scan(&entry.CouponName, &entry.UserID, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning allowlist entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating allowlist: %v", err)
+	}
+
+	return entries, nil
+}
+
+// RemoveFromAllowlist removes a user from a coupon's allowlist
+func (r *accessListRepository) RemoveFromAllowlist(ctx context.Context, couponName, userID string) (int64, error) {
+	result, err := execContext(ctx, r.db, "DELETE coupon_allowlist", `DELETE FROM coupon_allowlist WHERE coupon_name = $1 AND user_id = $2`, couponName, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error removing from allowlist: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// AddToDenylist blocks users from claiming any coupon. Users already on it keep their original reason.
+func (r *accessListRepository) AddToDenylist(ctx context.Context, userIDs []string, reason string) (int64, error) {
+	query := `
+		INSERT INTO user_denylist (user_id, reason)
+		SELECT unnest($1::text[]), $2
+		ON CONFLICT DO NOTHING
+	`
+
+	result, err := execContext(ctx, r.db, "INSERT user_denylist", query, pq.Array(userIDs), reason)
+	if err != nil {
+		return 0, fmt.Errorf("error adding to denylist: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// ListDenylist returns every denied user
+func (r *accessListRepository) ListDenylist(ctx context.Context) ([]models.DenylistEntry, error) {
+	query := `
+		SELECT user_id, reason, created_at
+		FROM user_denylist
+		ORDER BY user_id ASC
+	`
+
+	rows, err := queryContext(ctx, r.db, "SELECT user_denylist", query)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching denylist: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []models.DenylistEntry{}
+	for rows.Next() {
+		var entry models.DenylistEntry
+		if err := rows.Scan(&entry.UserID, &entry.Reason, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning denylist entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating denylist: %v", err)
+	}
+
+	return entries, nil
+}
+
+// RemoveFromDenylist lifts the block on a user
+func (r *accessListRepository) RemoveFromDenylist(ctx context.Context, userID string) (int64, error) {
+	result, err := execContext(ctx, r.db, "DELETE user_denylist", `DELETE FROM user_denylist WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error removing from denylist: %v", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddToAllowlist_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccessListRepository(db)
+
+	mock.ExpectExec("INSERT INTO coupon_allowlist").
+		WithArgs("B2B", pq.Array([]string{"user1", "user2"})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	added, err := repo.AddToAllowlist(context.Background(), "B2B", []string{"user1", "user2"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddToAllowlist_CouponNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccessListRepository(db)
+
+	mock.ExpectExec("INSERT INTO coupon_allowlist").
+		WithArgs("NONEXISTENT", pq.Array([]string{"user1"})).
+		WillReturnError(&pq.Error{Code: "23503"})
+
+	_, err = repo.AddToAllowlist(context.Background(), "NONEXISTENT", []string{"user1"})
+	assert.Equal(t, ErrCouponNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAllowlist_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccessListRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("B2B").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT coupon_name, user_id, created_at FROM coupon_allowlist").
+		WithArgs("B2B").
+		WillReturnRows(sqlmock.NewRows([]string{"coupon_name", "user_id", "created_at"}).
+			AddRow("B2B", "user1", now).
+			AddRow("B2B", "user2", now))
+
+	entries, err := repo.ListAllowlist(context.Background(), "B2B")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "user2", entries[1].UserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAllowlist_CouponNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccessListRepository(db)
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("NONEXISTENT").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	_, err = repo.ListAllowlist(context.Background(), "NONEXISTENT")
+	assert.Equal(t, ErrCouponNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRemoveFromAllowlist_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccessListRepository(db)
+
+	mock.ExpectExec("DELETE FROM coupon_allowlist").
+		WithArgs("B2B", "user1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rowsAffected, err := repo.RemoveFromAllowlist(context.Background(), "B2B", "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rowsAffected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddToDenylist_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccessListRepository(db)
+
+	mock.ExpectExec("INSERT INTO user_denylist").
+		WithArgs(pq.Array([]string{"abuser"}), "chargeback fraud").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	added, err := repo.AddToDenylist(context.Background(), []string{"abuser"}, "chargeback fraud")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddToDenylist_DatabaseError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccessListRepository(db)
+
+	mock.ExpectExec("INSERT INTO user_denylist").
+		WithArgs(pq.Array([]string{"abuser"}), "").
+		WillReturnError(errors.New("connection refused"))
+
+	_, err = repo.AddToDenylist(context.Background(), []string{"abuser"}, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error adding to denylist")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListDenylist_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccessListRepository(db)
+
+	mock.ExpectQuery("SELECT user_id, reason, created_at FROM user_denylist").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "reason", "created_at"}).
+			AddRow("abuser", "chargeback fraud", time.Now()))
+
+	entries, err := repo.ListDenylist(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "chargeback fraud", entries[0].Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRemoveFromDenylist_NotListed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccessListRepository(db)
+
+	mock.ExpectExec("DELETE FROM user_denylist").
+		WithArgs("user1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rowsAffected, err := repo.RemoveFromDenylist(context.Background(), "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), rowsAffected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
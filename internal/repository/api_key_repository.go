@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyRepository defines the interface for API key data operations
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error
+	GetActiveAPIKeyByPrefix(ctx context.Context, prefix string) (key *models.APIKey, keyHash string, err error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) (rowsAffected int64, err error)
+	TouchAPIKey(ctx context.Context, id int64) error
+}
+
+// apiKeyRepository handles database operations for API keys
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository with injected database connection
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+// CreateAPIKey stores a new key and fills in its ID and creation time
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, coupon_names)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := queryRowContext(ctx, r.db, "INSERT api_keys", query, key.Name, key.Prefix, keyHash, pq.Array(key.Scopes), pq.Array(key.CouponNames)).
+		Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating api key: %v", err)
+	}
+
+	return nil
+}
+
+// GetActiveAPIKeyByPrefix returns the unrevoked key with prefix together with its hash
+func (r *apiKeyRepository) GetActiveAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, string, error) {
+	query := `
+		SELECT id, name, prefix, key_hash, scopes, coupon_names, created_at, last_used_at
+		FROM api_keys
+		WHERE prefix = $1 AND revoked_at IS NULL
+	`
+
+	var (
+		key     models.APIKey
+		keyHash string
+	)
+	err := queryRowContext(ctx, r.db, "SELECT api_keys", query, prefix).Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&keyHash,
+		pq.Array(&key.Scopes),
+		pq.Array(&key.CouponNames),
+		&key.CreatedAt,
+		&key.LastUsedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", ErrAPIKeyNotFound
+		}
+		return nil, "", fmt.Errorf("error getting api key: %v", err)
+	}
+
+	return &key, keyHash, nil
+}
+
+// ListAPIKeys returns every key, including revoked ones, without their hashes
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := `
+		SELECT id, name, prefix, scopes, coupon_names, created_at, last_used_at, revoked_at
+		FROM api_keys
+		ORDER BY id ASC
+	`
+
+	rows, err := queryContext(ctx, r.db, "SELECT api_keys", query)
+	if err != nil {
+		return nil, fmt.Errorf("error listing api keys: %v", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var key models.APIKey
+		err := rows.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Prefix,
+			pq.Array(&key.Scopes),
+			pq.Array(&key.CouponNames),
+			&key.CreatedAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning api key: %v", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %v", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey marks a key as revoked. Already revoked keys are left untouched.
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, id int64) (int64, error) {
+	query := `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := execContext(ctx, r.db, "UPDATE api_keys", query, id)
+	if err != nil {
+		return 0, fmt.Errorf("error revoking api key: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// TouchAPIKey records that a key was just used
+func (r *apiKeyRepository) TouchAPIKey(ctx context.Context, id int64) error {
+	_, err := execContext(ctx, r.db, "UPDATE api_keys", `UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error updating api key last use: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+func TestCreateAPIKey_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+
+	now := time.Now()
+	key := &models.APIKey{Name: "checkout", Prefix: "a1b2c3", Scopes: []string{"coupons:claim"}, CouponNames: []string{}}
+	mock.ExpectQuery("INSERT INTO api_keys").
+		WithArgs("checkout", "a1b2c3", "hash", pq.Array([]string{"coupons:claim"}), pq.Array([]string{})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, now))
+
+	err = repo.CreateAPIKey(context.Background(), key, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), key.ID)
+	assert.Equal(t, now, key.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetActiveAPIKeyByPrefix_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+
+	mock.ExpectQuery("SELECT id, name, prefix, key_hash, scopes, coupon_names, created_at, last_used_at FROM api_keys").
+		WithArgs("a1b2c3").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "prefix", "key_hash", "scopes", "coupon_names", "created_at", "last_used_at"}).
+			AddRow(5, "checkout", "a1b2c3", "hash", "{coupons:claim,coupons:read}", "{FLASH25}", time.Now(), nil))
+
+	key, keyHash, err := repo.GetActiveAPIKeyByPrefix(context.Background(), "a1b2c3")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", keyHash)
+	assert.Equal(t, []string{"coupons:claim", "coupons:read"}, key.Scopes)
+	assert.Equal(t, []string{"FLASH25"}, key.CouponNames)
+	assert.Nil(t, key.LastUsedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetActiveAPIKeyByPrefix_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+
+	mock.ExpectQuery("SELECT id, name, prefix, key_hash").
+		WithArgs("unknown").
+		WillReturnError(sql.ErrNoRows)
+
+	_, _, err = repo.GetActiveAPIKeyByPrefix(context.Background(), "unknown")
+	assert.Equal(t, ErrAPIKeyNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAPIKeys_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT id, name, prefix, scopes, coupon_names, created_at, last_used_at, revoked_at FROM api_keys").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "prefix", "scopes", "coupon_names", "created_at", "last_used_at", "revoked_at"}).
+			AddRow(5, "checkout", "a1b2c3", "{coupons:claim}", "{}", now, now, nil).
+			AddRow(6, "crm", "d4e5f6", "{coupons:read}", "{}", now, nil, now))
+
+	keys, err := repo.ListAPIKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.NotNil(t, keys[0].LastUsedAt)
+	assert.NotNil(t, keys[1].RevokedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeAPIKey_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+
+	mock.ExpectExec("UPDATE api_keys SET revoked_at").
+		WithArgs(int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rowsAffected, err := repo.RevokeAPIKey(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rowsAffected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTouchAPIKey_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+
+	mock.ExpectExec("UPDATE api_keys SET last_used_at").
+		WithArgs(int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.TouchAPIKey(context.Background(), 5))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
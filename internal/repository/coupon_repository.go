@@ -1,12 +1,14 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/wazadio/coupon-system/internal/metrics"
 	"github.com/wazadio/coupon-system/internal/models"
 )
 
@@ -15,16 +17,53 @@ var (
 	ErrCouponAlreadyExists = errors.New("coupon already exists")
 	ErrAlreadyClaimed      = errors.New("user already claimed this coupon")
 	ErrNoStockAvailable    = errors.New("no stock available")
+	ErrCouponExpired       = errors.New("coupon has expired")
+	ErrUserDenied          = errors.New("user is denied from claiming coupons")
+	ErrUserNotAllowed      = errors.New("user is not on the coupon allowlist")
+	ErrAlreadyEntered      = errors.New("user already entered this lottery")
+	ErrEntriesClosed       = errors.New("lottery entries are closed")
+	ErrCodeNotFound        = errors.New("code not found")
+	ErrCodeNotClaimed      = errors.New("code has not been claimed")
+	ErrCodeAlreadyRedeemed = errors.New("code already redeemed")
+	ErrCodeExpired         = errors.New("code has expired")
 )
 
 // CouponRepository defines the interface for coupon data operations
 type CouponRepository interface {
-	CreateCoupon(name string, amount int) error
-	ClaimCoupon(userID, couponName string) error
-	GetCouponByName(name string) (*models.CouponDetailResponse, error)
-	Update(name string) (rowsAffected int64, err error)
+	CreateCoupon(ctx context.Context, coupon *models.Coupon) error
+	CreateCouponWithCodes(ctx context.Context, coupon *models.Coupon, codes []string) error
+	ClaimCoupon(ctx context.Context, userID, couponName string) (*models.Claim, error)
+	ClaimQueuedCoupon(ctx context.Context, ticketID int64, userID, couponName string) (*models.Claim, error)
+	EnterLottery(ctx context.Context, userID, couponName string) (*models.Claim, error)
+	GetCouponByName(ctx context.Context, name string) (*models.CouponDetailResponse, error)
+	GetClaimPolicy(ctx context.Context, couponName string) (*models.ClaimPolicy, error)
+	Update(ctx context.Context, name string) (rowsAffected int64, err error)
+	ExportCoupons(ctx context.Context, fn func(*models.Coupon) error) error
+	ExportClaims(ctx context.Context, couponName string, fn func(*models.Claim) error) error
+	ListCoupons(ctx context.Context, page models.Page) ([]models.Coupon, error)
+	GetCouponsByNames(ctx context.Context, names []string) ([]models.Coupon, error)
+	ListClaimsByCoupons(ctx context.Context, couponNames []string, page models.Page) ([]models.Claim, error)
+	ListClaimsByUsers(ctx context.Context, userIDs []string, page models.Page) ([]models.Claim, error)
+	GetCodeDetails(ctx context.Context, code string) (*models.CodeLookupResponse, error)
+	RedeemCode(ctx context.Context, code string) (*models.CodeRedemption, error)
 }
 
+// assignCodeQuery hands the lowest unassigned code of a coupon to a claim. Callers must hold
+// the coupon row lock.
+const assignCodeQuery = `
+	UPDATE coupon_codes
+	SET claim_id = $1,
+	    assigned_at = CURRENT_TIMESTAMP
+	WHERE id = (
+		SELECT id
+		FROM coupon_codes
+		WHERE coupon_name = $2 AND claim_id IS NULL
+		ORDER BY id ASC
+		LIMIT 1
+	)
+	RETURNING code
+`
+
 // couponRepository handles database operations for coupons
 type couponRepository struct {
 	db *sql.DB
@@ -38,13 +77,13 @@ func NewCouponRepository(db *sql.DB) CouponRepository {
 }
 
 // CreateCoupon creates a new coupon
-func (r *couponRepository) CreateCoupon(name string, amount int) error {
+func (r *couponRepository) CreateCoupon(ctx context.Context, coupon *models.Coupon) error {
 	query := `
-		INSERT INTO coupons (name, amount, remaining_amount)
-		VALUES ($1, $2, $2)
+		INSERT INTO coupons (name, amount, remaining_amount, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash)
+		VALUES ($1, $2, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err := r.db.Exec(query, name, amount)
+	_, err := execContext(ctx, r.db, "INSERT coupons", query, coupon.Name, coupon.Amount, coupon.ExpiresAt, coupon.EligibilityRules, coupon.AllowlistOnly, coupon.QueueMode, coupon.LotteryClosesAt, coupon.LotterySeedHash)
 	if err != nil {
 		// Check for unique constraint violation
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
@@ -56,52 +95,162 @@ func (r *couponRepository) CreateCoupon(name string, amount int) error {
 	return nil
 }
 
-// ClaimCoupon attempts to claim a coupon for a user with proper transaction handling
-func (r *couponRepository) ClaimCoupon(userID, couponName string) error {
-	// Start a transaction with default READ COMMITTED isolation level
-	tx, err := r.db.Begin()
+// CreateCouponWithCodes creates a coupon together with its pre-generated single-use codes
+func (r *couponRepository) CreateCouponWithCodes(ctx context.Context, coupon *models.Coupon, codes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %v", err)
 	}
 	defer tx.Rollback()
+	defer metrics.ObserveTransaction("create_coupon_with_codes", time.Now())
+
+	query := `
+		INSERT INTO coupons (name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash)
+		VALUES ($1, $2, $2, TRUE, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = execContext(ctx, tx, "INSERT coupons", query, coupon.Name, coupon.Amount, coupon.ExpiresAt, coupon.EligibilityRules, coupon.AllowlistOnly, coupon.QueueMode, coupon.LotteryClosesAt, coupon.LotterySeedHash)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrCouponAlreadyExists
+		}
+		return fmt.Errorf("error creating coupon: %v", err)
+	}
+
+	// Insert all codes in a single round trip
+	codesQuery := `
+		INSERT INTO coupon_codes (code, coupon_name)
+		SELECT unnest($1::text[]), $2
+	`
+	_, err = execContext(ctx, tx, "INSERT coupon_codes", codesQuery, pq.Array(codes), coupon.Name)
+	if err != nil {
+		return fmt.Errorf("error creating coupon codes: %v", err)
+	}
+
+	err = commitTx(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// ClaimCoupon attempts to claim a coupon for a user with proper transaction handling.
+// For coupons with unique codes, an unassigned code is handed to the user in the same transaction.
+func (r *couponRepository) ClaimCoupon(ctx context.Context, userID, couponName string) (*models.Claim, error) {
+	return r.claimCoupon(ctx, userID, couponName, nil)
+}
+
+// ClaimQueuedCoupon claims a coupon for the holder of a queue ticket being processed and
+// marks the ticket claimed in the same transaction, so a claim is never left without its
+// ticket recording it, whatever happens to the worker afterwards
+func (r *couponRepository) ClaimQueuedCoupon(ctx context.Context, ticketID int64, userID, couponName string) (*models.Claim, error) {
+	return r.claimCoupon(ctx, userID, couponName, func(tx *sql.Tx, claim *models.Claim) error {
+		query := `
+			UPDATE claim_queue
+			SET status = $2,
+			    code = $3,
+			    processed_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`
+		_, err := execContext(ctx, tx, "UPDATE claim_queue", query, ticketID, models.QueueStatusClaimed, claim.Code)
+		if err != nil {
+			return fmt.Errorf("error completing queue ticket: %v", err)
+		}
+		return nil
+	})
+}
+
+// claimCoupon claims a coupon for a user. onClaimed, when set, runs in the claim's
+// transaction once the claim is recorded and can fail it.
+func (r *couponRepository) claimCoupon(ctx context.Context, userID, couponName string, onClaimed func(*sql.Tx, *models.Claim) error) (*models.Claim, error) {
+	// Start a transaction with default READ COMMITTED isolation level
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+	defer metrics.ObserveTransaction("claim_coupon", time.Now())
 
 	// Lock the coupon row for update to prevent race conditions
-	// SELECT FOR UPDATE causes other transactions to wait (not fail)
-	var remainingAmount int
+	// SELECT FOR UPDATE causes other transactions to wait (not fail), so the span of this
+	// statement shows how long the claim waited for the lock
+	var (
+		remainingAmount int
+		uniqueCodes     bool
+		expiresAt       *time.Time
+		allowlistOnly   bool
+		denied          bool
+		allowed         bool
+	)
 	query := `
-		SELECT remaining_amount 
+		SELECT remaining_amount, unique_codes, expires_at, allowlist_only,
+		       EXISTS(SELECT 1 FROM user_denylist WHERE user_id = $2),
+		       EXISTS(SELECT 1 FROM coupon_allowlist WHERE coupon_name = $1 AND user_id = $2)
 		FROM coupons 
 		WHERE name = $1 
 		FOR UPDATE
 	`
-	err = tx.QueryRow(query, couponName).Scan(&remainingAmount)
+	err = queryRowContext(ctx, tx, "SELECT coupons FOR UPDATE", query, couponName, userID).Scan(
+		&remainingAmount,
+		&uniqueCodes,
+		&expiresAt,
+		&allowlistOnly,
+		&denied,
+		&allowed,
+	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return ErrCouponNotFound
+			return nil, ErrCouponNotFound
 		}
-		return fmt.Errorf("error checking coupon: %v", err)
+		return nil, fmt.Errorf("error checking coupon: %v", err)
 	}
 
-	time.Sleep(2 * time.Second)
+	if expiresAt != nil && !time.Now().Before(*expiresAt) {
+		return nil, ErrCouponExpired
+	}
+
+	// Check the global denylist and, for private coupons, the coupon allowlist
+	if denied {
+		return nil, ErrUserDenied
+	}
+	if allowlistOnly && !allowed {
+		return nil, ErrUserNotAllowed
+	}
 
 	// Check if stock is available
 	if remainingAmount <= 0 {
-		return ErrNoStockAvailable
+		return nil, ErrNoStockAvailable
 	}
 
 	// Try to insert claim record
 	// This will fail if the user already claimed this coupon (unique constraint)
+	claim := &models.Claim{
+		UserID:     userID,
+		CouponName: couponName,
+	}
 	insertQuery := `
 		INSERT INTO claims (user_id, coupon_name)
 		VALUES ($1, $2)
+		RETURNING id, claimed_at
 	`
-	_, err = tx.Exec(insertQuery, userID, couponName)
+	err = queryRowContext(ctx, tx, "INSERT claims", insertQuery, userID, couponName).Scan(&claim.ID, &claim.ClaimedAt)
 	if err != nil {
 		// Check for unique constraint violation
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-			return ErrAlreadyClaimed
+			return nil, ErrAlreadyClaimed
+		}
+		return nil, fmt.Errorf("error creating claim: %v", err)
+	}
+
+	if uniqueCodes {
+		// The coupon row lock serializes claims, so the lowest unassigned code is free to take
+		err = queryRowContext(ctx, tx, "UPDATE coupon_codes", assignCodeQuery, claim.ID, couponName).Scan(&claim.Code)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrNoStockAvailable
+			}
+			return nil, fmt.Errorf("error assigning coupon code: %v", err)
 		}
-		return fmt.Errorf("error creating claim: %v", err)
 	}
 
 	// Decrement the coupon stock
@@ -111,34 +260,102 @@ func (r *couponRepository) ClaimCoupon(userID, couponName string) error {
 		    updated_at = CURRENT_TIMESTAMP
 		WHERE name = $1
 	`
-	_, err = tx.Exec(updateQuery, couponName)
+	_, err = execContext(ctx, tx, "UPDATE coupons", updateQuery, couponName)
 	if err != nil {
-		return fmt.Errorf("error updating coupon stock: %v", err)
+		return nil, fmt.Errorf("error updating coupon stock: %v", err)
+	}
+
+	if onClaimed != nil {
+		if err := onClaimed(tx, claim); err != nil {
+			return nil, err
+		}
 	}
 
 	// Commit the transaction
-	err = tx.Commit()
+	err = commitTx(ctx, tx)
 	if err != nil {
-		return fmt.Errorf("error committing transaction: %v", err)
+		return nil, fmt.Errorf("error committing transaction: %v", err)
 	}
 
-	return nil
+	return claim, nil
+}
+
+// EnterLottery records a user's entry into a lottery coupon while its entry window is open.
+// The denylist and allowlist apply to entries as they do to claims. No stock is taken until the draw.
+func (r *couponRepository) EnterLottery(ctx context.Context, userID, couponName string) (*models.Claim, error) {
+	var (
+		closesAt      *time.Time
+		allowlistOnly bool
+		denied        bool
+		allowed       bool
+	)
+	query := `
+		SELECT lottery_closes_at, allowlist_only,
+		       EXISTS(SELECT 1 FROM user_denylist WHERE user_id = $2),
+		       EXISTS(SELECT 1 FROM coupon_allowlist WHERE coupon_name = $1 AND user_id = $2)
+		FROM coupons
+		WHERE name = $1
+	`
+	err := queryRowContext(ctx, r.db, "SELECT coupons", query, couponName, userID).Scan(&closesAt, &allowlistOnly, &denied, &allowed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCouponNotFound
+		}
+		return nil, fmt.Errorf("error checking coupon: %v", err)
+	}
+
+	if closesAt == nil || !time.Now().Before(*closesAt) {
+		return nil, ErrEntriesClosed
+	}
+	if denied {
+		return nil, ErrUserDenied
+	}
+	if allowlistOnly && !allowed {
+		return nil, ErrUserNotAllowed
+	}
+
+	entry := &models.Claim{
+		UserID:     userID,
+		CouponName: couponName,
+		Entry:      true,
+	}
+	insertQuery := `
+		INSERT INTO lottery_entries (coupon_name, user_id)
+		VALUES ($1, $2)
+		RETURNING id, entered_at
+	`
+	err = queryRowContext(ctx, r.db, "INSERT lottery_entries", insertQuery, couponName, userID).Scan(&entry.ID, &entry.ClaimedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, ErrAlreadyEntered
+		}
+		return nil, fmt.Errorf("error creating lottery entry: %v", err)
+	}
+
+	return entry, nil
 }
 
 // GetCouponByName retrieves a coupon by name with all users who claimed it
-func (r *couponRepository) GetCouponByName(name string) (*models.CouponDetailResponse, error) {
+func (r *couponRepository) GetCouponByName(ctx context.Context, name string) (*models.CouponDetailResponse, error) {
 	// Get coupon details
 	var coupon models.Coupon
 	query := `
-		SELECT id, name, amount, remaining_amount, created_at, updated_at
+		SELECT id, name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash, created_at, updated_at
 		FROM coupons
 		WHERE name = $1
 	`
-	err := r.db.QueryRow(query, name).Scan(
+	err := queryRowContext(ctx, r.db, "SELECT coupons", query, name).Scan(
 		&coupon.ID,
 		&coupon.Name,
 		&coupon.Amount,
 		&coupon.RemainingAmount,
+		&coupon.UniqueCodes,
+		&coupon.ExpiresAt,
+		&coupon.EligibilityRules,
+		&coupon.AllowlistOnly,
+		&coupon.QueueMode,
+		&coupon.LotteryClosesAt,
+		&coupon.LotterySeedHash,
 		&coupon.CreatedAt,
 		&coupon.UpdatedAt,
 	)
@@ -156,7 +373,7 @@ func (r *couponRepository) GetCouponByName(name string) (*models.CouponDetailRes
 		WHERE coupon_name = $1
 		ORDER BY claimed_at ASC
 	`
-	rows, err := r.db.Query(claimsQuery, name)
+	rows, err := queryContext(ctx, r.db, "SELECT claims", claimsQuery, name)
 	if err != nil {
 		return nil, fmt.Errorf("error getting claims: %v", err)
 	}
@@ -176,23 +393,50 @@ func (r *couponRepository) GetCouponByName(name string) (*models.CouponDetailRes
 	}
 
 	response := &models.CouponDetailResponse{
-		Name:            coupon.Name,
-		Amount:          coupon.Amount,
-		RemainingAmount: coupon.RemainingAmount,
-		ClaimedBy:       claimedBy,
+		Name:             coupon.Name,
+		Amount:           coupon.Amount,
+		RemainingAmount:  coupon.RemainingAmount,
+		UniqueCodes:      coupon.UniqueCodes,
+		ExpiresAt:        coupon.ExpiresAt,
+		EligibilityRules: coupon.EligibilityRules,
+		AllowlistOnly:    coupon.AllowlistOnly,
+		QueueMode:        coupon.QueueMode,
+		LotteryClosesAt:  coupon.LotteryClosesAt,
+		LotterySeedHash:  coupon.LotterySeedHash,
+		ClaimedBy:        claimedBy,
 	}
 
 	return response, nil
 }
 
-func (r *couponRepository) Update(name string) (rowsAffected int64, err error) {
+// GetClaimPolicy returns the eligibility rules, which may be empty, queue mode and lottery
+// entry deadline of a coupon
+func (r *couponRepository) GetClaimPolicy(ctx context.Context, couponName string) (*models.ClaimPolicy, error) {
+	var policy models.ClaimPolicy
+	query := `
+		SELECT eligibility_rules, queue_mode, lottery_closes_at
+		FROM coupons
+		WHERE name = $1
+	`
+	err := queryRowContext(ctx, r.db, "SELECT coupons", query, couponName).Scan(&policy.EligibilityRules, &policy.QueueMode, &policy.LotteryClosesAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCouponNotFound
+		}
+		return nil, fmt.Errorf("error getting claim policy: %v", err)
+	}
+
+	return &policy, nil
+}
+
+func (r *couponRepository) Update(ctx context.Context, name string) (rowsAffected int64, err error) {
 	updateQuery := `
 		UPDATE coupons 
 		SET updated_at = NOW()
 		WHERE name = $1;
 	`
 
-	result, err := r.db.Exec(updateQuery, name)
+	result, err := execContext(ctx, r.db, "UPDATE coupons", updateQuery, name)
 	if err != nil {
 		return
 	}
@@ -204,3 +448,326 @@ func (r *couponRepository) Update(name string) (rowsAffected int64, err error) {
 
 	return
 }
+
+// ExportCoupons streams every coupon ordered by id to fn, stopping at the first error
+func (r *couponRepository) ExportCoupons(ctx context.Context, fn func(*models.Coupon) error) error {
+	query := `
+		SELECT id, name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash, created_at, updated_at
+		FROM coupons
+		ORDER BY id ASC
+	`
+	rows, err := queryContext(ctx, r.db, "SELECT coupons", query)
+	if err != nil {
+		return fmt.Errorf("error exporting coupons: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var coupon models.Coupon
+		if err := rows.Scan(
+			&coupon.ID,
+			&coupon.Name,
+			&coupon.Amount,
+			&coupon.RemainingAmount,
+			&coupon.UniqueCodes,
+			&coupon.ExpiresAt,
+			&coupon.EligibilityRules,
+			&coupon.AllowlistOnly,
+			&coupon.QueueMode,
+			&coupon.LotteryClosesAt,
+			&coupon.LotterySeedHash,
+			&coupon.CreatedAt,
+			&coupon.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("error scanning coupon: %v", err)
+		}
+		if err := fn(&coupon); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating coupons: %v", err)
+	}
+
+	return nil
+}
+
+// ExportClaims streams every claim of a coupon ordered by claim time to fn.
+// ErrCouponNotFound is returned before fn is called if the coupon does not exist.
+func (r *couponRepository) ExportClaims(ctx context.Context, couponName string, fn func(*models.Claim) error) error {
+	var exists bool
+	err := queryRowContext(ctx, r.db, "SELECT coupons", `SELECT EXISTS(SELECT 1 FROM coupons WHERE name = $1)`, couponName).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("error checking coupon: %v", err)
+	}
+	if !exists {
+		return ErrCouponNotFound
+	}
+
+	query := `
+		SELECT cl.id, cl.user_id, cl.coupon_name, COALESCE(cc.code, ''), cl.claimed_at
+		FROM claims cl
+		LEFT JOIN coupon_codes cc ON cc.claim_id = cl.id
+		WHERE cl.coupon_name = $1
+		ORDER BY cl.claimed_at ASC, cl.id ASC
+	`
+	rows, err := queryContext(ctx, r.db, "SELECT claims", query, couponName)
+	if err != nil {
+		return fmt.Errorf("error exporting claims: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var claim models.Claim
+		if err := rows.Scan(&claim.ID, &claim.UserID, &claim.CouponName, &claim.Code, &claim.ClaimedAt); err != nil {
+			return fmt.Errorf("error scanning claim: %v", err)
+		}
+		if err := fn(&claim); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating claims: %v", err)
+	}
+
+	return nil
+}
+
+// couponColumns are the columns queryCoupons scans, in order
+const couponColumns = `id, name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash, created_at, updated_at`
+
+// ListCoupons returns a page of coupons ordered by id
+func (r *couponRepository) ListCoupons(ctx context.Context, page models.Page) ([]models.Coupon, error) {
+	query := `
+		SELECT ` + couponColumns + `
+		FROM coupons
+		WHERE id > $1
+		ORDER BY id ASC
+		LIMIT $2
+	`
+	return r.queryCoupons(ctx, "SELECT coupons", query, page.AfterID, page.Limit)
+}
+
+// GetCouponsByNames returns the coupons among names that exist, in one query
+func (r *couponRepository) GetCouponsByNames(ctx context.Context, names []string) ([]models.Coupon, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT ` + couponColumns + `
+		FROM coupons
+		WHERE name = ANY($1)
+		ORDER BY id ASC
+	`
+	return r.queryCoupons(ctx, "SELECT coupons", query, pq.Array(names))
+}
+
+func (r *couponRepository) queryCoupons(ctx context.Context, name, query string, args ...interface{}) ([]models.Coupon, error) {
+	rows, err := queryContext(ctx, r.db, name, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing coupons: %v", err)
+	}
+	defer rows.Close()
+
+	coupons := []models.Coupon{}
+	for rows.Next() {
+		var coupon models.Coupon
+		if err := rows.Scan(
+			&coupon.ID,
+			&coupon.Name,
+			&coupon.Amount,
+			&coupon.RemainingAmount,
+			&coupon.UniqueCodes,
+			&coupon.ExpiresAt,
+			&coupon.EligibilityRules,
+			&coupon.AllowlistOnly,
+			&coupon.QueueMode,
+			&coupon.LotteryClosesAt,
+			&coupon.LotterySeedHash,
+			&coupon.CreatedAt,
+			&coupon.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning coupon: %v", err)
+		}
+		coupons = append(coupons, coupon)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating coupons: %v", err)
+	}
+
+	return coupons, nil
+}
+
+// ListClaimsByCoupons returns a page of claims for each of couponNames, ordered by coupon
+// name then claim id, in one query however many coupons are asked for
+func (r *couponRepository) ListClaimsByCoupons(ctx context.Context, couponNames []string, page models.Page) ([]models.Claim, error) {
+	return r.listClaimsBy(ctx, "coupon_name", couponNames, page)
+}
+
+// ListClaimsByUsers returns a page of claims for each of userIDs, ordered by user ID then
+// claim id, in one query however many users are asked for
+func (r *couponRepository) ListClaimsByUsers(ctx context.Context, userIDs []string, page models.Page) ([]models.Claim, error) {
+	return r.listClaimsBy(ctx, "user_id", userIDs, page)
+}
+
+// listClaimsBy pages the claims of each key of column separately, numbering every key's
+// claims after the cursor and keeping the first page.Limit. column is never user input.
+func (r *couponRepository) listClaimsBy(ctx context.Context, column string, keys []string, page models.Page) ([]models.Claim, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, user_id, coupon_name, code, claimed_at
+		FROM (
+			SELECT cl.id, cl.user_id, cl.coupon_name, COALESCE(cc.code, '') AS code, cl.claimed_at,
+			       ROW_NUMBER() OVER (PARTITION BY cl.%[1]s ORDER BY cl.id) AS position
+			FROM claims cl
+			LEFT JOIN coupon_codes cc ON cc.claim_id = cl.id
+			WHERE cl.%[1]s = ANY($1) AND cl.id > $2
+		) claim_page
+		WHERE position <= $3
+		ORDER BY %[1]s ASC, id ASC
+	`, column)
+
+	rows, err := queryContext(ctx, r.db, "SELECT claims", query, pq.Array(keys), page.AfterID, page.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing claims: %v", err)
+	}
+	defer rows.Close()
+
+	claims := []models.Claim{}
+	for rows.Next() {
+		var claim models.Claim
+		if err := rows.Scan(&claim.ID, &claim.UserID, &claim.CouponName, &claim.Code, &claim.ClaimedAt); err != nil {
+			return nil, fmt.Errorf("error scanning claim: %v", err)
+		}
+		claims = append(claims, claim)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating claims: %v", err)
+	}
+
+	return claims, nil
+}
+
+// GetCodeDetails resolves a generated code to its coupon and, if it has been assigned, its claim
+func (r *couponRepository) GetCodeDetails(ctx context.Context, code string) (*models.CodeLookupResponse, error) {
+	query := `
+		SELECT cc.code, cc.redeemed_at,
+		       c.id, c.name, c.amount, c.remaining_amount, c.unique_codes, c.expires_at, c.created_at, c.updated_at,
+		       cl.id, cl.user_id, cl.claimed_at
+		FROM coupon_codes cc
+		JOIN coupons c ON c.name = cc.coupon_name
+		LEFT JOIN claims cl ON cl.id = cc.claim_id
+		WHERE cc.code = $1
+	`
+
+	var (
+		response  models.CodeLookupResponse
+		claimID   sql.NullInt64
+		userID    sql.NullString
+		claimedAt sql.NullTime
+	)
+	err := queryRowContext(ctx, r.db, "SELECT coupon_codes", query, code).Scan(
+		&response.Code,
+		&response.RedeemedAt,
+		&response.Coupon.ID,
+		&response.Coupon.Name,
+		&response.Coupon.Amount,
+		&response.Coupon.RemainingAmount,
+		&response.Coupon.UniqueCodes,
+		&response.Coupon.ExpiresAt,
+		&response.Coupon.CreatedAt,
+		&response.Coupon.UpdatedAt,
+		&claimID,
+		&userID,
+		&claimedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCodeNotFound
+		}
+		return nil, fmt.Errorf("error getting code: %v", err)
+	}
+
+	if claimID.Valid {
+		response.Claim = &models.Claim{
+			ID:         int(claimID.Int64),
+			UserID:     userID.String,
+			CouponName: response.Coupon.Name,
+			Code:       response.Code,
+			ClaimedAt:  claimedAt.Time,
+		}
+	}
+
+	return &response, nil
+}
+
+// RedeemCode marks an assigned code as redeemed. The code row is locked so that
+// concurrent redemptions of the same code cannot both succeed.
+func (r *couponRepository) RedeemCode(ctx context.Context, code string) (*models.CodeRedemption, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+	defer metrics.ObserveTransaction("redeem_code", time.Now())
+
+	var (
+		codeID     int64
+		userID     sql.NullString
+		redeemedAt *time.Time
+		expiresAt  *time.Time
+		redemption = &models.CodeRedemption{Code: code}
+	)
+	query := `
+		SELECT cc.id, cc.coupon_name, cl.user_id, cc.redeemed_at, c.expires_at
+		FROM coupon_codes cc
+		JOIN coupons c ON c.name = cc.coupon_name
+		LEFT JOIN claims cl ON cl.id = cc.claim_id
+		WHERE cc.code = $1
+		FOR UPDATE OF cc
+	`
+	err = queryRowContext(ctx, tx, "SELECT coupon_codes FOR UPDATE", query, code).Scan(&codeID, &redemption.CouponName, &userID, &redeemedAt, &expiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCodeNotFound
+		}
+		return nil, fmt.Errorf("error checking code: %v", err)
+	}
+
+	if redeemedAt != nil {
+		return nil, ErrCodeAlreadyRedeemed
+	}
+	if expiresAt != nil && !time.Now().Before(*expiresAt) {
+		return nil, ErrCodeExpired
+	}
+	if !userID.Valid {
+		return nil, ErrCodeNotClaimed
+	}
+	redemption.UserID = userID.String
+
+	updateQuery := `
+		UPDATE coupon_codes
+		SET redeemed_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING redeemed_at
+	`
+	err = queryRowContext(ctx, tx, "UPDATE coupon_codes", updateQuery, codeID).Scan(&redemption.RedeemedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error redeeming code: %v", err)
+	}
+
+	err = commitTx(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return redemption, nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"testing"
@@ -9,8 +10,12 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/models"
 )
 
+// claimLockColumns are the columns read when ClaimCoupon locks the coupon row
+var claimLockColumns = []string{"remaining_amount", "unique_codes", "expires_at", "allowlist_only", "denied", "allowed"}
+
 func TestCreateCoupon_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -19,10 +24,10 @@ func TestCreateCoupon_Success(t *testing.T) {
 	repo := NewCouponRepository(db)
 
 	mock.ExpectExec("INSERT INTO coupons").
-		WithArgs("FLASH25", 100).
+		WithArgs("FLASH25", 100, nil, nil, false, false, nil, "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err = repo.CreateCoupon("FLASH25", 100)
+	err = repo.CreateCoupon(context.Background(), &models.Coupon{Name: "FLASH25", Amount: 100})
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -36,10 +41,10 @@ func TestCreateCoupon_DuplicateCoupon(t *testing.T) {
 
 	pqErr := &pq.Error{Code: "23505"}
 	mock.ExpectExec("INSERT INTO coupons").
-		WithArgs("FLASH25", 100).
+		WithArgs("FLASH25", 100, nil, nil, false, false, nil, "").
 		WillReturnError(pqErr)
 
-	err = repo.CreateCoupon("FLASH25", 100)
+	err = repo.CreateCoupon(context.Background(), &models.Coupon{Name: "FLASH25", Amount: 100})
 	assert.Equal(t, ErrCouponAlreadyExists, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -52,10 +57,10 @@ func TestCreateCoupon_DatabaseError(t *testing.T) {
 	repo := NewCouponRepository(db)
 
 	mock.ExpectExec("INSERT INTO coupons").
-		WithArgs("FLASH25", 100).
+		WithArgs("FLASH25", 100, nil, nil, false, false, nil, "").
 		WillReturnError(errors.New("database connection lost"))
 
-	err = repo.CreateCoupon("FLASH25", 100)
+	err = repo.CreateCoupon(context.Background(), &models.Coupon{Name: "FLASH25", Amount: 100})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error creating coupon")
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -69,23 +74,73 @@ func TestClaimCoupon_Success(t *testing.T) {
 	repo := &couponRepository{db: db}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT remaining_amount FROM coupons WHERE name").
-		WithArgs("FLASH25").
-		WillReturnRows(sqlmock.NewRows([]string{"remaining_amount"}).AddRow(10))
-	mock.ExpectExec("INSERT INTO claims").
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, false, nil, false, false, false))
+	mock.ExpectQuery("INSERT INTO claims").
 		WithArgs("user1", "FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "claimed_at"}).AddRow(1, time.Now()))
+	mock.ExpectExec("UPDATE coupons SET remaining_amount").
+		WithArgs("FLASH25").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimQueuedCoupon_CompletesTicket(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := &couponRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, false, nil, false, false, false))
+	mock.ExpectQuery("INSERT INTO claims").
+		WithArgs("user1", "FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "claimed_at"}).AddRow(1, time.Now()))
 	mock.ExpectExec("UPDATE coupons SET remaining_amount").
 		WithArgs("FLASH25").
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE claim_queue SET status = \\$2, code = \\$3").
+		WithArgs(int64(7), models.QueueStatusClaimed, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
-	// Note: The actual implementation has time.Sleep(2 * time.Second) which we can't mock
-	// For testing purposes, you may want to refactor the repository to inject a sleep function
-	// For now, this test will take 2 seconds due to the sleep in the actual code
+	_, err = repo.ClaimQueuedCoupon(context.Background(), 7, "user1", "FLASH25")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
 
-	err = repo.ClaimCoupon("user1", "FLASH25")
+func TestClaimQueuedCoupon_TicketError(t *testing.T) {
+	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := &couponRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, false, nil, false, false, false))
+	mock.ExpectQuery("INSERT INTO claims").
+		WithArgs("user1", "FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "claimed_at"}).AddRow(1, time.Now()))
+	mock.ExpectExec("UPDATE coupons SET remaining_amount").
+		WithArgs("FLASH25").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE claim_queue").
+		WillReturnError(errors.New("connection reset"))
+	// The claim is rolled back with the ticket, so the ticket can be retried
+	mock.ExpectRollback()
+
+	_, err = repo.ClaimQueuedCoupon(context.Background(), 7, "user1", "FLASH25")
+	assert.ErrorContains(t, err, "error completing queue ticket")
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -97,12 +152,12 @@ func TestClaimCoupon_CouponNotFound(t *testing.T) {
 	repo := &couponRepository{db: db}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT remaining_amount FROM coupons WHERE name").
-		WithArgs("NONEXISTENT").
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("NONEXISTENT", "user1").
 		WillReturnError(sql.ErrNoRows)
 	mock.ExpectRollback()
 
-	err = repo.ClaimCoupon("user1", "NONEXISTENT")
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "NONEXISTENT")
 	assert.Equal(t, ErrCouponNotFound, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -115,12 +170,12 @@ func TestClaimCoupon_NoStockAvailable(t *testing.T) {
 	repo := &couponRepository{db: db}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT remaining_amount FROM coupons WHERE name").
-		WithArgs("FLASH25").
-		WillReturnRows(sqlmock.NewRows([]string{"remaining_amount"}).AddRow(0))
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(0, false, nil, false, false, false))
 	mock.ExpectRollback()
 
-	err = repo.ClaimCoupon("user1", "FLASH25")
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
 	assert.Equal(t, ErrNoStockAvailable, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -135,15 +190,15 @@ func TestClaimCoupon_AlreadyClaimed(t *testing.T) {
 	pqErr := &pq.Error{Code: "23505"}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT remaining_amount FROM coupons WHERE name").
-		WithArgs("FLASH25").
-		WillReturnRows(sqlmock.NewRows([]string{"remaining_amount"}).AddRow(10))
-	mock.ExpectExec("INSERT INTO claims").
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, false, nil, false, false, false))
+	mock.ExpectQuery("INSERT INTO claims").
 		WithArgs("user1", "FLASH25").
 		WillReturnError(pqErr)
 	mock.ExpectRollback()
 
-	err = repo.ClaimCoupon("user1", "FLASH25")
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
 	assert.Equal(t, ErrAlreadyClaimed, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -157,7 +212,7 @@ func TestClaimCoupon_TransactionBeginError(t *testing.T) {
 
 	mock.ExpectBegin().WillReturnError(errors.New("connection pool exhausted"))
 
-	err = repo.ClaimCoupon("user1", "FLASH25")
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error starting transaction")
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -171,12 +226,12 @@ func TestClaimCoupon_SelectError(t *testing.T) {
 	repo := &couponRepository{db: db}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT remaining_amount FROM coupons WHERE name").
-		WithArgs("FLASH25").
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
 		WillReturnError(errors.New("connection timeout"))
 	mock.ExpectRollback()
 
-	err = repo.ClaimCoupon("user1", "FLASH25")
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error checking coupon")
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -190,15 +245,15 @@ func TestClaimCoupon_InsertClaimError(t *testing.T) {
 	repo := &couponRepository{db: db}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT remaining_amount FROM coupons WHERE name").
-		WithArgs("FLASH25").
-		WillReturnRows(sqlmock.NewRows([]string{"remaining_amount"}).AddRow(10))
-	mock.ExpectExec("INSERT INTO claims").
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, false, nil, false, false, false))
+	mock.ExpectQuery("INSERT INTO claims").
 		WithArgs("user1", "FLASH25").
 		WillReturnError(errors.New("insert failed"))
 	mock.ExpectRollback()
 
-	err = repo.ClaimCoupon("user1", "FLASH25")
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error creating claim")
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -212,18 +267,18 @@ func TestClaimCoupon_UpdateCouponError(t *testing.T) {
 	repo := &couponRepository{db: db}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT remaining_amount FROM coupons WHERE name").
-		WithArgs("FLASH25").
-		WillReturnRows(sqlmock.NewRows([]string{"remaining_amount"}).AddRow(10))
-	mock.ExpectExec("INSERT INTO claims").
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, false, nil, false, false, false))
+	mock.ExpectQuery("INSERT INTO claims").
 		WithArgs("user1", "FLASH25").
-		WillReturnResult(sqlmock.NewResult(1, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "claimed_at"}).AddRow(1, time.Now()))
 	mock.ExpectExec("UPDATE coupons SET remaining_amount").
 		WithArgs("FLASH25").
 		WillReturnError(errors.New("update failed"))
 	mock.ExpectRollback()
 
-	err = repo.ClaimCoupon("user1", "FLASH25")
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error updating coupon stock")
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -237,18 +292,18 @@ func TestClaimCoupon_CommitError(t *testing.T) {
 	repo := &couponRepository{db: db}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT remaining_amount FROM coupons WHERE name").
-		WithArgs("FLASH25").
-		WillReturnRows(sqlmock.NewRows([]string{"remaining_amount"}).AddRow(10))
-	mock.ExpectExec("INSERT INTO claims").
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, false, nil, false, false, false))
+	mock.ExpectQuery("INSERT INTO claims").
 		WithArgs("user1", "FLASH25").
-		WillReturnResult(sqlmock.NewResult(1, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "claimed_at"}).AddRow(1, time.Now()))
 	mock.ExpectExec("UPDATE coupons SET remaining_amount").
 		WithArgs("FLASH25").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit().WillReturnError(errors.New("commit failed"))
 
-	err = repo.ClaimCoupon("user1", "FLASH25")
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error committing transaction")
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -262,14 +317,14 @@ func TestGetCouponByName_Success(t *testing.T) {
 	repo := NewCouponRepository(db)
 
 	now := time.Now()
-	couponRows := sqlmock.NewRows([]string{"id", "name", "amount", "remaining_amount", "created_at", "updated_at"}).
-		AddRow(1, "FLASH25", 100, 75, now, now)
+	couponRows := sqlmock.NewRows([]string{"id", "name", "amount", "remaining_amount", "unique_codes", "expires_at", "eligibility_rules", "allowlist_only", "queue_mode", "lottery_closes_at", "lottery_seed_hash", "created_at", "updated_at"}).
+		AddRow(1, "FLASH25", 100, 75, false, nil, nil, false, false, nil, "", now, now)
 
 	claimRows := sqlmock.NewRows([]string{"user_id"}).
 		AddRow("user1").
 		AddRow("user2")
 
-	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, created_at, updated_at FROM coupons WHERE name").
+	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash, created_at, updated_at FROM coupons WHERE name").
 		WithArgs("FLASH25").
 		WillReturnRows(couponRows)
 
@@ -277,7 +332,7 @@ func TestGetCouponByName_Success(t *testing.T) {
 		WithArgs("FLASH25").
 		WillReturnRows(claimRows)
 
-	result, err := repo.GetCouponByName("FLASH25")
+	result, err := repo.GetCouponByName(context.Background(), "FLASH25")
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, "FLASH25", result.Name)
@@ -295,12 +350,12 @@ func TestGetCouponByName_SuccessNoClaims(t *testing.T) {
 	repo := NewCouponRepository(db)
 
 	now := time.Now()
-	couponRows := sqlmock.NewRows([]string{"id", "name", "amount", "remaining_amount", "created_at", "updated_at"}).
-		AddRow(1, "FLASH25", 100, 100, now, now)
+	couponRows := sqlmock.NewRows([]string{"id", "name", "amount", "remaining_amount", "unique_codes", "expires_at", "eligibility_rules", "allowlist_only", "queue_mode", "lottery_closes_at", "lottery_seed_hash", "created_at", "updated_at"}).
+		AddRow(1, "FLASH25", 100, 100, false, nil, nil, false, false, nil, "", now, now)
 
 	claimRows := sqlmock.NewRows([]string{"user_id"})
 
-	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, created_at, updated_at FROM coupons WHERE name").
+	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash, created_at, updated_at FROM coupons WHERE name").
 		WithArgs("FLASH25").
 		WillReturnRows(couponRows)
 
@@ -308,7 +363,7 @@ func TestGetCouponByName_SuccessNoClaims(t *testing.T) {
 		WithArgs("FLASH25").
 		WillReturnRows(claimRows)
 
-	result, err := repo.GetCouponByName("FLASH25")
+	result, err := repo.GetCouponByName(context.Background(), "FLASH25")
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, "FLASH25", result.Name)
@@ -323,11 +378,11 @@ func TestGetCouponByName_CouponNotFound(t *testing.T) {
 
 	repo := NewCouponRepository(db)
 
-	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, created_at, updated_at FROM coupons WHERE name").
+	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash, created_at, updated_at FROM coupons WHERE name").
 		WithArgs("NONEXISTENT").
 		WillReturnError(sql.ErrNoRows)
 
-	result, err := repo.GetCouponByName("NONEXISTENT")
+	result, err := repo.GetCouponByName(context.Background(), "NONEXISTENT")
 	assert.Nil(t, result)
 	assert.Equal(t, ErrCouponNotFound, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -340,11 +395,11 @@ func TestGetCouponByName_QueryError(t *testing.T) {
 
 	repo := NewCouponRepository(db)
 
-	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, created_at, updated_at FROM coupons WHERE name").
+	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash, created_at, updated_at FROM coupons WHERE name").
 		WithArgs("FLASH25").
 		WillReturnError(errors.New("connection timeout"))
 
-	result, err := repo.GetCouponByName("FLASH25")
+	result, err := repo.GetCouponByName(context.Background(), "FLASH25")
 	assert.Nil(t, result)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error getting coupon")
@@ -359,10 +414,10 @@ func TestGetCouponByName_ClaimsQueryError(t *testing.T) {
 	repo := NewCouponRepository(db)
 
 	now := time.Now()
-	couponRows := sqlmock.NewRows([]string{"id", "name", "amount", "remaining_amount", "created_at", "updated_at"}).
-		AddRow(1, "FLASH25", 100, 75, now, now)
+	couponRows := sqlmock.NewRows([]string{"id", "name", "amount", "remaining_amount", "unique_codes", "expires_at", "eligibility_rules", "allowlist_only", "queue_mode", "lottery_closes_at", "lottery_seed_hash", "created_at", "updated_at"}).
+		AddRow(1, "FLASH25", 100, 75, false, nil, nil, false, false, nil, "", now, now)
 
-	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, created_at, updated_at FROM coupons WHERE name").
+	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash, created_at, updated_at FROM coupons WHERE name").
 		WithArgs("FLASH25").
 		WillReturnRows(couponRows)
 
@@ -370,7 +425,7 @@ func TestGetCouponByName_ClaimsQueryError(t *testing.T) {
 		WithArgs("FLASH25").
 		WillReturnError(errors.New("connection timeout"))
 
-	result, err := repo.GetCouponByName("FLASH25")
+	result, err := repo.GetCouponByName(context.Background(), "FLASH25")
 	assert.Nil(t, result)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error getting claims")
@@ -388,7 +443,7 @@ func TestUpdate_Success(t *testing.T) {
 		WithArgs("FLASH25").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	rowsAffected, err := repo.Update("FLASH25")
+	rowsAffected, err := repo.Update(context.Background(), "FLASH25")
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), rowsAffected)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -405,7 +460,7 @@ func TestUpdate_NoRowsAffected(t *testing.T) {
 		WithArgs("NONEXISTENT").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
-	rowsAffected, err := repo.Update("NONEXISTENT")
+	rowsAffected, err := repo.Update(context.Background(), "NONEXISTENT")
 	assert.NoError(t, err)
 	assert.Equal(t, int64(0), rowsAffected)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -422,9 +477,628 @@ func TestUpdate_DatabaseError(t *testing.T) {
 		WithArgs("FLASH25").
 		WillReturnError(errors.New("database error"))
 
-	rowsAffected, err := repo.Update("FLASH25")
+	rowsAffected, err := repo.Update(context.Background(), "FLASH25")
 	assert.Error(t, err)
 	assert.Equal(t, int64(0), rowsAffected)
 	assert.Contains(t, err.Error(), "database error")
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestExportCoupons_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "name", "amount", "remaining_amount", "unique_codes", "expires_at", "eligibility_rules", "allowlist_only", "queue_mode", "lottery_closes_at", "lottery_seed_hash", "created_at", "updated_at"}).
+		AddRow(1, "FLASH25", 100, 75, false, nil, nil, false, false, nil, "", now, now).
+		AddRow(2, "PROMO", 10, 10, false, nil, nil, false, false, nil, "", now, now)
+	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash, created_at, updated_at FROM coupons ORDER BY id").
+		WillReturnRows(rows)
+
+	var names []string
+	err = repo.ExportCoupons(context.Background(), func(c *models.Coupon) error {
+		names = append(names, c.Name)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"FLASH25", "PROMO"}, names)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportCoupons_CallbackError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "name", "amount", "remaining_amount", "unique_codes", "expires_at", "eligibility_rules", "allowlist_only", "queue_mode", "lottery_closes_at", "lottery_seed_hash", "created_at", "updated_at"}).
+		AddRow(1, "FLASH25", 100, 75, false, nil, nil, false, false, nil, "", now, now).
+		AddRow(2, "PROMO", 10, 10, false, nil, nil, false, false, nil, "", now, now)
+	mock.ExpectQuery("SELECT id, name, amount, remaining_amount, unique_codes, expires_at, eligibility_rules, allowlist_only, queue_mode, lottery_closes_at, lottery_seed_hash, created_at, updated_at FROM coupons").
+		WillReturnRows(rows)
+
+	writeErr := errors.New("client went away")
+	calls := 0
+	err = repo.ExportCoupons(context.Background(), func(c *models.Coupon) error {
+		calls++
+		return writeErr
+	})
+	assert.Equal(t, writeErr, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestExportClaims_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT cl.id, cl.user_id, cl.coupon_name, COALESCE\\(cc.code, ''\\), cl.claimed_at FROM claims cl").
+		WithArgs("FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "coupon_name", "code", "claimed_at"}).
+			AddRow(1, "user1", "FLASH25", "", now).
+			AddRow(2, "user2", "FLASH25", "", now))
+
+	var users []string
+	err = repo.ExportClaims(context.Background(), "FLASH25", func(c *models.Claim) error {
+		users = append(users, c.UserID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user1", "user2"}, users)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportClaims_CouponNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("NONEXISTENT").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	err = repo.ExportClaims(context.Background(), "NONEXISTENT", func(c *models.Claim) error {
+		t.Fatal("callback must not be called")
+		return nil
+	})
+	assert.Equal(t, ErrCouponNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListCoupons_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "name", "amount", "remaining_amount", "unique_codes", "expires_at", "eligibility_rules", "allowlist_only", "queue_mode", "lottery_closes_at", "lottery_seed_hash", "created_at", "updated_at"}).
+		AddRow(3, "PROMO", 10, 10, false, nil, nil, false, false, nil, "", now, now)
+	mock.ExpectQuery("SELECT id, name, .* FROM coupons WHERE id > \\$1 ORDER BY id ASC LIMIT \\$2").
+		WithArgs(int64(2), 5).
+		WillReturnRows(rows)
+
+	coupons, err := repo.ListCoupons(context.Background(), models.Page{AfterID: 2, Limit: 5})
+	assert.NoError(t, err)
+	assert.Len(t, coupons, 1)
+	assert.Equal(t, int64(3), coupons[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCouponsByNames_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "name", "amount", "remaining_amount", "unique_codes", "expires_at", "eligibility_rules", "allowlist_only", "queue_mode", "lottery_closes_at", "lottery_seed_hash", "created_at", "updated_at"}).
+		AddRow(1, "FLASH25", 100, 75, false, nil, nil, false, false, nil, "", now, now)
+	mock.ExpectQuery("SELECT id, name, .* FROM coupons WHERE name = ANY\\(\\$1\\)").
+		WithArgs(pq.Array([]string{"FLASH25", "MISSING"})).
+		WillReturnRows(rows)
+
+	coupons, err := repo.GetCouponsByNames(context.Background(), []string{"FLASH25", "MISSING"})
+	assert.NoError(t, err)
+	assert.Len(t, coupons, 1)
+	assert.Equal(t, "FLASH25", coupons[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCouponsByNames_NoNames(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	coupons, err := NewCouponRepository(db).GetCouponsByNames(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, coupons)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListClaimsByCoupons_OneQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("ROW_NUMBER\\(\\) OVER \\(PARTITION BY cl.coupon_name ORDER BY cl.id\\) AS position .* WHERE cl.coupon_name = ANY\\(\\$1\\) AND cl.id > \\$2 .* WHERE position <= \\$3 ORDER BY coupon_name ASC, id ASC").
+		WithArgs(pq.Array([]string{"FLASH25", "PROMO"}), int64(0), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "coupon_name", "code", "claimed_at"}).
+			AddRow(1, "user1", "FLASH25", "FLASH25-AAAA-BBBB", now).
+			AddRow(4, "user2", "FLASH25", "", now).
+			AddRow(2, "user1", "PROMO", "", now))
+
+	claims, err := repo.ListClaimsByCoupons(context.Background(), []string{"FLASH25", "PROMO"}, models.Page{Limit: 3})
+	assert.NoError(t, err)
+	assert.Len(t, claims, 3)
+	assert.Equal(t, "FLASH25-AAAA-BBBB", claims[0].Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListClaimsByUsers_OneQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	mock.ExpectQuery("PARTITION BY cl.user_id .* WHERE cl.user_id = ANY\\(\\$1\\)").
+		WithArgs(pq.Array([]string{"user1"}), int64(7), 2).
+		WillReturnError(errors.New("connection reset"))
+
+	_, err = repo.ListClaimsByUsers(context.Background(), []string{"user1"}, models.Page{AfterID: 7, Limit: 2})
+	assert.EqualError(t, err, "error listing claims: connection reset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateCouponWithCodes_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	codes := []string{"FLASH25-AAAA-BBBB", "FLASH25-CCCC-DDDD"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO coupons").
+		WithArgs("FLASH25", 2, nil, nil, false, false, nil, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO coupon_codes").
+		WithArgs(pq.Array(codes), "FLASH25").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err = repo.CreateCouponWithCodes(context.Background(), &models.Coupon{Name: "FLASH25", Amount: 2}, codes)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateCouponWithCodes_DuplicateCoupon(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO coupons").
+		WithArgs("FLASH25", 1, nil, nil, false, false, nil, "").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	err = repo.CreateCouponWithCodes(context.Background(), &models.Coupon{Name: "FLASH25", Amount: 1}, []string{"FLASH25-AAAA-BBBB"})
+	assert.Equal(t, ErrCouponAlreadyExists, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimCoupon_AssignsUniqueCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := &couponRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, true, nil, false, false, false))
+	mock.ExpectQuery("INSERT INTO claims").
+		WithArgs("user1", "FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "claimed_at"}).AddRow(7, time.Now()))
+	mock.ExpectQuery("UPDATE coupon_codes SET claim_id").
+		WithArgs(7, "FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow("FLASH25-7KQ2-M9XD"))
+	mock.ExpectExec("UPDATE coupons SET remaining_amount").
+		WithArgs("FLASH25").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	claim, err := repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, claim.ID)
+	assert.Equal(t, "FLASH25-7KQ2-M9XD", claim.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimCoupon_UniqueCodesExhausted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := &couponRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(1, true, nil, false, false, false))
+	mock.ExpectQuery("INSERT INTO claims").
+		WithArgs("user1", "FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "claimed_at"}).AddRow(7, time.Now()))
+	mock.ExpectQuery("UPDATE coupon_codes SET claim_id").
+		WithArgs(7, "FLASH25").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
+	assert.Equal(t, ErrNoStockAvailable, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCodeDetails_Assigned(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT cc.code").
+		WithArgs("FLASH25-7KQ2-M9XD").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"code", "redeemed_at", "id", "name", "amount", "remaining_amount", "unique_codes", "expires_at", "created_at", "updated_at",
+			"claim_id", "user_id", "claimed_at",
+		}).AddRow("FLASH25-7KQ2-M9XD", nil, 1, "FLASH25", 100, 99, true, nil, now, now, 7, "user1", now))
+
+	result, err := repo.GetCodeDetails(context.Background(), "FLASH25-7KQ2-M9XD")
+	assert.NoError(t, err)
+	assert.Equal(t, "FLASH25", result.Coupon.Name)
+	assert.NotNil(t, result.Claim)
+	assert.Equal(t, "user1", result.Claim.UserID)
+	assert.Equal(t, "FLASH25-7KQ2-M9XD", result.Claim.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCodeDetails_Unassigned(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT cc.code").
+		WithArgs("FLASH25-7KQ2-M9XD").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"code", "redeemed_at", "id", "name", "amount", "remaining_amount", "unique_codes", "expires_at", "created_at", "updated_at",
+			"claim_id", "user_id", "claimed_at",
+		}).AddRow("FLASH25-7KQ2-M9XD", nil, 1, "FLASH25", 100, 100, true, nil, now, now, nil, nil, nil))
+
+	result, err := repo.GetCodeDetails(context.Background(), "FLASH25-7KQ2-M9XD")
+	assert.NoError(t, err)
+	assert.Nil(t, result.Claim)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCodeDetails_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	mock.ExpectQuery("SELECT cc.code").
+		WithArgs("UNKNOWN").
+		WillReturnError(sql.ErrNoRows)
+
+	result, err := repo.GetCodeDetails(context.Background(), "UNKNOWN")
+	assert.Nil(t, result)
+	assert.Equal(t, ErrCodeNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimCoupon_CouponExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := &couponRepository{db: db}
+
+	expired := time.Now().Add(-time.Hour)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, false, expired, false, false, false))
+	mock.ExpectRollback()
+
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
+	assert.Equal(t, ErrCouponExpired, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimCoupon_UserDenied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := &couponRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, false, nil, false, true, false))
+	mock.ExpectRollback()
+
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "FLASH25")
+	assert.Equal(t, ErrUserDenied, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimCoupon_UserNotAllowed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := &couponRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, expires_at, allowlist_only").
+		WithArgs("B2B", "user1").
+		WillReturnRows(sqlmock.NewRows(claimLockColumns).AddRow(10, false, nil, true, false, false))
+	mock.ExpectRollback()
+
+	_, err = repo.ClaimCoupon(context.Background(), "user1", "B2B")
+	assert.Equal(t, ErrUserNotAllowed, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedeemCode_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT cc.id, cc.coupon_name, cl.user_id, cc.redeemed_at, c.expires_at FROM coupon_codes cc").
+		WithArgs("FLASH25-7KQ2-M9XD-H4TC").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "coupon_name", "user_id", "redeemed_at", "expires_at"}).
+			AddRow(3, "FLASH25", "user1", nil, nil))
+	mock.ExpectQuery("UPDATE coupon_codes SET redeemed_at").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"redeemed_at"}).AddRow(now))
+	mock.ExpectCommit()
+
+	redemption, err := repo.RedeemCode(context.Background(), "FLASH25-7KQ2-M9XD-H4TC")
+	assert.NoError(t, err)
+	assert.Equal(t, "FLASH25", redemption.CouponName)
+	assert.Equal(t, "user1", redemption.UserID)
+	assert.Equal(t, now, redemption.RedeemedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedeemCode_Failures(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		rows       *sqlmock.Rows
+		queryError error
+		expected   error
+	}{
+		{
+			name:       "unknown code",
+			queryError: sql.ErrNoRows,
+			expected:   ErrCodeNotFound,
+		},
+		{
+			name: "already redeemed",
+			rows: sqlmock.NewRows([]string{"id", "coupon_name", "user_id", "redeemed_at", "expires_at"}).
+				AddRow(3, "FLASH25", "user1", now, nil),
+			expected: ErrCodeAlreadyRedeemed,
+		},
+		{
+			name: "expired",
+			rows: sqlmock.NewRows([]string{"id", "coupon_name", "user_id", "redeemed_at", "expires_at"}).
+				AddRow(3, "FLASH25", "user1", nil, now.Add(-time.Minute)),
+			expected: ErrCodeExpired,
+		},
+		{
+			name: "not claimed",
+			rows: sqlmock.NewRows([]string{"id", "coupon_name", "user_id", "redeemed_at", "expires_at"}).
+				AddRow(3, "FLASH25", nil, nil, nil),
+			expected: ErrCodeNotClaimed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewCouponRepository(db)
+
+			mock.ExpectBegin()
+			query := mock.ExpectQuery("SELECT cc.id, cc.coupon_name").WithArgs("FLASH25-7KQ2-M9XD-H4TC")
+			if tt.queryError != nil {
+				query.WillReturnError(tt.queryError)
+			} else {
+				query.WillReturnRows(tt.rows)
+			}
+			mock.ExpectRollback()
+
+			redemption, err := repo.RedeemCode(context.Background(), "FLASH25-7KQ2-M9XD-H4TC")
+			assert.Nil(t, redemption)
+			assert.Equal(t, tt.expected, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetClaimPolicy_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	mock.ExpectQuery("SELECT eligibility_rules, queue_mode, lottery_closes_at FROM coupons WHERE name").
+		WithArgs("FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"eligibility_rules", "queue_mode", "lottery_closes_at"}).
+			AddRow([]byte(`[{"name":"new_users_only","attribute":"is_new_user","operator":"eq","value":true}]`), true, nil))
+
+	policy, err := repo.GetClaimPolicy(context.Background(), "FLASH25")
+	assert.NoError(t, err)
+	assert.Len(t, policy.EligibilityRules, 1)
+	assert.Equal(t, "new_users_only", policy.EligibilityRules[0].Name)
+	assert.Equal(t, true, policy.EligibilityRules[0].Value)
+	assert.True(t, policy.QueueMode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetClaimPolicy_NoRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	mock.ExpectQuery("SELECT eligibility_rules, queue_mode, lottery_closes_at FROM coupons WHERE name").
+		WithArgs("FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"eligibility_rules", "queue_mode", "lottery_closes_at"}).AddRow(nil, false, nil))
+
+	policy, err := repo.GetClaimPolicy(context.Background(), "FLASH25")
+	assert.NoError(t, err)
+	assert.Empty(t, policy.EligibilityRules)
+	assert.False(t, policy.QueueMode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetClaimPolicy_CouponNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	mock.ExpectQuery("SELECT eligibility_rules, queue_mode, lottery_closes_at FROM coupons WHERE name").
+		WithArgs("NONEXISTENT").
+		WillReturnError(sql.ErrNoRows)
+
+	policy, err := repo.GetClaimPolicy(context.Background(), "NONEXISTENT")
+	assert.Nil(t, policy)
+	assert.Equal(t, ErrCouponNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnterLottery_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	closesAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery("SELECT lottery_closes_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows([]string{"lottery_closes_at", "allowlist_only", "denied", "allowed"}).
+			AddRow(closesAt, false, false, false))
+	mock.ExpectQuery("INSERT INTO lottery_entries").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entered_at"}).AddRow(1, time.Now()))
+
+	entry, err := repo.EnterLottery(context.Background(), "user1", "FLASH25")
+	assert.NoError(t, err)
+	assert.True(t, entry.Entry)
+	assert.Equal(t, 1, entry.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnterLottery_EntriesClosed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	closedAt := time.Now().Add(-time.Minute)
+	mock.ExpectQuery("SELECT lottery_closes_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows([]string{"lottery_closes_at", "allowlist_only", "denied", "allowed"}).
+			AddRow(closedAt, false, false, false))
+
+	entry, err := repo.EnterLottery(context.Background(), "user1", "FLASH25")
+	assert.Nil(t, entry)
+	assert.Equal(t, ErrEntriesClosed, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnterLottery_AlreadyEntered(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	closesAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery("SELECT lottery_closes_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows([]string{"lottery_closes_at", "allowlist_only", "denied", "allowed"}).
+			AddRow(closesAt, false, false, false))
+	mock.ExpectQuery("INSERT INTO lottery_entries").
+		WithArgs("FLASH25", "user1").
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	entry, err := repo.EnterLottery(context.Background(), "user1", "FLASH25")
+	assert.Nil(t, entry)
+	assert.Equal(t, ErrAlreadyEntered, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnterLottery_UserDenied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+
+	closesAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery("SELECT lottery_closes_at, allowlist_only").
+		WithArgs("FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows([]string{"lottery_closes_at", "allowlist_only", "denied", "allowed"}).
+			AddRow(closesAt, false, true, false))
+
+	entry, err := repo.EnterLottery(context.Background(), "user1", "FLASH25")
+	assert.Nil(t, entry)
+	assert.Equal(t, ErrUserDenied, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
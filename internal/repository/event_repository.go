@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+// EventRepository defines the interface for reading the coupon_events outbox
+type EventRepository interface {
+	Relay(ctx context.Context, limit int, deliver func(events []models.CouponEvent) error) (int, error)
+}
+
+// eventRepository handles database operations for the coupon_events outbox
+type eventRepository struct {
+	db *sql.DB
+}
+
+// NewEventRepository creates a new EventRepository with injected database connection
+func NewEventRepository(db *sql.DB) EventRepository {
+	return &eventRepository{
+		db: db,
+	}
+}
+
+// Relay hands up to limit of the oldest undelivered events to deliver and marks them
+// delivered once it returns nil. The events stay locked until then, so relays running on
+// other instances skip them instead of delivering them again. It returns how many events
+// were delivered, 0 when there were none.
+func (r *eventRepository) Relay(ctx context.Context, limit int, deliver func(events []models.CouponEvent) error) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, type, coupon_name, user_id, payload, created_at
+		FROM coupon_events
+		WHERE delivered_at IS NULL
+		ORDER BY id ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := queryContext(ctx, tx, "SELECT coupon_events", query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("error getting events: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		events []models.CouponEvent
+		ids    []int64
+	)
+	for rows.Next() {
+		var event models.CouponEvent
+		if err := rows.Scan(&event.ID, &event.Type, &event.CouponName, &event.UserID, &event.Payload, &event.CreatedAt); err != nil {
+			return 0, fmt.Errorf("error scanning event: %v", err)
+		}
+		events = append(events, event)
+		ids = append(ids, event.ID)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating events: %v", err)
+	}
+	rows.Close()
+
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := deliver(events); err != nil {
+		return 0, err
+	}
+
+	updateQuery := `
+		UPDATE coupon_events
+		SET delivered_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($1::bigint[])
+	`
+	if _, err = execContext(ctx, tx, "UPDATE coupon_events", updateQuery, pq.Array(ids)); err != nil {
+		return 0, fmt.Errorf("error marking events delivered: %v", err)
+	}
+
+	if err = commitTx(ctx, tx); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return len(events), nil
+}
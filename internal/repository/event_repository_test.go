@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+var eventColumns = []string{"id", "type", "coupon_name", "user_id", "payload", "created_at"}
+
+func TestRelay_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewEventRepository(db)
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, type, coupon_name, user_id, payload, created_at FROM coupon_events WHERE delivered_at IS NULL .* FOR UPDATE SKIP LOCKED").
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows(eventColumns).
+			AddRow(1, models.EventLotteryLost, "FLASH25", "bob", []byte(`{"entries":3}`), now).
+			AddRow(2, models.EventLotteryLost, "FLASH25", "dave", []byte(`{"entries":3}`), now))
+	mock.ExpectExec("UPDATE coupon_events SET delivered_at = CURRENT_TIMESTAMP").
+		WithArgs(pq.Array([]int64{1, 2})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	var delivered []models.CouponEvent
+	relayed, err := repo.Relay(context.Background(), 100, func(events []models.CouponEvent) error {
+		delivered = events
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, relayed)
+	assert.Len(t, delivered, 2)
+	assert.Equal(t, "dave", delivered[1].UserID)
+	assert.JSONEq(t, `{"entries":3}`, string(delivered[0].Payload))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelay_Empty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewEventRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, type, coupon_name, user_id, payload, created_at FROM coupon_events").
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows(eventColumns))
+	mock.ExpectRollback()
+
+	relayed, err := repo.Relay(context.Background(), 100, func([]models.CouponEvent) error {
+		t.Fatal("deliver called without events")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, relayed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelay_DeliveryFailed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewEventRepository(db)
+
+	deliverErr := errors.New("webhook returned 503 Service Unavailable")
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, type, coupon_name, user_id, payload, created_at FROM coupon_events").
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows(eventColumns).
+			AddRow(1, models.EventLotteryLost, "FLASH25", "bob", []byte(`{}`), time.Now()))
+	mock.ExpectRollback()
+
+	relayed, err := repo.Relay(context.Background(), 100, func([]models.CouponEvent) error {
+		return deliverErr
+	})
+	assert.Equal(t, deliverErr, err)
+	assert.Equal(t, 0, relayed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
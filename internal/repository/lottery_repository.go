@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+var (
+	ErrLotteryAlreadyDrawn = errors.New("lottery already drawn")
+	ErrDrawNotFound        = errors.New("lottery draw not found")
+)
+
+// DrawFunc picks the winners of a lottery from its entrants, read in the transaction that
+// draws it, and returns the draw to record along with the entrants who lost. An error rolls
+// the draw back and is returned as it is.
+type DrawFunc func(entrants *models.LotteryEntrants) (draw *models.LotteryDraw, losers []string, err error)
+
+// LotteryRepository defines the interface for lottery draw data operations
+type LotteryRepository interface {
+	Draw(ctx context.Context, couponName string, pick DrawFunc) (*models.LotteryDraw, error)
+	GetDraw(ctx context.Context, couponName string) (*models.LotteryDraw, error)
+}
+
+// lotteryRepository handles database operations for lottery draws
+type lotteryRepository struct {
+	db *sql.DB
+}
+
+// NewLotteryRepository creates a new LotteryRepository with injected database connection
+func NewLotteryRepository(db *sql.DB) LotteryRepository {
+	return &lotteryRepository{
+		db: db,
+	}
+}
+
+// Draw draws a lottery coupon in one transaction. The coupon row is locked and its entrants
+// read, leaving out users denied since entering, then pick chooses the winners. The winners
+// are given claims, and codes for unique-code coupons, entries are marked won or lost, and a
+// lottery.lost event is written to the outbox for every loser. The draw is recorded with the
+// exact entrants it was run over. A coupon can only be drawn once.
+func (r *lotteryRepository) Draw(ctx context.Context, couponName string, pick DrawFunc) (*models.LotteryDraw, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the coupon row so the draw is serialized with any other stock change
+	var uniqueCodes bool
+	entrants := &models.LotteryEntrants{CouponName: couponName, UserIDs: []string{}}
+	couponQuery := `
+		SELECT remaining_amount, unique_codes, lottery_closes_at, lottery_seed_hash,
+		       EXISTS(SELECT 1 FROM lottery_draws WHERE coupon_name = $1)
+		FROM coupons
+		WHERE name = $1
+		FOR UPDATE
+	`
+	err = queryRowContext(ctx, tx, "SELECT coupons FOR UPDATE", couponQuery, couponName).Scan(
+		&entrants.RemainingAmount,
+		&uniqueCodes,
+		&entrants.LotteryClosesAt,
+		&entrants.SeedHash,
+		&entrants.Drawn,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCouponNotFound
+		}
+		return nil, fmt.Errorf("error checking coupon: %v", err)
+	}
+
+	entriesQuery := `
+		SELECT e.user_id
+		FROM lottery_entries e
+		WHERE e.coupon_name = $1
+		  AND NOT EXISTS (SELECT 1 FROM user_denylist d WHERE d.user_id = e.user_id)
+		ORDER BY e.user_id ASC
+	`
+	rows, err := queryContext(ctx, tx, "SELECT lottery_entries", entriesQuery, couponName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting lottery entries: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("error scanning lottery entry: %v", err)
+		}
+		entrants.UserIDs = append(entrants.UserIDs, userID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lottery entries: %v", err)
+	}
+	rows.Close()
+
+	draw, losers, err := pick(entrants)
+	if err != nil {
+		return nil, err
+	}
+
+	drawQuery := `
+		INSERT INTO lottery_draws (coupon_name, seed, seed_hash, entries, entrants, winners)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING drawn_at
+	`
+	err = queryRowContext(ctx, tx, "INSERT lottery_draws", drawQuery, draw.CouponName, draw.Seed, draw.SeedHash, draw.Entries, pq.Array(draw.Entrants), pq.Array(draw.Winners)).Scan(&draw.DrawnAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, ErrLotteryAlreadyDrawn
+		}
+		return nil, fmt.Errorf("error recording lottery draw: %v", err)
+	}
+
+	for _, userID := range draw.Winners {
+		var claimID int
+		err = queryRowContext(ctx, tx, "INSERT claims", `INSERT INTO claims (user_id, coupon_name) VALUES ($1, $2) RETURNING id`, userID, draw.CouponName).Scan(&claimID)
+		if err != nil {
+			return nil, fmt.Errorf("error creating claim: %v", err)
+		}
+
+		if uniqueCodes {
+			var code string
+			if err = queryRowContext(ctx, tx, "UPDATE coupon_codes", assignCodeQuery, claimID, draw.CouponName).Scan(&code); err != nil {
+				return nil, fmt.Errorf("error assigning coupon code: %v", err)
+			}
+		}
+	}
+
+	updateQuery := `
+		UPDATE coupons
+		SET remaining_amount = remaining_amount - $2,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE name = $1
+	`
+	if _, err = execContext(ctx, tx, "UPDATE coupons", updateQuery, draw.CouponName, len(draw.Winners)); err != nil {
+		return nil, fmt.Errorf("error updating coupon stock: %v", err)
+	}
+
+	resultQuery := `
+		UPDATE lottery_entries
+		SET result = $3
+		WHERE coupon_name = $1 AND user_id = ANY($2::text[])
+	`
+	if _, err = execContext(ctx, tx, "UPDATE lottery_entries", resultQuery, draw.CouponName, pq.Array(draw.Winners), models.LotteryResultWon); err != nil {
+		return nil, fmt.Errorf("error recording lottery results: %v", err)
+	}
+	if _, err = execContext(ctx, tx, "UPDATE lottery_entries", resultQuery, draw.CouponName, pq.Array(losers), models.LotteryResultLost); err != nil {
+		return nil, fmt.Errorf("error recording lottery results: %v", err)
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"entries": draw.Entries,
+		"winners": len(draw.Winners),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding event payload: %v", err)
+	}
+	eventsQuery := `
+		INSERT INTO coupon_events (type, coupon_name, user_id, payload)
+		SELECT $1, $2, unnest($3::text[]), $4
+	`
+	if _, err = execContext(ctx, tx, "INSERT coupon_events", eventsQuery, models.EventLotteryLost, draw.CouponName, pq.Array(losers), payload); err != nil {
+		return nil, fmt.Errorf("error writing lottery events: %v", err)
+	}
+
+	err = commitTx(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return draw, nil
+}
+
+// GetDraw returns the recorded draw of a lottery coupon
+func (r *lotteryRepository) GetDraw(ctx context.Context, couponName string) (*models.LotteryDraw, error) {
+	draw := &models.LotteryDraw{}
+	query := `
+		SELECT coupon_name, seed, seed_hash, entries, entrants, winners, drawn_at
+		FROM lottery_draws
+		WHERE coupon_name = $1
+	`
+	err := queryRowContext(ctx, r.db, "SELECT lottery_draws", query, couponName).Scan(
+		&draw.CouponName,
+		&draw.Seed,
+		&draw.SeedHash,
+		&draw.Entries,
+		pq.Array(&draw.Entrants),
+		pq.Array(&draw.Winners),
+		&draw.DrawnAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDrawNotFound
+		}
+		return nil, fmt.Errorf("error getting lottery draw: %v", err)
+	}
+
+	return draw, nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+// expectLotteryCoupon expects the locking read of a closed lottery coupon and its entrants
+func expectLotteryCoupon(mock sqlmock.Sqlmock, uniqueCodes bool, entrants ...string) {
+	rows := sqlmock.NewRows([]string{"user_id"})
+	for _, userID := range entrants {
+		rows.AddRow(userID)
+	}
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes, lottery_closes_at, lottery_seed_hash, .* FOR UPDATE").
+		WithArgs("FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"remaining_amount", "unique_codes", "lottery_closes_at", "lottery_seed_hash", "drawn"}).
+			AddRow(2, uniqueCodes, time.Now().Add(-time.Minute), "hash", false))
+	mock.ExpectQuery("SELECT e.user_id FROM lottery_entries e").
+		WithArgs("FLASH25").
+		WillReturnRows(rows)
+}
+
+func TestDraw_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLotteryRepository(db)
+
+	entrants := []string{"alice", "bob", "carol"}
+	winners := []string{"carol", "alice"}
+	losers := []string{"bob"}
+
+	mock.ExpectBegin()
+	expectLotteryCoupon(mock, true, entrants...)
+	mock.ExpectQuery("INSERT INTO lottery_draws").
+		WithArgs("FLASH25", "seed", "hash", 3, pq.Array(entrants), pq.Array(winners)).
+		WillReturnRows(sqlmock.NewRows([]string{"drawn_at"}).AddRow(time.Now()))
+	for i, winner := range winners {
+		mock.ExpectQuery("INSERT INTO claims").
+			WithArgs(winner, "FLASH25").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(i + 1))
+		mock.ExpectQuery("UPDATE coupon_codes").
+			WithArgs(i+1, "FLASH25").
+			WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow("FLASH25-7KQ2-M9XD-H4TC"))
+	}
+	mock.ExpectExec("UPDATE coupons").
+		WithArgs("FLASH25", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE lottery_entries").
+		WithArgs("FLASH25", pq.Array(winners), models.LotteryResultWon).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE lottery_entries").
+		WithArgs("FLASH25", pq.Array(losers), models.LotteryResultLost).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO coupon_events").
+		WithArgs(models.EventLotteryLost, "FLASH25", pq.Array(losers), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var picked *models.LotteryEntrants
+	draw, err := repo.Draw(context.Background(), "FLASH25", func(e *models.LotteryEntrants) (*models.LotteryDraw, []string, error) {
+		picked = e
+		return &models.LotteryDraw{
+			CouponName: "FLASH25",
+			Seed:       "seed",
+			SeedHash:   e.SeedHash,
+			Entries:    len(e.UserIDs),
+			Entrants:   e.UserIDs,
+			Winners:    winners,
+		}, losers, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, picked.RemainingAmount)
+	assert.Equal(t, "hash", picked.SeedHash)
+	assert.Equal(t, entrants, picked.UserIDs)
+	assert.False(t, draw.DrawnAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDraw_PickError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLotteryRepository(db)
+
+	pickErr := errors.New("seed mismatch")
+	mock.ExpectBegin()
+	expectLotteryCoupon(mock, false, "alice")
+	mock.ExpectRollback()
+
+	draw, err := repo.Draw(context.Background(), "FLASH25", func(*models.LotteryEntrants) (*models.LotteryDraw, []string, error) {
+		return nil, nil, pickErr
+	})
+	assert.Nil(t, draw)
+	assert.Equal(t, pickErr, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDraw_CouponNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLotteryRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT remaining_amount, unique_codes").
+		WithArgs("NONEXISTENT").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	draw, err := repo.Draw(context.Background(), "NONEXISTENT", func(*models.LotteryEntrants) (*models.LotteryDraw, []string, error) {
+		t.Fatal("pick called for a missing coupon")
+		return nil, nil, nil
+	})
+	assert.Nil(t, draw)
+	assert.Equal(t, ErrCouponNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDraw_AlreadyDrawn(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLotteryRepository(db)
+
+	mock.ExpectBegin()
+	expectLotteryCoupon(mock, false, "alice")
+	mock.ExpectQuery("INSERT INTO lottery_draws").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	draw, err := repo.Draw(context.Background(), "FLASH25", func(e *models.LotteryEntrants) (*models.LotteryDraw, []string, error) {
+		return &models.LotteryDraw{CouponName: "FLASH25", Seed: "seed", Entries: 1, Entrants: e.UserIDs, Winners: e.UserIDs}, nil, nil
+	})
+	assert.Nil(t, draw)
+	assert.Equal(t, ErrLotteryAlreadyDrawn, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDraw_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLotteryRepository(db)
+
+	mock.ExpectQuery("SELECT coupon_name, seed, seed_hash, entries, entrants, winners, drawn_at FROM lottery_draws").
+		WithArgs("FLASH25").
+		WillReturnRows(sqlmock.NewRows([]string{"coupon_name", "seed", "seed_hash", "entries", "entrants", "winners", "drawn_at"}).
+			AddRow("FLASH25", "seed", "hash", 3, "{alice,bob,carol}", "{carol,alice}", time.Now()))
+
+	draw, err := repo.GetDraw(context.Background(), "FLASH25")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", draw.SeedHash)
+	assert.Equal(t, []string{"alice", "bob", "carol"}, draw.Entrants)
+	assert.Equal(t, []string{"carol", "alice"}, draw.Winners)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDraw_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLotteryRepository(db)
+
+	mock.ExpectQuery("SELECT coupon_name, seed, seed_hash, entries, entrants, winners, drawn_at FROM lottery_draws").
+		WithArgs("FLASH25").
+		WillReturnError(sql.ErrNoRows)
+
+	draw, err := repo.GetDraw(context.Background(), "FLASH25")
+	assert.Nil(t, draw)
+	assert.Equal(t, ErrDrawNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
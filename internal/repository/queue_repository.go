@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+var ErrTicketNotFound = errors.New("queue ticket not found")
+
+// QueueRepository defines the interface for claim queue data operations
+type QueueRepository interface {
+	Enqueue(ctx context.Context, couponName, userID, ticket string) (string, error)
+	GetTicket(ctx context.Context, ticket string) (*models.QueueTicket, error)
+	TakeNext(ctx context.Context, lease time.Duration) (*models.QueueTicket, error)
+	FailTicket(ctx context.Context, id int64, errMsg string) error
+}
+
+// queueRepository handles database operations for the claim queue
+type queueRepository struct {
+	db *sql.DB
+}
+
+// NewQueueRepository creates a new QueueRepository with injected database connection
+func NewQueueRepository(db *sql.DB) QueueRepository {
+	return &queueRepository{
+		db: db,
+	}
+}
+
+// Enqueue adds a waiting ticket for the user and returns it. A user who is already in the
+// coupon's queue keeps their place and gets their existing ticket back.
+func (r *queueRepository) Enqueue(ctx context.Context, couponName, userID, ticket string) (string, error) {
+	// The outer SELECT does not see the row inserted by the CTE, so it only finds
+	// a ticket when the insert hit the unique constraint
+	query := `
+		WITH inserted AS (
+			INSERT INTO claim_queue (ticket, coupon_name, user_id)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (coupon_name, user_id) DO NOTHING
+			RETURNING ticket
+		)
+		SELECT ticket FROM inserted
+		UNION ALL
+		SELECT ticket FROM claim_queue WHERE coupon_name = $2 AND user_id = $3
+		LIMIT 1
+	`
+
+	var result string
+	err := queryRowContext(ctx, r.db, "INSERT claim_queue", query, ticket, couponName, userID).Scan(&result)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return "", ErrCouponNotFound
+		}
+		return "", fmt.Errorf("error joining queue: %v", err)
+	}
+
+	return result, nil
+}
+
+// GetTicket returns a ticket with its position among the coupon's waiting tickets
+func (r *queueRepository) GetTicket(ctx context.Context, ticket string) (*models.QueueTicket, error) {
+	query := `
+		SELECT q.id, q.ticket, q.coupon_name, q.user_id, q.status, q.error, q.code, q.created_at, q.processed_at,
+		       CASE WHEN q.status = 'waiting' THEN (
+		           SELECT COUNT(*)
+		           FROM claim_queue w
+		           WHERE w.coupon_name = q.coupon_name AND w.status = 'waiting' AND w.id <= q.id
+		       ) ELSE 0 END
+		FROM claim_queue q
+		WHERE q.ticket = $1
+	`
+
+	var t models.QueueTicket
+	err := queryRowContext(ctx, r.db, "SELECT claim_queue", query, ticket).Scan(
+		&t.ID,
+		&t.Ticket,
+		&t.CouponName,
+		&t.UserID,
+		&t.Status,
+		&t.Error,
+		&t.Code,
+		&t.CreatedAt,
+		&t.ProcessedAt,
+		&t.Position,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTicketNotFound
+		}
+		return nil, fmt.Errorf("error getting queue ticket: %v", err)
+	}
+
+	return &t, nil
+}
+
+// TakeNext marks the oldest waiting ticket of any coupon as processing for lease and
+// returns it, or nil when the queue is empty. Tickets taken by another worker are skipped
+// until their lease expires: a ticket still processing by then belongs to a worker that
+// stopped or failed to record the outcome, and is taken again. Retrying is safe, as a
+// successful claim marks its ticket claimed in the claim's own transaction.
+func (r *queueRepository) TakeNext(ctx context.Context, lease time.Duration) (*models.QueueTicket, error) {
+	query := `
+		UPDATE claim_queue
+		SET status = 'processing',
+		    taken_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id
+			FROM claim_queue
+			WHERE status = 'waiting'
+			   OR (status = 'processing' AND taken_at < CURRENT_TIMESTAMP - make_interval(secs => $1))
+			ORDER BY id ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, ticket, coupon_name, user_id, status, created_at
+	`
+
+	var t models.QueueTicket
+	err := queryRowContext(ctx, r.db, "UPDATE claim_queue", query, lease.Seconds()).Scan(&t.ID, &t.Ticket, &t.CouponName, &t.UserID, &t.Status, &t.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error taking queue ticket: %v", err)
+	}
+
+	return &t, nil
+}
+
+// FailTicket records why the claim of a ticket being processed failed. A ticket another
+// worker already completed is left as it is.
+func (r *queueRepository) FailTicket(ctx context.Context, id int64, errMsg string) error {
+	query := `
+		UPDATE claim_queue
+		SET status = $2,
+		    error = $3,
+		    processed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'processing'
+	`
+
+	_, err := execContext(ctx, r.db, "UPDATE claim_queue", query, id, models.QueueStatusFailed, errMsg)
+	if err != nil {
+		return fmt.Errorf("error completing queue ticket: %v", err)
+	}
+
+	return nil
+}
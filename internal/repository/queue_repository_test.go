@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+const testTicket = "5f1d7c1e-7a51-4d7b-9d55-0c7bb7f0a9b1"
+
+func TestEnqueue_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQueueRepository(db)
+
+	mock.ExpectQuery("INSERT INTO claim_queue .* ON CONFLICT \\(coupon_name, user_id\\) DO NOTHING").
+		WithArgs(testTicket, "FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows([]string{"ticket"}).AddRow(testTicket))
+
+	ticket, err := repo.Enqueue(context.Background(), "FLASH25", "user1", testTicket)
+	assert.NoError(t, err)
+	assert.Equal(t, testTicket, ticket)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueue_ExistingTicket(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQueueRepository(db)
+
+	existing := "0b7d8f4e-2c7f-4b53-a3f6-1d2c3e4f5a6b"
+	mock.ExpectQuery("INSERT INTO claim_queue").
+		WithArgs(testTicket, "FLASH25", "user1").
+		WillReturnRows(sqlmock.NewRows([]string{"ticket"}).AddRow(existing))
+
+	ticket, err := repo.Enqueue(context.Background(), "FLASH25", "user1", testTicket)
+	assert.NoError(t, err)
+	assert.Equal(t, existing, ticket)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueue_CouponNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQueueRepository(db)
+
+	mock.ExpectQuery("INSERT INTO claim_queue").
+		WithArgs(testTicket, "NONEXISTENT", "user1").
+		WillReturnError(&pq.Error{Code: "23503"})
+
+	_, err = repo.Enqueue(context.Background(), "NONEXISTENT", "user1", testTicket)
+	assert.Equal(t, ErrCouponNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTicket_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQueueRepository(db)
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "ticket", "coupon_name", "user_id", "status", "error", "code", "created_at", "processed_at", "position"}).
+		AddRow(7, testTicket, "FLASH25", "user1", "waiting", "", "", now, nil, 3)
+	mock.ExpectQuery("SELECT q.id, q.ticket, .* FROM claim_queue q WHERE q.ticket").
+		WithArgs(testTicket).
+		WillReturnRows(rows)
+
+	ticket, err := repo.GetTicket(context.Background(), testTicket)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.QueueTicket{
+		ID:         7,
+		Ticket:     testTicket,
+		CouponName: "FLASH25",
+		UserID:     "user1",
+		Status:     models.QueueStatusWaiting,
+		Position:   3,
+		CreatedAt:  now,
+	}, ticket)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTicket_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQueueRepository(db)
+
+	mock.ExpectQuery("SELECT q.id, q.ticket").
+		WithArgs(testTicket).
+		WillReturnError(sql.ErrNoRows)
+
+	ticket, err := repo.GetTicket(context.Background(), testTicket)
+	assert.Nil(t, ticket)
+	assert.Equal(t, ErrTicketNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTakeNext_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQueueRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("UPDATE claim_queue SET status = 'processing', taken_at = CURRENT_TIMESTAMP .*" +
+		"\\(status = 'processing' AND taken_at < CURRENT_TIMESTAMP - make_interval\\(secs => \\$1\\)\\) ORDER BY id ASC .* FOR UPDATE SKIP LOCKED").
+		WithArgs(float64(30)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "ticket", "coupon_name", "user_id", "status", "created_at"}).
+			AddRow(7, testTicket, "FLASH25", "user1", "processing", now))
+
+	ticket, err := repo.TakeNext(context.Background(), 30*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), ticket.ID)
+	assert.Equal(t, models.QueueStatusProcessing, ticket.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTakeNext_Empty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQueueRepository(db)
+
+	mock.ExpectQuery("UPDATE claim_queue SET status = 'processing'").
+		WillReturnError(sql.ErrNoRows)
+
+	ticket, err := repo.TakeNext(context.Background(), 30*time.Second)
+	assert.NoError(t, err)
+	assert.Nil(t, ticket)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFailTicket(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewQueueRepository(db)
+
+	mock.ExpectExec("UPDATE claim_queue SET status = \\$2, error = \\$3, .* WHERE id = \\$1 AND status = 'processing'").
+		WithArgs(int64(7), "failed", "no stock available").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.FailTicket(context.Background(), 7, "no stock available")
+	assert.NoError(t, err)
+
+	mock.ExpectExec("UPDATE claim_queue").
+		WithArgs(int64(8), "failed", "claim could not be processed").
+		WillReturnError(errors.New("database connection lost"))
+
+	err = repo.FailTicket(context.Background(), 8, "claim could not be processed")
+	assert.ErrorContains(t, err, "error completing queue ticket")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/wazadio/coupon-system/internal/repository")
+
+// queryer runs statements on a *sql.DB or inside a *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// startStatement starts a client span named name for one SQL statement. The query text is
+// recorded with its placeholders, never the argument values.
+func startStatement(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+// endStatement ends the span of a statement, recording err unless it only means no rows
+func endStatement(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// execContext runs a statement that returns no rows in a span named name
+func execContext(ctx context.Context, q queryer, name, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startStatement(ctx, name, query)
+	result, err := q.ExecContext(ctx, query, args...)
+	endStatement(span, err)
+	return result, err
+}
+
+// queryContext runs a query in a span named name. The span covers running the query, not
+// reading its rows.
+func queryContext(ctx context.Context, q queryer, name, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startStatement(ctx, name, query)
+	rows, err := q.QueryContext(ctx, query, args...)
+	endStatement(span, err)
+	return rows, err
+}
+
+// queryRowContext runs a query returning at most one row in a span named name. Any error
+// running it is recorded on the span and, as with QueryRowContext, reported by Scan.
+func queryRowContext(ctx context.Context, q queryer, name, query string, args ...interface{}) *sql.Row {
+	ctx, span := startStatement(ctx, name, query)
+	row := q.QueryRowContext(ctx, query, args...)
+	endStatement(span, row.Err())
+	return row
+}
+
+// commitTx commits tx in a span of its own, as committing waits on the database too
+func commitTx(ctx context.Context, tx *sql.Tx) error {
+	_, span := startStatement(ctx, "COMMIT", "COMMIT")
+	err := tx.Commit()
+	endStatement(span, err)
+	return err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStatementSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO coupons").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO coupon_codes").
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "CouponService.CreateCoupon")
+	err = NewCouponRepository(db).CreateCouponWithCodes(ctx, &models.Coupon{Name: "FLASH25", Amount: 1}, []string{"FLASH25-AAAA-BBBB"})
+	parent.End()
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	var statements []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() == parent.SpanContext().SpanID() {
+			statements = append(statements, span)
+		}
+	}
+	if assert.Len(t, statements, 2) {
+		assert.Equal(t, "INSERT coupons", statements[0].Name())
+		assert.Equal(t, codes.Unset, statements[0].Status().Code)
+		assert.Contains(t, statements[0].Attributes(), attribute.String("db.system", "postgresql"))
+
+		assert.Equal(t, "INSERT coupon_codes", statements[1].Name())
+		assert.Equal(t, codes.Error, statements[1].Status().Code)
+		for _, attr := range statements[1].Attributes() {
+			if attr.Key == "db.query.text" {
+				// Placeholders are recorded, never the argument values
+				assert.Contains(t, attr.Value.AsString(), "unnest($1::text[]), $2")
+				assert.NotContains(t, attr.Value.AsString(), "FLASH25-AAAA-BBBB")
+			}
+		}
+	}
+}
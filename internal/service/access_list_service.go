@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/validation"
+)
+
+// maxAccessListBatch caps how many user IDs a single bulk upload may contain
+const maxAccessListBatch = 10000
+
+// AccessListService defines the interface for managing coupon allowlists and the user denylist
+type AccessListService interface {
+	AddToAllowlist(ctx context.Context, couponName string, userIDs []string) (*models.AccessListResponse, error)
+	ListAllowlist(ctx context.Context, couponName string) ([]models.AllowlistEntry, error)
+	RemoveFromAllowlist(ctx context.Context, couponName, userID string) error
+	AddToDenylist(ctx context.Context, userIDs []string, reason string) (*models.AccessListResponse, error)
+	ListDenylist(ctx context.Context) ([]models.DenylistEntry, error)
+	RemoveFromDenylist(ctx context.Context, userID string) error
+}
+
+// ErrUserNotListed is returned when removing a user that is not on the list
+var ErrUserNotListed = errors.New("user is not on the list")
+
+// accessListService handles business logic for allowlists and the denylist
+type accessListService struct {
+	repo repository.AccessListRepository
+}
+
+// NewAccessListService creates a new AccessListService with injected repository
+func NewAccessListService(repo repository.AccessListRepository) AccessListService {
+	return &accessListService{
+		repo: repo,
+	}
+}
+
+// AddToAllowlist adds users to a coupon's allowlist
+func (s *accessListService) AddToAllowlist(ctx context.Context, couponName string, userIDs []string) (*models.AccessListResponse, error) {
+	if couponName == "" {
+		return nil, validation.Invalid("name", "name is required")
+	}
+
+	ids, err := normalizeUserIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := s.repo.AddToAllowlist(ctx, couponName, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AccessListResponse{Submitted: len(ids), Added: added}, nil
+}
+
+// ListAllowlist returns the users on a coupon's allowlist
+func (s *accessListService) ListAllowlist(ctx context.Context, couponName string) ([]models.AllowlistEntry, error) {
+	return s.repo.ListAllowlist(ctx, couponName)
+}
+
+// RemoveFromAllowlist removes a user from a coupon's allowlist
+func (s *accessListService) RemoveFromAllowlist(ctx context.Context, couponName, userID string) error {
+	rowsAffected, err := s.repo.RemoveFromAllowlist(ctx, couponName, userID)
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotListed
+	}
+
+	return nil
+}
+
+// AddToDenylist blocks users from claiming any coupon
+func (s *accessListService) AddToDenylist(ctx context.Context, userIDs []string, reason string) (*models.AccessListResponse, error) {
+	ids, err := normalizeUserIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := s.repo.AddToDenylist(ctx, ids, strings.TrimSpace(reason))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AccessListResponse{Submitted: len(ids), Added: added}, nil
+}
+
+// ListDenylist returns every denied user
+func (s *accessListService) ListDenylist(ctx context.Context) ([]models.DenylistEntry, error) {
+	return s.repo.ListDenylist(ctx)
+}
+
+// RemoveFromDenylist lifts the block on a user
+func (s *accessListService) RemoveFromDenylist(ctx context.Context, userID string) error {
+	rowsAffected, err := s.repo.RemoveFromDenylist(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotListed
+	}
+
+	return nil
+}
+
+// normalizeUserIDs trims surrounding whitespace, drops blanks and duplicates and enforces the batch cap
+func normalizeUserIDs(userIDs []string) ([]string, error) {
+	var errs validation.Errors
+	seen := make(map[string]struct{}, len(userIDs))
+	ids := make([]string, 0, len(userIDs))
+	for i, id := range userIDs {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if len(id) > validation.MaxVarchar {
+			errs.Add(fmt.Sprintf("user_ids[%d]", i), "user_ids[%d] must be at most %d characters", i, validation.MaxVarchar)
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 && len(errs) == 0 {
+		errs.Add("user_ids", "at least one user ID is required")
+	}
+	if len(ids) > maxAccessListBatch {
+		errs.Add("user_ids", "at most %d user IDs can be uploaded at once", maxAccessListBatch)
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
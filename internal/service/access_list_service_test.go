@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+)
+
+// MockAccessListRepository is a mock implementation of AccessListRepository
+type MockAccessListRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccessListRepository) AddToAllowlist(ctx context.Context, couponName string, userIDs []string) (int64, error) {
+	args := m.Called(couponName, userIDs)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccessListRepository) ListAllowlist(ctx context.Context, couponName string) ([]models.AllowlistEntry, error) {
+	args := m.Called(couponName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AllowlistEntry), args.Error(1)
+}
+
+func (m *MockAccessListRepository) RemoveFromAllowlist(ctx context.Context, couponName, userID string) (int64, error) {
+	args := m.Called(couponName, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccessListRepository) AddToDenylist(ctx context.Context, userIDs []string, reason string) (int64, error) {
+	args := m.Called(userIDs, reason)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccessListRepository) ListDenylist(ctx context.Context) ([]models.DenylistEntry, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DenylistEntry), args.Error(1)
+}
+
+func (m *MockAccessListRepository) RemoveFromDenylist(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestAddToAllowlist_NormalizesUserIDs(t *testing.T) {
+	mockRepo := new(MockAccessListRepository)
+	svc := NewAccessListService(mockRepo)
+
+	mockRepo.On("AddToAllowlist", "B2B", []string{"user1", "user2"}).Return(int64(1), nil)
+
+	resp, err := svc.AddToAllowlist(context.Background(), "B2B", []string{" user1 ", "user2", "", "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.AccessListResponse{Submitted: 2, Added: 1}, resp)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAddToAllowlist_NoUserIDs(t *testing.T) {
+	mockRepo := new(MockAccessListRepository)
+	svc := NewAccessListService(mockRepo)
+
+	_, err := svc.AddToAllowlist(context.Background(), "B2B", []string{" ", ""})
+	assert.Error(t, err)
+	assert.Equal(t, "at least one user ID is required", err.Error())
+	mockRepo.AssertNotCalled(t, "AddToAllowlist")
+}
+
+func TestAddToAllowlist_TooManyUserIDs(t *testing.T) {
+	mockRepo := new(MockAccessListRepository)
+	svc := NewAccessListService(mockRepo)
+
+	ids := make([]string, maxAccessListBatch+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("user%d", i)
+	}
+
+	_, err := svc.AddToAllowlist(context.Background(), "B2B", ids)
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "AddToAllowlist")
+}
+
+func TestAddToAllowlist_CouponNotFound(t *testing.T) {
+	mockRepo := new(MockAccessListRepository)
+	svc := NewAccessListService(mockRepo)
+
+	mockRepo.On("AddToAllowlist", "NONEXISTENT", []string{"user1"}).Return(int64(0), repository.ErrCouponNotFound)
+
+	_, err := svc.AddToAllowlist(context.Background(), "NONEXISTENT", []string{"user1"})
+	assert.Equal(t, repository.ErrCouponNotFound, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRemoveFromAllowlist_NotListed(t *testing.T) {
+	mockRepo := new(MockAccessListRepository)
+	svc := NewAccessListService(mockRepo)
+
+	mockRepo.On("RemoveFromAllowlist", "B2B", "user1").Return(int64(0), nil)
+
+	err := svc.RemoveFromAllowlist(context.Background(), "B2B", "user1")
+	assert.Equal(t, ErrUserNotListed, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAddToDenylist_TrimsReason(t *testing.T) {
+	mockRepo := new(MockAccessListRepository)
+	svc := NewAccessListService(mockRepo)
+
+	mockRepo.On("AddToDenylist", []string{"abuser"}, "chargeback fraud").Return(int64(1), nil)
+
+	resp, err := svc.AddToDenylist(context.Background(), []string{"abuser"}, "  chargeback fraud ")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Added)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRemoveFromDenylist_Success(t *testing.T) {
+	mockRepo := new(MockAccessListRepository)
+	svc := NewAccessListService(mockRepo)
+
+	mockRepo.On("RemoveFromDenylist", "abuser").Return(int64(1), nil)
+
+	err := svc.RemoveFromDenylist(context.Background(), "abuser")
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/validation"
+	"go.uber.org/zap"
+)
+
+const (
+	// apiKeyTag starts every key so leaked keys are easy to recognise in code and logs
+	apiKeyTag = "cpk"
+
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+)
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyService defines the interface for managing and authenticating API keys
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// apiKeyService handles business logic for API keys
+type apiKeyService struct {
+	repo         repository.APIKeyRepository
+	isValidScope func(scope string) bool
+}
+
+// NewAPIKeyService creates a new APIKeyService with injected repository. isValidScope
+// reports whether a scope names a permission routes can require.
+func NewAPIKeyService(repo repository.APIKeyRepository, isValidScope func(scope string) bool) APIKeyService {
+	return &apiKeyService{
+		repo:         repo,
+		isValidScope: isValidScope,
+	}
+}
+
+// CreateAPIKey generates a new key of the form cpk_<prefix>_<secret> and stores its hash
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error) {
+	errs := validation.Struct(req)
+	for i, scope := range req.Scopes {
+		if !s.isValidScope(scope) {
+			errs.Add(fmt.Sprintf("scopes[%d]", i), "unknown scope %q", scope)
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+
+	couponNames := []string{}
+	for _, couponName := range req.CouponNames {
+		if couponName = strings.TrimSpace(couponName); couponName != "" {
+			couponNames = append(couponNames, couponName)
+		}
+	}
+
+	prefix, err := randomHex(apiKeyPrefixBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error generating api key: %v", err)
+	}
+	secret, err := randomHex(apiKeySecretBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error generating api key: %v", err)
+	}
+	key := apiKeyTag + "_" + prefix + "_" + secret
+
+	apiKey := &models.APIKey{
+		Name:        name,
+		Prefix:      prefix,
+		Scopes:      req.Scopes,
+		CouponNames: couponNames,
+	}
+	if err := s.repo.CreateAPIKey(ctx, apiKey, hashAPIKey(key)); err != nil {
+		return nil, err
+	}
+
+	return &models.CreateAPIKeyResponse{APIKey: *apiKey, Key: key}, nil
+}
+
+// ListAPIKeys returns every key without its secret
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx)
+}
+
+// RevokeAPIKey stops a key from authenticating
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id int64) error {
+	rowsAffected, err := s.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the active key matching key and records its use.
+// Every mismatch is reported as ErrInvalidAPIKey.
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, keyHash, err := s.repo.GetActiveAPIKeyByPrefix(ctx, parts[1])
+	if err != nil {
+		if err == repository.ErrAPIKeyNotFound {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(keyHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// A failed timestamp update must not fail the request it was recorded for
+	if err := s.repo.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		logger.Log.Warn("Failed to record api key use", zap.Int64("api_key_id", apiKey.ID), zap.Error(err))
+	}
+
+	return apiKey, nil
+}
+
+// hashAPIKey returns the hex SHA-256 of key. Keys carry 256 random bits, so a fast
+// unsalted hash is enough to make a leaked table useless.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+// MockAPIKeyRepository is a mock implementation of APIKeyRepository
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error {
+	args := m.Called(key, keyHash)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetActiveAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, string, error) {
+	args := m.Called(prefix)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*models.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) RevokeAPIKey(ctx context.Context, id int64) (int64, error) {
+	args := m.Called(id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func validTestScope(scope string) bool {
+	return scope == "coupons:claim" || scope == "coupons:read"
+}
+
+func TestCreateAPIKey_Success(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, validTestScope)
+
+	var storedHash string
+	mockRepo.On("CreateAPIKey", mock.AnythingOfType("*models.APIKey"), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*models.APIKey).ID = 5
+			storedHash = args.String(1)
+		}).
+		Return(nil)
+
+	resp, err := svc.CreateAPIKey(context.Background(), &models.CreateAPIKeyRequest{
+		Name:        " checkout ",
+		Scopes:      []string{"coupons:claim"},
+		CouponNames: []string{"FLASH25", " "},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), resp.ID)
+	assert.Equal(t, "checkout", resp.Name)
+	assert.Equal(t, []string{"FLASH25"}, resp.CouponNames)
+	assert.True(t, strings.HasPrefix(resp.Key, "cpk_"+resp.Prefix+"_"))
+	assert.Equal(t, hashAPIKey(resp.Key), storedHash)
+	assert.NotContains(t, storedHash, resp.Key)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAPIKey_Validation(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, validTestScope)
+
+	_, err := svc.CreateAPIKey(context.Background(), &models.CreateAPIKeyRequest{Scopes: []string{"coupons:claim"}})
+	assert.EqualError(t, err, "name is required")
+
+	_, err = svc.CreateAPIKey(context.Background(), &models.CreateAPIKeyRequest{Name: "crm"})
+	assert.EqualError(t, err, "scopes is required")
+
+	_, err = svc.CreateAPIKey(context.Background(), &models.CreateAPIKeyRequest{Name: "crm", Scopes: []string{"coupons:delete"}})
+	assert.EqualError(t, err, `unknown scope "coupons:delete"`)
+
+	mockRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+}
+
+func TestAuthenticateAPIKey_Success(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, validTestScope)
+
+	key := "cpk_a1b2c3_secret"
+	stored := &models.APIKey{ID: 5, Prefix: "a1b2c3", Scopes: []string{"coupons:claim"}}
+	mockRepo.On("GetActiveAPIKeyByPrefix", "a1b2c3").Return(stored, hashAPIKey(key), nil)
+	mockRepo.On("TouchAPIKey", int64(5)).Return(nil)
+
+	apiKey, err := svc.AuthenticateAPIKey(context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, apiKey)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthenticateAPIKey_TouchFailureIgnored(t *testing.T) {
+	logger.Init()
+
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, validTestScope)
+
+	key := "cpk_a1b2c3_secret"
+	mockRepo.On("GetActiveAPIKeyByPrefix", "a1b2c3").Return(&models.APIKey{ID: 5}, hashAPIKey(key), nil)
+	mockRepo.On("TouchAPIKey", int64(5)).Return(errors.New("connection reset"))
+
+	_, err := svc.AuthenticateAPIKey(context.Background(), key)
+	assert.NoError(t, err)
+}
+
+func TestAuthenticateAPIKey_Invalid(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, validTestScope)
+
+	mockRepo.On("GetActiveAPIKeyByPrefix", "a1b2c3").Return(&models.APIKey{ID: 5}, hashAPIKey("cpk_a1b2c3_secret"), nil)
+	mockRepo.On("GetActiveAPIKeyByPrefix", "revoked").Return(nil, "", repository.ErrAPIKeyNotFound)
+
+	for _, key := range []string{"garbage", "xyz_a1b2c3_secret", "cpk_a1b2c3_guess", "cpk_revoked_secret"} {
+		_, err := svc.AuthenticateAPIKey(context.Background(), key)
+		assert.Equal(t, ErrInvalidAPIKey, err, key)
+	}
+	mockRepo.AssertNotCalled(t, "TouchAPIKey", mock.Anything)
+}
+
+func TestRevokeAPIKey_NotFound(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, validTestScope)
+
+	mockRepo.On("RevokeAPIKey", int64(9)).Return(int64(0), nil)
+
+	err := svc.RevokeAPIKey(context.Background(), 9)
+	assert.Equal(t, repository.ErrAPIKeyNotFound, err)
+}
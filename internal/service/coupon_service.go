@@ -1,18 +1,43 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/wazadio/coupon-system/internal/metrics"
 	"github.com/wazadio/coupon-system/internal/models"
 	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/internal/tracing"
+	"github.com/wazadio/coupon-system/pkg/couponcode"
+	"github.com/wazadio/coupon-system/pkg/validation"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/wazadio/coupon-system/internal/service")
+
+// maxUniqueCodes caps how many codes a single coupon may pre-generate
+const maxUniqueCodes = 100000
+
 // CouponService defines the interface for coupon business logic
 type CouponService interface {
-	CreateCoupon(req *models.CreateCouponRequest) error
-	ClaimCoupon(req *models.ClaimCouponRequest) error
-	GetCouponDetails(name string) (*models.CouponDetailResponse, error)
-	UpdateCoupon(name string) (rowsAffected int64, err error)
+	CreateCoupon(ctx context.Context, req *models.CreateCouponRequest) error
+	ClaimCoupon(ctx context.Context, req *models.ClaimCouponRequest) (*models.Claim, error)
+	GetCouponDetails(ctx context.Context, name string) (*models.CouponDetailResponse, error)
+	UpdateCoupon(ctx context.Context, name string) (rowsAffected int64, err error)
+	ImportCoupons(ctx context.Context, rows []models.ImportCouponRow) *models.ImportCouponsResponse
+	ExportCoupons(ctx context.Context, fn func(*models.Coupon) error) error
+	ExportClaims(ctx context.Context, couponName string, fn func(*models.Claim) error) error
+	ListCoupons(ctx context.Context, page models.Page) (*models.CouponPage, error)
+	GetCouponsByNames(ctx context.Context, names []string) (map[string]*models.Coupon, error)
+	ListClaimsByCoupons(ctx context.Context, couponNames []string, page models.Page) (map[string]*models.ClaimPage, error)
+	ListClaimsByUsers(ctx context.Context, userIDs []string, page models.Page) (map[string]*models.ClaimPage, error)
+	LookupCode(ctx context.Context, code string) (*models.CodeLookupResponse, error)
+	RedeemCode(ctx context.Context, code string) (*models.CodeRedemption, error)
 }
 
 // couponService handles business logic for coupons
@@ -28,44 +53,350 @@ func NewCouponService(repo repository.CouponRepository) CouponService {
 }
 
 // CreateCoupon creates a new coupon
-func (s *couponService) CreateCoupon(req *models.CreateCouponRequest) error {
-	// Validate input
-	if req.Name == "" {
-		return errors.New("coupon name is required")
+func (s *couponService) CreateCoupon(ctx context.Context, req *models.CreateCouponRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.CreateCoupon")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateCreateCouponRequest(req); err != nil {
+		return err
+	}
+
+	coupon := &models.Coupon{
+		Name:             req.Name,
+		Amount:           req.Amount,
+		RemainingAmount:  req.Amount,
+		UniqueCodes:      req.UniqueCodes,
+		ExpiresAt:        req.ExpiresAt,
+		EligibilityRules: req.EligibilityRules,
+		AllowlistOnly:    req.AllowlistOnly,
+		QueueMode:        req.QueueMode,
+		LotteryClosesAt:  req.LotteryClosesAt,
+		LotterySeedHash:  strings.ToLower(req.LotterySeedHash),
+	}
+
+	if !req.UniqueCodes {
+		return s.repo.CreateCoupon(ctx, coupon)
+	}
+
+	codes, err := couponcode.GenerateN(req.Name, req.Amount)
+	if err != nil {
+		return fmt.Errorf("error generating coupon codes: %v", err)
+	}
+
+	return s.repo.CreateCouponWithCodes(ctx, coupon, codes)
+}
+
+// validateCreateCouponRequest holds the rules shared by single and bulk coupon creation
+// and reports every violation at once. Field rules are declared on the model, the
+// cross-field rules are checked here.
+func validateCreateCouponRequest(req *models.CreateCouponRequest) error {
+	errs := validation.Struct(req)
+	if req.UniqueCodes && req.Amount > maxUniqueCodes {
+		errs.Add("amount", "amount must be at most %d when unique_codes is enabled", maxUniqueCodes)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		errs.Add("expires_at", "expires_at must be in the future")
 	}
-	if req.Amount <= 0 {
-		return errors.New("coupon amount must be greater than 0")
+	if req.LotteryClosesAt != nil {
+		if !req.LotteryClosesAt.After(time.Now()) {
+			errs.Add("lottery_closes_at", "lottery_closes_at must be in the future")
+		}
+		if req.QueueMode {
+			errs.Add("queue_mode", "queue_mode cannot be combined with a lottery")
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(*req.LotteryClosesAt) {
+			errs.Add("expires_at", "expires_at must be after lottery_closes_at")
+		}
+		// The seed is committed to now, so it cannot be picked once the entrants are known
+		if hash, err := hex.DecodeString(req.LotterySeedHash); err != nil || len(hash) != sha256.Size {
+			errs.Add("lottery_seed_hash", "lottery_seed_hash must be the hex SHA-256 hash of the draw seed")
+		}
+	} else if req.LotterySeedHash != "" {
+		errs.Add("lottery_seed_hash", "lottery_seed_hash requires lottery_closes_at")
 	}
+	validateEligibilityRules(&errs, req.EligibilityRules)
 
-	return s.repo.CreateCoupon(req.Name, req.Amount)
+	return errs.Err()
 }
 
 // ClaimCoupon attempts to claim a coupon for a user
-func (s *couponService) ClaimCoupon(req *models.ClaimCouponRequest) error {
-	// Validate input
-	if req.UserID == "" {
-		return errors.New("user_id is required")
+func (s *couponService) ClaimCoupon(ctx context.Context, req *models.ClaimCouponRequest) (claim *models.Claim, err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.ClaimCoupon")
+	defer func() { tracing.End(span, err) }()
+
+	claim, err = s.claimCoupon(ctx, req)
+	metrics.ClaimOutcomes.WithLabelValues(claimOutcome(claim, err)).Inc()
+	return claim, err
+}
+
+func (s *couponService) claimCoupon(ctx context.Context, req *models.ClaimCouponRequest) (*models.Claim, error) {
+	if err := validation.Struct(req).Err(); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.repo.GetClaimPolicy(ctx, req.CouponName)
+	if err != nil {
+		return nil, err
+	}
+	// Queue-mode coupons are only claimed by the queue worker, in ticket order
+	if policy.QueueMode {
+		return nil, ErrQueueRequired
+	}
+	if err := evaluateEligibility(policy.EligibilityRules, req.Attributes); err != nil {
+		return nil, err
 	}
-	if req.CouponName == "" {
-		return errors.New("coupon_name is required")
+
+	// Lottery coupons record the claim as an entry, winners are drawn when entries close
+	if policy.LotteryClosesAt != nil {
+		return s.repo.EnterLottery(ctx, req.UserID, req.CouponName)
 	}
 
-	return s.repo.ClaimCoupon(req.UserID, req.CouponName)
+	return s.repo.ClaimCoupon(ctx, req.UserID, req.CouponName)
+}
+
+// claimOutcomeLabels names the outcome of claims failing with a known error in metrics
+var claimOutcomeLabels = []struct {
+	err   error
+	label string
+}{
+	{repository.ErrCouponNotFound, "not_found"},
+	{repository.ErrAlreadyClaimed, "already_claimed"},
+	{repository.ErrNoStockAvailable, "sold_out"},
+	{repository.ErrCouponExpired, "expired"},
+	{repository.ErrUserDenied, "denied"},
+	{repository.ErrUserNotAllowed, "not_allowlisted"},
+	{ErrNotEligible, "not_eligible"},
+	{ErrQueueRequired, "queue_required"},
+	{repository.ErrAlreadyEntered, "already_entered"},
+	{repository.ErrEntriesClosed, "entries_closed"},
+}
+
+// claimOutcome returns the outcome label of a claim that returned claim and err. Lottery
+// entries are counted apart from claims, as they do not take stock.
+func claimOutcome(claim *models.Claim, err error) string {
+	if err == nil {
+		if claim != nil && claim.Entry {
+			return "lottery_entry"
+		}
+		return "success"
+	}
+
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		return "invalid"
+	}
+	for _, outcome := range claimOutcomeLabels {
+		if errors.Is(err, outcome.err) {
+			return outcome.label
+		}
+	}
+	return "error"
 }
 
 // GetCouponDetails retrieves coupon details with all claimed users
-func (s *couponService) GetCouponDetails(name string) (*models.CouponDetailResponse, error) {
+func (s *couponService) GetCouponDetails(ctx context.Context, name string) (details *models.CouponDetailResponse, err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.GetCouponDetails")
+	defer func() { tracing.End(span, err) }()
+
 	if name == "" {
-		return nil, errors.New("coupon name is required")
+		return nil, validation.Invalid("name", "name is required")
 	}
 
-	return s.repo.GetCouponByName(name)
+	return s.repo.GetCouponByName(ctx, name)
 }
 
-func (s *couponService) UpdateCoupon(name string) (rowsAffected int64, err error) {
+func (s *couponService) UpdateCoupon(ctx context.Context, name string) (rowsAffected int64, err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.UpdateCoupon")
+	defer func() { tracing.End(span, err) }()
+
 	if name == "" {
-		return 0, errors.New("coupon name is required")
+		return 0, validation.Invalid("name", "name is required")
+	}
+
+	return s.repo.Update(ctx, name)
+}
+
+// ImportCoupons creates every valid row and reports the rows that were rejected.
+// Rows are independent: a failing row does not prevent the others from being imported.
+func (s *couponService) ImportCoupons(ctx context.Context, rows []models.ImportCouponRow) *models.ImportCouponsResponse {
+	ctx, span := tracer.Start(ctx, "CouponService.ImportCoupons")
+	defer span.End()
+
+	response := &models.ImportCouponsResponse{
+		Total:  len(rows),
+		Errors: []models.ImportRowError{},
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		err := row.Err
+		if err == nil {
+			err = s.CreateCoupon(ctx, &row.CreateCouponRequest)
+		}
+		if err != nil {
+			response.Failed++
+			response.Errors = append(response.Errors, models.ImportRowError{
+				Row:  row.Row,
+				Name: row.Name,
+				Err:  err,
+			})
+			continue
+		}
+		response.Imported++
+	}
+
+	return response
+}
+
+// ExportCoupons streams every coupon to fn
+func (s *couponService) ExportCoupons(ctx context.Context, fn func(*models.Coupon) error) (err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.ExportCoupons")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.ExportCoupons(ctx, fn)
+}
+
+// ExportClaims streams every claim of a coupon to fn
+func (s *couponService) ExportClaims(ctx context.Context, couponName string, fn func(*models.Claim) error) (err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.ExportClaims")
+	defer func() { tracing.End(span, err) }()
+
+	if couponName == "" {
+		return validation.Invalid("name", "name is required")
+	}
+
+	return s.repo.ExportClaims(ctx, couponName, fn)
+}
+
+// MaxPageSize caps how many items a page may hold
+const MaxPageSize = 100
+
+// ListCoupons returns a page of coupons ordered by id
+func (s *couponService) ListCoupons(ctx context.Context, page models.Page) (result *models.CouponPage, err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.ListCoupons")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validatePage(page); err != nil {
+		return nil, err
+	}
+
+	// Ask for one more than the page holds to learn whether another page follows
+	coupons, err := s.repo.ListCoupons(ctx, models.Page{AfterID: page.AfterID, Limit: page.Limit + 1})
+	if err != nil {
+		return nil, err
+	}
+
+	result = &models.CouponPage{Coupons: coupons}
+	if len(coupons) > page.Limit {
+		result.Coupons = coupons[:page.Limit]
+		result.HasNextPage = true
+	}
+	return result, nil
+}
+
+// GetCouponsByNames returns the coupons among names that exist, keyed by name
+func (s *couponService) GetCouponsByNames(ctx context.Context, names []string) (byName map[string]*models.Coupon, err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.GetCouponsByNames")
+	defer func() { tracing.End(span, err) }()
+
+	coupons, err := s.repo.GetCouponsByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	byName = make(map[string]*models.Coupon, len(coupons))
+	for i := range coupons {
+		byName[coupons[i].Name] = &coupons[i]
+	}
+	return byName, nil
+}
+
+// ListClaimsByCoupons returns a page of claims for each of couponNames, keyed by coupon name.
+// Every name gets a page, empty for coupons without claims or that do not exist.
+func (s *couponService) ListClaimsByCoupons(ctx context.Context, couponNames []string, page models.Page) (pages map[string]*models.ClaimPage, err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.ListClaimsByCoupons")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validatePage(page); err != nil {
+		return nil, err
+	}
+
+	claims, err := s.repo.ListClaimsByCoupons(ctx, couponNames, models.Page{AfterID: page.AfterID, Limit: page.Limit + 1})
+	if err != nil {
+		return nil, err
+	}
+	return groupClaims(couponNames, claims, page.Limit, func(c *models.Claim) string { return c.CouponName }), nil
+}
+
+// ListClaimsByUsers returns a page of claims for each of userIDs, keyed by user ID
+func (s *couponService) ListClaimsByUsers(ctx context.Context, userIDs []string, page models.Page) (pages map[string]*models.ClaimPage, err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.ListClaimsByUsers")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validatePage(page); err != nil {
+		return nil, err
+	}
+
+	claims, err := s.repo.ListClaimsByUsers(ctx, userIDs, models.Page{AfterID: page.AfterID, Limit: page.Limit + 1})
+	if err != nil {
+		return nil, err
+	}
+	return groupClaims(userIDs, claims, page.Limit, func(c *models.Claim) string { return c.UserID }), nil
+}
+
+// groupClaims splits claims fetched with one extra per key into a page per key
+func groupClaims(keys []string, claims []models.Claim, limit int, keyOf func(*models.Claim) string) map[string]*models.ClaimPage {
+	pages := make(map[string]*models.ClaimPage, len(keys))
+	for _, key := range keys {
+		pages[key] = &models.ClaimPage{Claims: []models.Claim{}}
+	}
+
+	for i := range claims {
+		page, ok := pages[keyOf(&claims[i])]
+		if !ok {
+			continue
+		}
+		if len(page.Claims) == limit {
+			page.HasNextPage = true
+			continue
+		}
+		page.Claims = append(page.Claims, claims[i])
+	}
+	return pages
+}
+
+func validatePage(page models.Page) error {
+	if page.Limit < 1 || page.Limit > MaxPageSize {
+		return validation.Invalid("limit", "limit must be between 1 and %d", MaxPageSize)
+	}
+	if page.AfterID < 0 {
+		return validation.Invalid("after", "after must not be negative")
+	}
+	return nil
+}
+
+// LookupCode resolves a generated code to its coupon and claim
+func (s *couponService) LookupCode(ctx context.Context, code string) (response *models.CodeLookupResponse, err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.LookupCode")
+	defer func() { tracing.End(span, err) }()
+
+	code, err = couponcode.Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetCodeDetails(ctx, code)
+}
+
+// RedeemCode redeems a claimed code. Typos are rejected by the checksum before the database is queried.
+func (s *couponService) RedeemCode(ctx context.Context, code string) (redemption *models.CodeRedemption, err error) {
+	ctx, span := tracer.Start(ctx, "CouponService.RedeemCode")
+	defer func() { tracing.End(span, err) }()
+
+	code, err = couponcode.Normalize(code)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.Update(name)
+	return s.repo.RedeemCode(ctx, code)
 }
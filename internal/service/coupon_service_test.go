@@ -1,13 +1,22 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/handlers/middleware"
+	"github.com/wazadio/coupon-system/internal/metrics"
 	"github.com/wazadio/coupon-system/internal/models"
 	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/couponcode"
+	"github.com/wazadio/coupon-system/pkg/lottery"
+	"github.com/wazadio/coupon-system/pkg/validation"
 )
 
 // MockCouponRepository is a mock implementation of CouponRepository
@@ -15,17 +24,41 @@ type MockCouponRepository struct {
 	mock.Mock
 }
 
-func (m *MockCouponRepository) CreateCoupon(name string, amount int) error {
-	args := m.Called(name, amount)
+func (m *MockCouponRepository) CreateCoupon(ctx context.Context, coupon *models.Coupon) error {
+	args := m.Called(coupon)
 	return args.Error(0)
 }
 
-func (m *MockCouponRepository) ClaimCoupon(userID, couponName string) error {
-	args := m.Called(userID, couponName)
+func (m *MockCouponRepository) CreateCouponWithCodes(ctx context.Context, coupon *models.Coupon, codes []string) error {
+	args := m.Called(coupon, codes)
 	return args.Error(0)
 }
 
-func (m *MockCouponRepository) GetCouponByName(name string) (*models.CouponDetailResponse, error) {
+func (m *MockCouponRepository) ClaimCoupon(ctx context.Context, userID, couponName string) (*models.Claim, error) {
+	args := m.Called(userID, couponName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Claim), args.Error(1)
+}
+
+func (m *MockCouponRepository) ClaimQueuedCoupon(ctx context.Context, ticketID int64, userID, couponName string) (*models.Claim, error) {
+	args := m.Called(ticketID, userID, couponName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Claim), args.Error(1)
+}
+
+func (m *MockCouponRepository) EnterLottery(ctx context.Context, userID, couponName string) (*models.Claim, error) {
+	args := m.Called(userID, couponName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Claim), args.Error(1)
+}
+
+func (m *MockCouponRepository) GetCouponByName(ctx context.Context, name string) (*models.CouponDetailResponse, error) {
 	args := m.Called(name)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -33,11 +66,77 @@ func (m *MockCouponRepository) GetCouponByName(name string) (*models.CouponDetai
 	return args.Get(0).(*models.CouponDetailResponse), args.Error(1)
 }
 
-func (m *MockCouponRepository) Update(name string) (int64, error) {
+func (m *MockCouponRepository) GetClaimPolicy(ctx context.Context, couponName string) (*models.ClaimPolicy, error) {
+	args := m.Called(couponName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClaimPolicy), args.Error(1)
+}
+
+func (m *MockCouponRepository) Update(ctx context.Context, name string) (int64, error) {
 	args := m.Called(name)
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockCouponRepository) ExportCoupons(ctx context.Context, fn func(*models.Coupon) error) error {
+	args := m.Called(fn)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) ExportClaims(ctx context.Context, couponName string, fn func(*models.Claim) error) error {
+	args := m.Called(couponName, fn)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) ListCoupons(ctx context.Context, page models.Page) ([]models.Coupon, error) {
+	args := m.Called(page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponRepository) GetCouponsByNames(ctx context.Context, names []string) ([]models.Coupon, error) {
+	args := m.Called(names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponRepository) ListClaimsByCoupons(ctx context.Context, couponNames []string, page models.Page) ([]models.Claim, error) {
+	args := m.Called(couponNames, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Claim), args.Error(1)
+}
+
+func (m *MockCouponRepository) ListClaimsByUsers(ctx context.Context, userIDs []string, page models.Page) ([]models.Claim, error) {
+	args := m.Called(userIDs, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Claim), args.Error(1)
+}
+
+func (m *MockCouponRepository) GetCodeDetails(ctx context.Context, code string) (*models.CodeLookupResponse, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeLookupResponse), args.Error(1)
+}
+
+func (m *MockCouponRepository) RedeemCode(ctx context.Context, code string) (*models.CodeRedemption, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeRedemption), args.Error(1)
+}
+
 func TestCreateCoupon_Success(t *testing.T) {
 	mockRepo := new(MockCouponRepository)
 	service := NewCouponService(mockRepo)
@@ -47,9 +146,9 @@ func TestCreateCoupon_Success(t *testing.T) {
 		Amount: 100,
 	}
 
-	mockRepo.On("CreateCoupon", "FLASH25", 100).Return(nil)
+	mockRepo.On("CreateCoupon", &models.Coupon{Name: "FLASH25", Amount: 100, RemainingAmount: 100}).Return(nil)
 
-	err := service.CreateCoupon(req)
+	err := service.CreateCoupon(context.Background(), req)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
@@ -63,9 +162,53 @@ func TestCreateCoupon_EmptyName(t *testing.T) {
 		Amount: 100,
 	}
 
-	err := service.CreateCoupon(req)
+	err := service.CreateCoupon(context.Background(), req)
 	assert.Error(t, err)
-	assert.Equal(t, "coupon name is required", err.Error())
+	assert.Equal(t, "name is required", err.Error())
+}
+
+func TestCreateCoupon_ReportsEveryViolation(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	req := &models.CreateCouponRequest{
+		Name:   " FLASH 25",
+		Amount: 0,
+	}
+
+	err := service.CreateCoupon(context.Background(), req)
+	var violations validation.Errors
+	assert.True(t, errors.As(err, &violations))
+	assert.Equal(t, validation.Errors{
+		{Field: "name", Message: "name must not start or end with whitespace"},
+		{Field: "amount", Message: "amount must be at least 1"},
+	}, violations)
+	mockRepo.AssertNotCalled(t, "CreateCoupon", mock.Anything)
+}
+
+func TestCreateCoupon_NameRules(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"FLASH_25.v2-a", ""},
+		{"FLASH 25", "name may only contain letters, digits, '_', '-' and '.', starting with a letter or digit"},
+		{"-FLASH25", "name may only contain letters, digits, '_', '-' and '.', starting with a letter or digit"},
+		{"FLASH/25", "name may only contain letters, digits, '_', '-' and '.', starting with a letter or digit"},
+		{strings.Repeat("A", 255), ""},
+		{strings.Repeat("A", 256), "name must be at most 255 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCreateCouponRequest(&models.CreateCouponRequest{Name: tt.name, Amount: 1})
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.want)
+		})
+	}
 }
 
 func TestCreateCoupon_ZeroAmount(t *testing.T) {
@@ -77,9 +220,9 @@ func TestCreateCoupon_ZeroAmount(t *testing.T) {
 		Amount: 0,
 	}
 
-	err := service.CreateCoupon(req)
+	err := service.CreateCoupon(context.Background(), req)
 	assert.Error(t, err)
-	assert.Equal(t, "coupon amount must be greater than 0", err.Error())
+	assert.Equal(t, "amount must be at least 1", err.Error())
 }
 
 func TestCreateCoupon_NegativeAmount(t *testing.T) {
@@ -91,9 +234,9 @@ func TestCreateCoupon_NegativeAmount(t *testing.T) {
 		Amount: -10,
 	}
 
-	err := service.CreateCoupon(req)
+	err := service.CreateCoupon(context.Background(), req)
 	assert.Error(t, err)
-	assert.Equal(t, "coupon amount must be greater than 0", err.Error())
+	assert.Equal(t, "amount must be at least 1", err.Error())
 }
 
 func TestCreateCoupon_AlreadyExists(t *testing.T) {
@@ -105,9 +248,9 @@ func TestCreateCoupon_AlreadyExists(t *testing.T) {
 		Amount: 100,
 	}
 
-	mockRepo.On("CreateCoupon", "FLASH25", 100).Return(repository.ErrCouponAlreadyExists)
+	mockRepo.On("CreateCoupon", &models.Coupon{Name: "FLASH25", Amount: 100, RemainingAmount: 100}).Return(repository.ErrCouponAlreadyExists)
 
-	err := service.CreateCoupon(req)
+	err := service.CreateCoupon(context.Background(), req)
 	assert.Equal(t, repository.ErrCouponAlreadyExists, err)
 	mockRepo.AssertExpectations(t)
 }
@@ -121,9 +264,9 @@ func TestCreateCoupon_RepositoryError(t *testing.T) {
 		Amount: 100,
 	}
 
-	mockRepo.On("CreateCoupon", "FLASH25", 100).Return(errors.New("database error"))
+	mockRepo.On("CreateCoupon", &models.Coupon{Name: "FLASH25", Amount: 100, RemainingAmount: 100}).Return(errors.New("database error"))
 
-	err := service.CreateCoupon(req)
+	err := service.CreateCoupon(context.Background(), req)
 	assert.Error(t, err)
 	assert.Equal(t, "database error", err.Error())
 	mockRepo.AssertExpectations(t)
@@ -138,13 +281,58 @@ func TestClaimCoupon_Success(t *testing.T) {
 		CouponName: "FLASH25",
 	}
 
-	mockRepo.On("ClaimCoupon", "user1", "FLASH25").Return(nil)
+	mockRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{}, nil)
+	mockRepo.On("ClaimCoupon", "user1", "FLASH25").Return(&models.Claim{ID: 1, UserID: "user1", CouponName: "FLASH25"}, nil)
 
-	err := service.ClaimCoupon(req)
+	_, err := service.ClaimCoupon(context.Background(), req)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestClaimCoupon_RecordsOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		claim   *models.Claim
+		err     error
+		outcome string
+	}{
+		{"success", &models.Claim{ID: 1}, nil, "success"},
+		{"lottery entry", &models.Claim{ID: 1, Entry: true}, nil, "lottery_entry"},
+		{"sold out", nil, repository.ErrNoStockAvailable, "sold_out"},
+		{"already claimed", nil, repository.ErrAlreadyClaimed, "already_claimed"},
+		{"not found", nil, repository.ErrCouponNotFound, "not_found"},
+		{"unexpected error", nil, errors.New("connection reset"), "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCouponRepository)
+			service := NewCouponService(mockRepo)
+
+			mockRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{}, nil)
+			mockRepo.On("ClaimCoupon", "user1", "FLASH25").Return(tt.claim, tt.err)
+
+			counter := metrics.ClaimOutcomes.WithLabelValues(tt.outcome)
+			before := testutil.ToFloat64(counter)
+
+			service.ClaimCoupon(context.Background(), &models.ClaimCouponRequest{UserID: "user1", CouponName: "FLASH25"})
+
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
+
+func TestClaimCoupon_InvalidRequestOutcome(t *testing.T) {
+	service := NewCouponService(new(MockCouponRepository))
+
+	counter := metrics.ClaimOutcomes.WithLabelValues("invalid")
+	before := testutil.ToFloat64(counter)
+
+	service.ClaimCoupon(context.Background(), &models.ClaimCouponRequest{CouponName: "FLASH25"})
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
 func TestClaimCoupon_EmptyUserID(t *testing.T) {
 	mockRepo := new(MockCouponRepository)
 	service := NewCouponService(mockRepo)
@@ -154,7 +342,7 @@ func TestClaimCoupon_EmptyUserID(t *testing.T) {
 		CouponName: "FLASH25",
 	}
 
-	err := service.ClaimCoupon(req)
+	_, err := service.ClaimCoupon(context.Background(), req)
 	assert.Error(t, err)
 	assert.Equal(t, "user_id is required", err.Error())
 }
@@ -168,7 +356,7 @@ func TestClaimCoupon_EmptyCouponName(t *testing.T) {
 		CouponName: "",
 	}
 
-	err := service.ClaimCoupon(req)
+	_, err := service.ClaimCoupon(context.Background(), req)
 	assert.Error(t, err)
 	assert.Equal(t, "coupon_name is required", err.Error())
 }
@@ -182,9 +370,10 @@ func TestClaimCoupon_CouponNotFound(t *testing.T) {
 		CouponName: "NONEXISTENT",
 	}
 
-	mockRepo.On("ClaimCoupon", "user1", "NONEXISTENT").Return(repository.ErrCouponNotFound)
+	mockRepo.On("GetClaimPolicy", "NONEXISTENT").Return(&models.ClaimPolicy{}, nil)
+	mockRepo.On("ClaimCoupon", "user1", "NONEXISTENT").Return(nil, repository.ErrCouponNotFound)
 
-	err := service.ClaimCoupon(req)
+	_, err := service.ClaimCoupon(context.Background(), req)
 	assert.Equal(t, repository.ErrCouponNotFound, err)
 	mockRepo.AssertExpectations(t)
 }
@@ -198,9 +387,10 @@ func TestClaimCoupon_AlreadyClaimed(t *testing.T) {
 		CouponName: "FLASH25",
 	}
 
-	mockRepo.On("ClaimCoupon", "user1", "FLASH25").Return(repository.ErrAlreadyClaimed)
+	mockRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{}, nil)
+	mockRepo.On("ClaimCoupon", "user1", "FLASH25").Return(nil, repository.ErrAlreadyClaimed)
 
-	err := service.ClaimCoupon(req)
+	_, err := service.ClaimCoupon(context.Background(), req)
 	assert.Equal(t, repository.ErrAlreadyClaimed, err)
 	mockRepo.AssertExpectations(t)
 }
@@ -214,9 +404,10 @@ func TestClaimCoupon_NoStockAvailable(t *testing.T) {
 		CouponName: "FLASH25",
 	}
 
-	mockRepo.On("ClaimCoupon", "user1", "FLASH25").Return(repository.ErrNoStockAvailable)
+	mockRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{}, nil)
+	mockRepo.On("ClaimCoupon", "user1", "FLASH25").Return(nil, repository.ErrNoStockAvailable)
 
-	err := service.ClaimCoupon(req)
+	_, err := service.ClaimCoupon(context.Background(), req)
 	assert.Equal(t, repository.ErrNoStockAvailable, err)
 	mockRepo.AssertExpectations(t)
 }
@@ -230,9 +421,10 @@ func TestClaimCoupon_RepositoryError(t *testing.T) {
 		CouponName: "FLASH25",
 	}
 
-	mockRepo.On("ClaimCoupon", "user1", "FLASH25").Return(errors.New("database error"))
+	mockRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{}, nil)
+	mockRepo.On("ClaimCoupon", "user1", "FLASH25").Return(nil, errors.New("database error"))
 
-	err := service.ClaimCoupon(req)
+	_, err := service.ClaimCoupon(context.Background(), req)
 	assert.Error(t, err)
 	assert.Equal(t, "database error", err.Error())
 	mockRepo.AssertExpectations(t)
@@ -251,7 +443,7 @@ func TestGetCouponDetails_Success(t *testing.T) {
 
 	mockRepo.On("GetCouponByName", "FLASH25").Return(expectedResponse, nil)
 
-	result, err := service.GetCouponDetails("FLASH25")
+	result, err := service.GetCouponDetails(context.Background(), "FLASH25")
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, "FLASH25", result.Name)
@@ -264,10 +456,10 @@ func TestGetCouponDetails_EmptyName(t *testing.T) {
 	mockRepo := new(MockCouponRepository)
 	service := NewCouponService(mockRepo)
 
-	result, err := service.GetCouponDetails("")
+	result, err := service.GetCouponDetails(context.Background(), "")
 	assert.Nil(t, result)
 	assert.Error(t, err)
-	assert.Equal(t, "coupon name is required", err.Error())
+	assert.Equal(t, "name is required", err.Error())
 }
 
 func TestGetCouponDetails_NotFound(t *testing.T) {
@@ -276,7 +468,7 @@ func TestGetCouponDetails_NotFound(t *testing.T) {
 
 	mockRepo.On("GetCouponByName", "NONEXISTENT").Return(nil, repository.ErrCouponNotFound)
 
-	result, err := service.GetCouponDetails("NONEXISTENT")
+	result, err := service.GetCouponDetails(context.Background(), "NONEXISTENT")
 	assert.Nil(t, result)
 	assert.Equal(t, repository.ErrCouponNotFound, err)
 	mockRepo.AssertExpectations(t)
@@ -288,7 +480,7 @@ func TestGetCouponDetails_RepositoryError(t *testing.T) {
 
 	mockRepo.On("GetCouponByName", "FLASH25").Return(nil, errors.New("database error"))
 
-	result, err := service.GetCouponDetails("FLASH25")
+	result, err := service.GetCouponDetails(context.Background(), "FLASH25")
 	assert.Nil(t, result)
 	assert.Error(t, err)
 	assert.Equal(t, "database error", err.Error())
@@ -301,7 +493,7 @@ func TestUpdateCoupon_Success(t *testing.T) {
 
 	mockRepo.On("Update", "FLASH25").Return(int64(1), nil)
 
-	rowsAffected, err := service.UpdateCoupon("FLASH25")
+	rowsAffected, err := service.UpdateCoupon(context.Background(), "FLASH25")
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), rowsAffected)
 	mockRepo.AssertExpectations(t)
@@ -311,10 +503,10 @@ func TestUpdateCoupon_EmptyName(t *testing.T) {
 	mockRepo := new(MockCouponRepository)
 	service := NewCouponService(mockRepo)
 
-	rowsAffected, err := service.UpdateCoupon("")
+	rowsAffected, err := service.UpdateCoupon(context.Background(), "")
 	assert.Error(t, err)
 	assert.Equal(t, int64(0), rowsAffected)
-	assert.Equal(t, "coupon name is required", err.Error())
+	assert.Equal(t, "name is required", err.Error())
 }
 
 func TestUpdateCoupon_RepositoryError(t *testing.T) {
@@ -323,9 +515,495 @@ func TestUpdateCoupon_RepositoryError(t *testing.T) {
 
 	mockRepo.On("Update", "FLASH25").Return(int64(0), errors.New("database error"))
 
-	rowsAffected, err := service.UpdateCoupon("FLASH25")
+	rowsAffected, err := service.UpdateCoupon(context.Background(), "FLASH25")
 	assert.Error(t, err)
 	assert.Equal(t, int64(0), rowsAffected)
 	assert.Equal(t, "database error", err.Error())
 	mockRepo.AssertExpectations(t)
 }
+
+func TestImportCoupons_ReportsRowErrors(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	rows := []models.ImportCouponRow{
+		{Row: 2, CreateCouponRequest: models.CreateCouponRequest{Name: "FLASH25", Amount: 100}},
+		{Row: 3, CreateCouponRequest: models.CreateCouponRequest{Name: "", Amount: 10}},
+		{Row: 4, CreateCouponRequest: models.CreateCouponRequest{Name: "ZERO", Amount: 0}},
+		{Row: 5, CreateCouponRequest: models.CreateCouponRequest{Name: "DUPLICATE", Amount: 5}},
+		{Row: 6, CreateCouponRequest: models.CreateCouponRequest{Name: "UNREAD"}, Err: validation.Invalid("amount", "amount must be an integer")},
+	}
+
+	mockRepo.On("CreateCoupon", &models.Coupon{Name: "FLASH25", Amount: 100, RemainingAmount: 100}).Return(nil)
+	mockRepo.On("CreateCoupon", &models.Coupon{Name: "DUPLICATE", Amount: 5, RemainingAmount: 5}).Return(repository.ErrCouponAlreadyExists)
+
+	response := service.ImportCoupons(context.Background(), rows)
+	assert.Equal(t, 5, response.Total)
+	assert.Equal(t, 1, response.Imported)
+	assert.Equal(t, 4, response.Failed)
+	want := []struct {
+		row     int
+		name    string
+		message string
+	}{
+		{3, "", "name is required"},
+		{4, "ZERO", "amount must be at least 1"},
+		{5, "DUPLICATE", repository.ErrCouponAlreadyExists.Error()},
+		{6, "UNREAD", "amount must be an integer"},
+	}
+	if assert.Len(t, response.Errors, len(want)) {
+		for i, w := range want {
+			assert.Equal(t, w.row, response.Errors[i].Row)
+			assert.Equal(t, w.name, response.Errors[i].Name)
+			assert.EqualError(t, response.Errors[i].Err, w.message)
+			// The transport maps Err to the reported message
+			assert.Empty(t, response.Errors[i].Error)
+		}
+	}
+	assert.ErrorIs(t, response.Errors[2].Err, repository.ErrCouponAlreadyExists)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestExportClaims_EmptyName(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	err := service.ExportClaims(context.Background(), "", func(*models.Claim) error { return nil })
+	assert.Error(t, err)
+	assert.Equal(t, "name is required", err.Error())
+	mockRepo.AssertNotCalled(t, "ExportClaims", mock.Anything, mock.Anything)
+}
+
+func TestCreateCoupon_UniqueCodes(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	req := &models.CreateCouponRequest{
+		Name:        "FLASH25",
+		Amount:      3,
+		UniqueCodes: true,
+	}
+
+	coupon := &models.Coupon{Name: "FLASH25", Amount: 3, RemainingAmount: 3, UniqueCodes: true}
+	mockRepo.On("CreateCouponWithCodes", coupon, mock.MatchedBy(func(codes []string) bool {
+		if len(codes) != 3 {
+			return false
+		}
+		for _, code := range codes {
+			if !strings.HasPrefix(code, "FLASH25-") {
+				return false
+			}
+		}
+		return codes[0] != codes[1] && codes[1] != codes[2] && codes[0] != codes[2]
+	})).Return(nil)
+
+	err := service.CreateCoupon(context.Background(), req)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateCoupon", mock.Anything)
+}
+
+func TestCreateCoupon_UniqueCodesAmountTooLarge(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	req := &models.CreateCouponRequest{
+		Name:        "FLASH25",
+		Amount:      maxUniqueCodes + 1,
+		UniqueCodes: true,
+	}
+
+	err := service.CreateCoupon(context.Background(), req)
+	assert.Error(t, err)
+	assert.Equal(t, "amount must be at most 100000 when unique_codes is enabled", err.Error())
+}
+
+func TestClaimCoupon_ReturnsAssignedCode(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	req := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+	}
+
+	mockRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{}, nil)
+	mockRepo.On("ClaimCoupon", "user1", "FLASH25").Return(&models.Claim{ID: 1, Code: "FLASH25-7KQ2-M9XD"}, nil)
+
+	claim, err := service.ClaimCoupon(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "FLASH25-7KQ2-M9XD", claim.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLookupCode_Success(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	code, _ := couponcode.Generate("FLASH25")
+	expected := &models.CodeLookupResponse{Code: code}
+	mockRepo.On("GetCodeDetails", code).Return(expected, nil)
+
+	result, err := service.LookupCode(context.Background(), " "+code[:8]+strings.ToLower(code[8:]))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLookupCode_MalformedCode(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	result, err := service.LookupCode(context.Background(), "")
+	assert.Nil(t, result)
+	assert.Equal(t, couponcode.ErrInvalidFormat, err)
+	mockRepo.AssertNotCalled(t, "GetCodeDetails", mock.Anything)
+}
+
+func TestRedeemCode_Success(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	code, _ := couponcode.Generate("FLASH25")
+	expected := &models.CodeRedemption{Code: code, CouponName: "FLASH25", UserID: "user1"}
+	mockRepo.On("RedeemCode", code).Return(expected, nil)
+
+	result, err := service.RedeemCode(context.Background(), code)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRedeemCode_ChecksumMismatch(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	code, _ := couponcode.Generate("FLASH25")
+	last := code[len(code)-1]
+	typo := byte('2')
+	if last == typo {
+		typo = '3'
+	}
+
+	result, err := service.RedeemCode(context.Background(), code[:len(code)-1]+string(typo))
+	assert.Nil(t, result)
+	assert.Equal(t, couponcode.ErrInvalidChecksum, err)
+	mockRepo.AssertNotCalled(t, "RedeemCode", mock.Anything)
+}
+
+func TestRedeemCode_RepositoryError(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	code, _ := couponcode.Generate("FLASH25")
+	mockRepo.On("RedeemCode", code).Return(nil, repository.ErrCodeAlreadyRedeemed)
+
+	result, err := service.RedeemCode(context.Background(), code)
+	assert.Nil(t, result)
+	assert.Equal(t, repository.ErrCodeAlreadyRedeemed, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateCoupon_ExpiresInPast(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	past := time.Now().Add(-time.Hour)
+	req := &models.CreateCouponRequest{
+		Name:      "FLASH25",
+		Amount:    10,
+		ExpiresAt: &past,
+	}
+
+	err := service.CreateCoupon(context.Background(), req)
+	assert.Error(t, err)
+	assert.Equal(t, "expires_at must be in the future", err.Error())
+}
+
+func TestClaimCoupon_NotEligible(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	req := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+		Attributes: map[string]interface{}{"is_new_user": false},
+	}
+
+	rules := models.EligibilityRules{
+		{Name: "new_users_only", Attribute: "is_new_user", Operator: models.OperatorEq, Value: true},
+	}
+	mockRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{EligibilityRules: rules}, nil)
+
+	claim, err := service.ClaimCoupon(context.Background(), req)
+	assert.Nil(t, claim)
+	assert.True(t, errors.Is(err, ErrNotEligible))
+	assert.Equal(t, `user is not eligible for this coupon: rule "new_users_only" failed`, err.Error())
+	mockRepo.AssertNotCalled(t, "ClaimCoupon", mock.Anything, mock.Anything)
+}
+
+func TestClaimCoupon_IgnoresClientAttributesForTokenCallers(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	rules := models.EligibilityRules{
+		{Name: "gold_only", Attribute: "tier", Operator: models.OperatorEq, Value: "gold"},
+	}
+	mockRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{EligibilityRules: rules}, nil)
+	mockRepo.On("ClaimCoupon", "gold-user", "FLASH25").Return(&models.Claim{ID: 1, UserID: "gold-user", CouponName: "FLASH25"}, nil)
+
+	// claim authorizes req for a token caller, as every transport does, then claims
+	claim := func(identity *middleware.Identity, attributes map[string]interface{}) (*models.Claim, error) {
+		ctx := middleware.WithIdentity(context.Background(), identity)
+		req := &models.ClaimCouponRequest{CouponName: "FLASH25", Attributes: attributes}
+		if err := middleware.AuthorizeClaim(ctx, req); err != nil {
+			return nil, err
+		}
+		return service.ClaimCoupon(ctx, req)
+	}
+
+	silver := &middleware.Identity{Subject: "silver-user", Attributes: map[string]interface{}{"tier": "silver"}}
+	_, err := claim(silver, map[string]interface{}{"tier": "gold"})
+	assert.Equal(t, middleware.ErrAttributesNotAllowed, err)
+
+	_, err = claim(silver, nil)
+	assert.True(t, errors.Is(err, ErrNotEligible))
+
+	gold := &middleware.Identity{Subject: "gold-user", Attributes: map[string]interface{}{"tier": "gold"}}
+	result, err := claim(gold, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "gold-user", result.UserID)
+
+	mockRepo.AssertNumberOfCalls(t, "ClaimCoupon", 1)
+}
+
+func TestClaimCoupon_QueueMode(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	req := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+	}
+
+	mockRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{QueueMode: true}, nil)
+
+	claim, err := service.ClaimCoupon(context.Background(), req)
+	assert.Nil(t, claim)
+	assert.Equal(t, ErrQueueRequired, err)
+	mockRepo.AssertNotCalled(t, "ClaimCoupon", mock.Anything, mock.Anything)
+}
+
+func TestClaimCoupon_PolicyLookupCouponNotFound(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	req := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "NONEXISTENT",
+	}
+
+	mockRepo.On("GetClaimPolicy", "NONEXISTENT").Return(nil, repository.ErrCouponNotFound)
+
+	_, err := service.ClaimCoupon(context.Background(), req)
+	assert.Equal(t, repository.ErrCouponNotFound, err)
+	mockRepo.AssertNotCalled(t, "ClaimCoupon", mock.Anything, mock.Anything)
+}
+
+func TestCreateCoupon_InvalidEligibilityRules(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	req := &models.CreateCouponRequest{
+		Name:   "FLASH25",
+		Amount: 10,
+		EligibilityRules: models.EligibilityRules{
+			{Name: "tier", Attribute: "tier", Operator: "approx", Value: "gold"},
+		},
+	}
+
+	err := service.CreateCoupon(context.Background(), req)
+	assert.EqualError(t, err, `eligibility_rules[0].operator "approx" is not supported`)
+
+	var violations validation.Errors
+	assert.True(t, errors.As(err, &violations))
+	assert.Equal(t, "eligibility_rules[0].operator", violations[0].Field)
+	mockRepo.AssertNotCalled(t, "CreateCoupon", mock.Anything)
+}
+
+func TestClaimCoupon_LotteryRecordsEntry(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	req := &models.ClaimCouponRequest{
+		UserID:     "user1",
+		CouponName: "FLASH25",
+	}
+
+	closesAt := time.Now().Add(time.Hour)
+	entry := &models.Claim{ID: 1, UserID: "user1", CouponName: "FLASH25", Entry: true}
+	mockRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{LotteryClosesAt: &closesAt}, nil)
+	mockRepo.On("EnterLottery", "user1", "FLASH25").Return(entry, nil)
+
+	claim, err := service.ClaimCoupon(context.Background(), req)
+	assert.NoError(t, err)
+	assert.True(t, claim.Entry)
+	mockRepo.AssertNotCalled(t, "ClaimCoupon", mock.Anything, mock.Anything)
+}
+
+func TestCreateCoupon_LotteryValidation(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	closesAt := time.Now().Add(time.Hour)
+	expiresAt := time.Now().Add(30 * time.Minute)
+	seedHash := lottery.Commit("seed")
+
+	tests := []struct {
+		name string
+		req  *models.CreateCouponRequest
+		want string
+	}{
+		{
+			name: "closes in past",
+			req:  &models.CreateCouponRequest{Name: "FLASH25", Amount: 10, LotteryClosesAt: &past, LotterySeedHash: seedHash},
+			want: "lottery_closes_at must be in the future",
+		},
+		{
+			name: "combined with queue mode",
+			req:  &models.CreateCouponRequest{Name: "FLASH25", Amount: 10, LotteryClosesAt: &closesAt, LotterySeedHash: seedHash, QueueMode: true},
+			want: "queue_mode cannot be combined with a lottery",
+		},
+		{
+			name: "expires before draw",
+			req:  &models.CreateCouponRequest{Name: "FLASH25", Amount: 10, LotteryClosesAt: &closesAt, LotterySeedHash: seedHash, ExpiresAt: &expiresAt},
+			want: "expires_at must be after lottery_closes_at",
+		},
+		{
+			name: "no seed hash",
+			req:  &models.CreateCouponRequest{Name: "FLASH25", Amount: 10, LotteryClosesAt: &closesAt},
+			want: "lottery_seed_hash must be the hex SHA-256 hash of the draw seed",
+		},
+		{
+			name: "seed hash not hex",
+			req:  &models.CreateCouponRequest{Name: "FLASH25", Amount: 10, LotteryClosesAt: &closesAt, LotterySeedHash: "seed"},
+			want: "lottery_seed_hash must be the hex SHA-256 hash of the draw seed",
+		},
+		{
+			name: "seed hash without lottery",
+			req:  &models.CreateCouponRequest{Name: "FLASH25", Amount: 10, LotterySeedHash: seedHash},
+			want: "lottery_seed_hash requires lottery_closes_at",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCouponRepository)
+			service := NewCouponService(mockRepo)
+
+			err := service.CreateCoupon(context.Background(), tt.req)
+			assert.EqualError(t, err, tt.want)
+			mockRepo.AssertNotCalled(t, "CreateCoupon", mock.Anything)
+		})
+	}
+}
+
+func TestCreateCoupon_LotterySeedHash(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	closesAt := time.Now().Add(time.Hour)
+	seedHash := lottery.Commit("seed")
+	req := &models.CreateCouponRequest{Name: "FLASH25", Amount: 10, LotteryClosesAt: &closesAt, LotterySeedHash: strings.ToUpper(seedHash)}
+
+	mockRepo.On("CreateCoupon", mock.MatchedBy(func(c *models.Coupon) bool {
+		return c.LotterySeedHash == seedHash
+	})).Return(nil)
+
+	err := service.CreateCoupon(context.Background(), req)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListCoupons_HasNextPage(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	mockRepo.On("ListCoupons", models.Page{AfterID: 1, Limit: 3}).
+		Return([]models.Coupon{{ID: 2}, {ID: 3}, {ID: 4}}, nil)
+
+	page, err := service.ListCoupons(context.Background(), models.Page{AfterID: 1, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Coupon{{ID: 2}, {ID: 3}}, page.Coupons)
+	assert.True(t, page.HasNextPage)
+}
+
+func TestListCoupons_LastPage(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	mockRepo.On("ListCoupons", models.Page{Limit: 3}).Return([]models.Coupon{{ID: 1}}, nil)
+
+	page, err := service.ListCoupons(context.Background(), models.Page{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page.Coupons, 1)
+	assert.False(t, page.HasNextPage)
+}
+
+func TestListCoupons_InvalidPage(t *testing.T) {
+	service := NewCouponService(new(MockCouponRepository))
+
+	_, err := service.ListCoupons(context.Background(), models.Page{Limit: 0})
+	assert.EqualError(t, err, "limit must be between 1 and 100")
+
+	_, err = service.ListCoupons(context.Background(), models.Page{Limit: MaxPageSize + 1})
+	assert.EqualError(t, err, "limit must be between 1 and 100")
+
+	_, err = service.ListCoupons(context.Background(), models.Page{AfterID: -1, Limit: 1})
+	assert.EqualError(t, err, "after must not be negative")
+}
+
+func TestGetCouponsByNames(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	mockRepo.On("GetCouponsByNames", []string{"FLASH25", "MISSING"}).
+		Return([]models.Coupon{{ID: 1, Name: "FLASH25"}}, nil)
+
+	coupons, err := service.GetCouponsByNames(context.Background(), []string{"FLASH25", "MISSING"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), coupons["FLASH25"].ID)
+	assert.NotContains(t, coupons, "MISSING")
+}
+
+func TestListClaimsByCoupons_PagesEachCoupon(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	mockRepo.On("ListClaimsByCoupons", []string{"FLASH25", "PROMO", "EMPTY"}, models.Page{Limit: 3}).
+		Return([]models.Claim{
+			{ID: 1, CouponName: "FLASH25"},
+			{ID: 2, CouponName: "FLASH25"},
+			{ID: 5, CouponName: "FLASH25"},
+			{ID: 3, CouponName: "PROMO"},
+		}, nil)
+
+	pages, err := service.ListClaimsByCoupons(context.Background(), []string{"FLASH25", "PROMO", "EMPTY"}, models.Page{Limit: 2})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []models.Claim{{ID: 1, CouponName: "FLASH25"}, {ID: 2, CouponName: "FLASH25"}}, pages["FLASH25"].Claims)
+	assert.True(t, pages["FLASH25"].HasNextPage)
+	assert.Len(t, pages["PROMO"].Claims, 1)
+	assert.False(t, pages["PROMO"].HasNextPage)
+	assert.Empty(t, pages["EMPTY"].Claims)
+	mockRepo.AssertNumberOfCalls(t, "ListClaimsByCoupons", 1)
+}
+
+func TestListClaimsByUsers(t *testing.T) {
+	mockRepo := new(MockCouponRepository)
+	service := NewCouponService(mockRepo)
+
+	mockRepo.On("ListClaimsByUsers", []string{"user1"}, models.Page{AfterID: 4, Limit: 11}).
+		Return(nil, errors.New("error listing claims: timeout"))
+
+	_, err := service.ListClaimsByUsers(context.Background(), []string{"user1"}, models.Page{AfterID: 4, Limit: 10})
+	assert.EqualError(t, err, "error listing claims: timeout")
+}
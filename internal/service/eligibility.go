@@ -0,0 +1,204 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/pkg/validation"
+)
+
+// ErrNotEligible is matched by every EligibilityError through errors.Is
+var ErrNotEligible = errors.New("user is not eligible for this coupon")
+
+// EligibilityError reports the first eligibility rule a claim failed
+type EligibilityError struct {
+	Rule string
+}
+
+func (e *EligibilityError) Error() string {
+	return fmt.Sprintf("%s: rule %q failed", ErrNotEligible.Error(), e.Rule)
+}
+
+// Is lets callers match any EligibilityError with errors.Is(err, ErrNotEligible)
+func (e *EligibilityError) Is(target error) bool {
+	return target == ErrNotEligible
+}
+
+// evaluateEligibility checks attributes against every rule in order and returns an
+// EligibilityError naming the first rule that is not satisfied. A missing attribute fails the rule.
+// It does not know where attributes came from; callers must pass only trusted attributes.
+func evaluateEligibility(rules models.EligibilityRules, attributes map[string]interface{}) error {
+	for _, rule := range rules {
+		actual, ok := attributes[rule.Attribute]
+		if !ok || !ruleMatches(rule, actual) {
+			return &EligibilityError{Rule: rule.Name}
+		}
+	}
+
+	return nil
+}
+
+func ruleMatches(rule models.EligibilityRule, actual interface{}) bool {
+	switch rule.Operator {
+	case models.OperatorEq:
+		return valuesEqual(actual, rule.Value)
+	case models.OperatorNeq:
+		return !valuesEqual(actual, rule.Value)
+	case models.OperatorIn:
+		return valueIn(actual, rule.Value)
+	case models.OperatorNotIn:
+		return !valueIn(actual, rule.Value)
+	case models.OperatorGt, models.OperatorGte, models.OperatorLt, models.OperatorLte:
+		cmp, ok := compareValues(rule, actual)
+		if !ok {
+			return false
+		}
+		switch rule.Operator {
+		case models.OperatorGt:
+			return cmp > 0
+		case models.OperatorGte:
+			return cmp >= 0
+		case models.OperatorLt:
+			return cmp < 0
+		default:
+			return cmp <= 0
+		}
+	default:
+		return false
+	}
+}
+
+// valuesEqual compares JSON-decoded values, treating all numbers as float64
+func valuesEqual(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv
+	default:
+		return false
+	}
+}
+
+func valueIn(actual, list interface{}) bool {
+	values, ok := list.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, v := range values {
+		if valuesEqual(actual, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareValues returns -1, 0 or 1 comparing actual to the rule value, either
+// numerically or by rank in rule.Order. ok is false if the values cannot be compared.
+func compareValues(rule models.EligibilityRule, actual interface{}) (cmp int, ok bool) {
+	var a, b float64
+	if len(rule.Order) > 0 {
+		as, aok := actual.(string)
+		bs, bok := rule.Value.(string)
+		if !aok || !bok {
+			return 0, false
+		}
+		ai, bi := indexOf(rule.Order, as), indexOf(rule.Order, bs)
+		if ai < 0 || bi < 0 {
+			return 0, false
+		}
+		a, b = float64(ai), float64(bi)
+	} else {
+		var aok, bok bool
+		a, aok = toFloat(actual)
+		b, bok = toFloat(rule.Value)
+		if !aok || !bok {
+			return 0, false
+		}
+	}
+
+	switch {
+	case a < b:
+		return -1, true
+	case a > b:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+// validateEligibilityRules adds the rules that could never be evaluated meaningfully to errs.
+// Required fields are checked by the validate tags of EligibilityRule.
+func validateEligibilityRules(errs *validation.Errors, rules models.EligibilityRules) {
+	seen := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		field := fmt.Sprintf("eligibility_rules[%d]", i)
+		if rule.Name != "" && seen[rule.Name] {
+			errs.Add(field+".name", "%s.name %q is used by another rule", field, rule.Name)
+		}
+		seen[rule.Name] = true
+
+		switch rule.Operator {
+		case models.OperatorEq, models.OperatorNeq:
+			if !isScalar(rule.Value) {
+				errs.Add(field+".value", "%s.value must be a string, number or boolean", field)
+			}
+		case models.OperatorIn, models.OperatorNotIn:
+			if _, ok := rule.Value.([]interface{}); !ok {
+				errs.Add(field+".value", "%s.value must be a list for operator %q", field, rule.Operator)
+			}
+		case models.OperatorGt, models.OperatorGte, models.OperatorLt, models.OperatorLte:
+			if len(rule.Order) > 0 {
+				s, ok := rule.Value.(string)
+				if !ok || indexOf(rule.Order, s) < 0 {
+					errs.Add(field+".value", "%s.value must be one of order", field)
+				}
+			} else if _, ok := toFloat(rule.Value); !ok {
+				errs.Add(field+".value", "%s.value must be a number or order must be set", field)
+			}
+		default:
+			errs.Add(field+".operator", "%s.operator %q is not supported", field, rule.Operator)
+		}
+	}
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case string, bool:
+		return true
+	default:
+		_, ok := toFloat(v)
+		return ok
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+func indexOf(values []string, s string) int {
+	for i, v := range values {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+// decodeRules builds rules from JSON so values have the same types as in a real request
+func decodeRules(t *testing.T, raw string) models.EligibilityRules {
+	var rules models.EligibilityRules
+	assert.NoError(t, json.Unmarshal([]byte(raw), &rules))
+	return rules
+}
+
+func decodeAttributes(t *testing.T, raw string) map[string]interface{} {
+	var attributes map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(raw), &attributes))
+	return attributes
+}
+
+func TestEvaluateEligibility(t *testing.T) {
+	rules := decodeRules(t, `[
+		{"name": "new_users_only", "attribute": "is_new_user", "operator": "eq", "value": true},
+		{"name": "region_id", "attribute": "region", "operator": "in", "value": ["ID", "SG"]},
+		{"name": "gold_or_above", "attribute": "tier", "operator": "gte", "value": "gold",
+		 "order": ["bronze", "silver", "gold", "platinum"]},
+		{"name": "adult", "attribute": "age", "operator": "gte", "value": 18}
+	]`)
+
+	tests := []struct {
+		name         string
+		attributes   string
+		expectedRule string
+	}{
+		{"all rules pass", `{"is_new_user": true, "region": "ID", "tier": "platinum", "age": 30}`, ""},
+		{"existing user", `{"is_new_user": false, "region": "ID", "tier": "gold", "age": 30}`, "new_users_only"},
+		{"wrong region", `{"is_new_user": true, "region": "US", "tier": "gold", "age": 30}`, "region_id"},
+		{"tier too low", `{"is_new_user": true, "region": "SG", "tier": "silver", "age": 30}`, "gold_or_above"},
+		{"unknown tier", `{"is_new_user": true, "region": "SG", "tier": "diamond", "age": 30}`, "gold_or_above"},
+		{"too young", `{"is_new_user": true, "region": "SG", "tier": "gold", "age": 17}`, "adult"},
+		{"missing attribute", `{"region": "ID", "tier": "gold", "age": 30}`, "new_users_only"},
+		{"wrong type", `{"is_new_user": "yes", "region": "ID", "tier": "gold", "age": 30}`, "new_users_only"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := evaluateEligibility(rules, decodeAttributes(t, tt.attributes))
+			if tt.expectedRule == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var eligibilityErr *EligibilityError
+			assert.True(t, errors.As(err, &eligibilityErr))
+			assert.Equal(t, tt.expectedRule, eligibilityErr.Rule)
+			assert.True(t, errors.Is(err, ErrNotEligible))
+		})
+	}
+}
+
+func TestEvaluateEligibility_NoRules(t *testing.T) {
+	assert.NoError(t, evaluateEligibility(nil, nil))
+}
+
+func TestEvaluateEligibility_NegatedOperators(t *testing.T) {
+	rules := decodeRules(t, `[
+		{"name": "not_staff", "attribute": "role", "operator": "neq", "value": "staff"},
+		{"name": "not_blocked_region", "attribute": "region", "operator": "not_in", "value": ["XX"]},
+		{"name": "under_limit", "attribute": "orders", "operator": "lt", "value": 3}
+	]`)
+
+	assert.NoError(t, evaluateEligibility(rules, decodeAttributes(t, `{"role": "customer", "region": "ID", "orders": 2}`)))
+	assert.EqualError(t, evaluateEligibility(rules, decodeAttributes(t, `{"role": "staff", "region": "ID", "orders": 2}`)),
+		`user is not eligible for this coupon: rule "not_staff" failed`)
+	assert.EqualError(t, evaluateEligibility(rules, decodeAttributes(t, `{"role": "customer", "region": "ID", "orders": 3}`)),
+		`user is not eligible for this coupon: rule "under_limit" failed`)
+}
+
+func TestValidateEligibilityRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    string
+		expected string
+	}{
+		{"valid", `[{"name": "a", "attribute": "x", "operator": "eq", "value": 1}]`, ""},
+		{"missing name", `[{"attribute": "x", "operator": "eq", "value": 1}]`, "eligibility_rules[0].name is required"},
+		{"duplicate name", `[{"name": "a", "attribute": "x", "operator": "eq", "value": 1},
+			{"name": "a", "attribute": "y", "operator": "eq", "value": 1}]`, `eligibility_rules[1].name "a" is used by another rule`},
+		{"missing attribute", `[{"name": "a", "operator": "eq", "value": 1}]`, "eligibility_rules[0].attribute is required"},
+		{"unknown operator", `[{"name": "a", "attribute": "x", "operator": "like", "value": 1}]`, `eligibility_rules[0].operator "like" is not supported`},
+		{"eq with list", `[{"name": "a", "attribute": "x", "operator": "eq", "value": [1]}]`, "eligibility_rules[0].value must be a string, number or boolean"},
+		{"in without list", `[{"name": "a", "attribute": "x", "operator": "in", "value": "ID"}]`, `eligibility_rules[0].value must be a list for operator "in"`},
+		{"gte with string and no order", `[{"name": "a", "attribute": "x", "operator": "gte", "value": "gold"}]`, "eligibility_rules[0].value must be a number or order must be set"},
+		{"gte value outside order", `[{"name": "a", "attribute": "x", "operator": "gte", "value": "gold", "order": ["silver"]}]`, "eligibility_rules[0].value must be one of order"},
+		{"every rule reported", `[{"attribute": "x", "operator": "eq", "value": 1},
+			{"name": "b", "operator": "like"}]`, `eligibility_rules[0].name is required; eligibility_rules[1].attribute is required; eligibility_rules[1].operator "like" is not supported`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.CreateCouponRequest{Name: "FLASH25", Amount: 1, EligibilityRules: decodeRules(t, tt.rules)}
+			err := validateCreateCouponRequest(req)
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
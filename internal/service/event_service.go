@@ -0,0 +1,174 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// EventRelayConfig controls how the coupon_events outbox is relayed to the events webhook
+type EventRelayConfig struct {
+	// WebhookURL receives the events; the relay does not run when it is empty
+	WebhookURL   string        `yaml:"webhook_url"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+	// Timeout bounds each webhook request
+	Timeout time.Duration `yaml:"webhook_timeout"`
+}
+
+// DefaultEventRelayConfig relays up to 100 events every 5s once a webhook is set
+func DefaultEventRelayConfig() *EventRelayConfig {
+	return &EventRelayConfig{
+		PollInterval: 5 * time.Second,
+		BatchSize:    100,
+		Timeout:      10 * time.Second,
+	}
+}
+
+// LoadEnv overrides the settings whose EVENTS_* environment variable is set
+func (c *EventRelayConfig) LoadEnv() error {
+	if url := os.Getenv("EVENTS_WEBHOOK_URL"); url != "" {
+		c.WebhookURL = url
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"EVENTS_POLL_INTERVAL", &c.PollInterval},
+		{"EVENTS_WEBHOOK_TIMEOUT", &c.Timeout},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q", setting.name, value)
+			}
+			*setting.value = d
+		}
+	}
+
+	if size := os.Getenv("EVENTS_BATCH_SIZE"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			return fmt.Errorf("invalid EVENTS_BATCH_SIZE: %q", size)
+		}
+		c.BatchSize = n
+	}
+
+	return nil
+}
+
+// Validate checks the relay can run with the config
+func (c *EventRelayConfig) Validate() error {
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("poll_interval must be positive, got %s", c.PollInterval)
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("batch_size must be positive, got %d", c.BatchSize)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("webhook_timeout must be positive, got %s", c.Timeout)
+	}
+	return nil
+}
+
+// EventBatch is the body posted to the events webhook
+type EventBatch struct {
+	Events []models.CouponEvent `json:"events"`
+}
+
+// EventService defines the interface for relaying the coupon_events outbox
+type EventService interface {
+	RelayNext(ctx context.Context, config EventRelayConfig) (relayed int, err error)
+	Run(ctx context.Context, config EventRelayConfig)
+}
+
+// eventService posts outbox events to the events webhook
+type eventService struct {
+	repo   repository.EventRepository
+	client *http.Client
+}
+
+// NewEventService creates a new EventService with injected repository and HTTP client
+func NewEventService(repo repository.EventRepository, client *http.Client) EventService {
+	return &eventService{
+		repo:   repo,
+		client: client,
+	}
+}
+
+// RelayNext posts the oldest undelivered events to config.WebhookURL in one batch and marks
+// them delivered once the webhook answers with a 2xx status. Events are delivered at least
+// once: should marking them fail after the webhook took them, they are posted again.
+func (s *eventService) RelayNext(ctx context.Context, config EventRelayConfig) (int, error) {
+	ctx, span := tracer.Start(ctx, "EventService.RelayNext")
+	defer span.End()
+
+	return s.repo.Relay(ctx, config.BatchSize, func(events []models.CouponEvent) error {
+		return s.post(ctx, config, events)
+	})
+}
+
+// post sends one batch of events to the webhook
+func (s *eventService) post(ctx context.Context, config EventRelayConfig, events []models.CouponEvent) error {
+	body, err := json.Marshal(EventBatch{Events: events})
+	if err != nil {
+		return fmt.Errorf("error encoding events: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting events: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("events webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Run relays the outbox until ctx is cancelled, waiting config.PollInterval once it has been
+// emptied or whenever a batch could not be delivered
+func (s *eventService) Run(ctx context.Context, config EventRelayConfig) {
+	for {
+		relayed, err := s.RelayNext(ctx, config)
+		if err != nil && ctx.Err() == nil {
+			logger.Log.Error("Event relay error", zap.Error(err))
+		}
+
+		if relayed == config.BatchSize && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(config.PollInterval):
+		}
+	}
+}
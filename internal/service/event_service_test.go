@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/models"
+)
+
+// MockEventRepository is a mock implementation of EventRepository. Relay hands the events
+// the test returns to deliver, as the repository does while holding them.
+type MockEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockEventRepository) Relay(ctx context.Context, limit int, deliver func(events []models.CouponEvent) error) (int, error) {
+	args := m.Called(limit)
+	events := args.Get(0).([]models.CouponEvent)
+	if len(events) == 0 {
+		return 0, nil
+	}
+	if err := deliver(events); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+func testEvents() []models.CouponEvent {
+	return []models.CouponEvent{
+		{ID: 1, Type: models.EventLotteryLost, CouponName: "FLASH25", UserID: "bob", Payload: json.RawMessage(`{"entries":3}`)},
+		{ID: 2, Type: models.EventLotteryLost, CouponName: "FLASH25", UserID: "dave", Payload: json.RawMessage(`{"entries":3}`)},
+	}
+}
+
+func TestRelayNext_Delivered(t *testing.T) {
+	var batch EventBatch
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer webhook.Close()
+
+	mockRepo := new(MockEventRepository)
+	service := NewEventService(mockRepo, webhook.Client())
+	mockRepo.On("Relay", 100).Return(testEvents())
+
+	relayed, err := service.RelayNext(context.Background(), EventRelayConfig{WebhookURL: webhook.URL, BatchSize: 100, Timeout: time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, relayed)
+	assert.Len(t, batch.Events, 2)
+	assert.Equal(t, "bob", batch.Events[0].UserID)
+	assert.JSONEq(t, `{"entries":3}`, string(batch.Events[0].Payload))
+}
+
+func TestRelayNext_WebhookFailed(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer webhook.Close()
+
+	mockRepo := new(MockEventRepository)
+	service := NewEventService(mockRepo, webhook.Client())
+	mockRepo.On("Relay", 100).Return(testEvents())
+
+	relayed, err := service.RelayNext(context.Background(), EventRelayConfig{WebhookURL: webhook.URL, BatchSize: 100, Timeout: time.Second})
+	assert.EqualError(t, err, "events webhook returned 503 Service Unavailable")
+	assert.Equal(t, 0, relayed)
+}
+
+func TestEventRelayConfig_LoadEnv(t *testing.T) {
+	load := func() (*EventRelayConfig, error) {
+		config := DefaultEventRelayConfig()
+		if err := config.LoadEnv(); err != nil {
+			return nil, err
+		}
+		return config, config.Validate()
+	}
+
+	t.Setenv("EVENTS_WEBHOOK_URL", "")
+	t.Setenv("EVENTS_POLL_INTERVAL", "")
+	t.Setenv("EVENTS_BATCH_SIZE", "")
+	t.Setenv("EVENTS_WEBHOOK_TIMEOUT", "")
+	config, err := load()
+	assert.NoError(t, err)
+	assert.Equal(t, &EventRelayConfig{PollInterval: 5 * time.Second, BatchSize: 100, Timeout: 10 * time.Second}, config)
+
+	t.Setenv("EVENTS_WEBHOOK_URL", "https://events.example.com/coupons")
+	t.Setenv("EVENTS_POLL_INTERVAL", "1s")
+	t.Setenv("EVENTS_BATCH_SIZE", "10")
+	t.Setenv("EVENTS_WEBHOOK_TIMEOUT", "3s")
+	config, err = load()
+	assert.NoError(t, err)
+	assert.Equal(t, &EventRelayConfig{WebhookURL: "https://events.example.com/coupons", PollInterval: time.Second, BatchSize: 10, Timeout: 3 * time.Second}, config)
+
+	t.Setenv("EVENTS_BATCH_SIZE", "0")
+	_, err = load()
+	assert.EqualError(t, err, "batch_size must be positive, got 0")
+
+	t.Setenv("EVENTS_BATCH_SIZE", "lots")
+	_, err = load()
+	assert.EqualError(t, err, `invalid EVENTS_BATCH_SIZE: "lots"`)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/lottery"
+	"github.com/wazadio/coupon-system/pkg/validation"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotLottery   = errors.New("coupon is not a lottery")
+	ErrEntriesOpen  = errors.New("lottery entries are still open")
+	ErrSeedMismatch = errors.New("seed does not match the lottery seed hash")
+)
+
+// LotteryService defines the interface for drawing lottery coupons
+type LotteryService interface {
+	DrawLottery(ctx context.Context, couponName string, req *models.DrawLotteryRequest) (*models.LotteryDraw, error)
+	GetDraw(ctx context.Context, couponName string) (*models.LotteryDraw, error)
+}
+
+// lotteryService handles business logic for lottery draws
+type lotteryService struct {
+	repo repository.LotteryRepository
+}
+
+// NewLotteryService creates a new LotteryService with injected repository
+func NewLotteryService(repo repository.LotteryRepository) LotteryService {
+	return &lotteryService{
+		repo: repo,
+	}
+}
+
+// DrawLottery picks as many winners as the coupon has stock once its entries have closed.
+// req.Seed must be the seed the coupon's lottery_seed_hash committed to when it was created,
+// so the seed cannot be chosen once the entrants are known. The seed and the entrants are
+// stored with the draw so the result can be reproduced with pkg/lottery.
+func (s *lotteryService) DrawLottery(ctx context.Context, couponName string, req *models.DrawLotteryRequest) (*models.LotteryDraw, error) {
+	if couponName == "" {
+		return nil, validation.Invalid("name", "name is required")
+	}
+	if err := validation.Struct(req).Err(); err != nil {
+		return nil, err
+	}
+
+	draw, err := s.repo.Draw(ctx, couponName, func(entrants *models.LotteryEntrants) (*models.LotteryDraw, []string, error) {
+		if entrants.LotteryClosesAt == nil {
+			return nil, nil, ErrNotLottery
+		}
+		if time.Now().Before(*entrants.LotteryClosesAt) {
+			return nil, nil, ErrEntriesOpen
+		}
+		if entrants.Drawn {
+			return nil, nil, repository.ErrLotteryAlreadyDrawn
+		}
+		if !lottery.Verify(req.Seed, entrants.SeedHash) {
+			return nil, nil, ErrSeedMismatch
+		}
+
+		winners, losers := lottery.Draw(req.Seed, entrants.UserIDs, entrants.RemainingAmount)
+		return &models.LotteryDraw{
+			CouponName: couponName,
+			Seed:       req.Seed,
+			SeedHash:   entrants.SeedHash,
+			Entries:    len(entrants.UserIDs),
+			Entrants:   entrants.UserIDs,
+			Winners:    winners,
+		}, losers, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Info("Lottery drawn",
+		zap.String("coupon_name", couponName),
+		zap.String("seed", draw.Seed),
+		zap.Int("entries", draw.Entries),
+		zap.Int("winners", len(draw.Winners)),
+	)
+
+	return draw, nil
+}
+
+// GetDraw returns the recorded draw of a lottery coupon
+func (s *lotteryService) GetDraw(ctx context.Context, couponName string) (*models.LotteryDraw, error) {
+	if couponName == "" {
+		return nil, validation.Invalid("name", "name is required")
+	}
+
+	return s.repo.GetDraw(ctx, couponName)
+}
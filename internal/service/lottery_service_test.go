@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/lottery"
+)
+
+// MockLotteryRepository is a mock implementation of LotteryRepository. Draw runs pick over
+// the entrants the test returns, as the repository does in its transaction, and keeps the
+// losers it was given.
+type MockLotteryRepository struct {
+	mock.Mock
+	losers []string
+}
+
+func (m *MockLotteryRepository) Draw(ctx context.Context, couponName string, pick repository.DrawFunc) (*models.LotteryDraw, error) {
+	args := m.Called(couponName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	draw, losers, err := pick(args.Get(0).(*models.LotteryEntrants))
+	if err != nil {
+		return nil, err
+	}
+	m.losers = losers
+	return draw, nil
+}
+
+func (m *MockLotteryRepository) GetDraw(ctx context.Context, couponName string) (*models.LotteryDraw, error) {
+	args := m.Called(couponName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LotteryDraw), args.Error(1)
+}
+
+func closedEntrants() *models.LotteryEntrants {
+	closedAt := time.Now().Add(-time.Minute)
+	return &models.LotteryEntrants{
+		CouponName:      "FLASH25",
+		RemainingAmount: 2,
+		LotteryClosesAt: &closedAt,
+		SeedHash:        lottery.Commit("seed"),
+		UserIDs:         []string{"alice", "bob", "carol", "dave"},
+	}
+}
+
+func TestDrawLottery_Success(t *testing.T) {
+	logger.Init()
+	mockRepo := new(MockLotteryRepository)
+	service := NewLotteryService(mockRepo)
+
+	winners, losers := lottery.Draw("seed", []string{"alice", "bob", "carol", "dave"}, 2)
+	mockRepo.On("Draw", "FLASH25").Return(closedEntrants(), nil)
+
+	draw, err := service.DrawLottery(context.Background(), "FLASH25", &models.DrawLotteryRequest{Seed: "seed"})
+	assert.NoError(t, err)
+	assert.Equal(t, "seed", draw.Seed)
+	assert.Equal(t, lottery.Commit("seed"), draw.SeedHash)
+	assert.Equal(t, 4, draw.Entries)
+	assert.Equal(t, []string{"alice", "bob", "carol", "dave"}, draw.Entrants)
+	assert.Equal(t, winners, draw.Winners)
+	assert.Equal(t, losers, mockRepo.losers)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDrawLottery_SeedRequired(t *testing.T) {
+	mockRepo := new(MockLotteryRepository)
+	service := NewLotteryService(mockRepo)
+
+	_, err := service.DrawLottery(context.Background(), "FLASH25", &models.DrawLotteryRequest{})
+	assert.EqualError(t, err, "seed is required")
+	mockRepo.AssertNotCalled(t, "Draw", mock.Anything)
+}
+
+func TestDrawLottery_Rejected(t *testing.T) {
+	open := time.Now().Add(time.Hour)
+	drawn := closedEntrants()
+	drawn.Drawn = true
+	uncommitted := closedEntrants()
+	uncommitted.SeedHash = ""
+
+	tests := []struct {
+		name     string
+		entrants *models.LotteryEntrants
+		seed     string
+		want     error
+	}{
+		{"not a lottery", &models.LotteryEntrants{CouponName: "FLASH25"}, "seed", ErrNotLottery},
+		{"entries open", &models.LotteryEntrants{CouponName: "FLASH25", LotteryClosesAt: &open}, "seed", ErrEntriesOpen},
+		{"already drawn", drawn, "seed", repository.ErrLotteryAlreadyDrawn},
+		{"wrong seed", closedEntrants(), "other seed", ErrSeedMismatch},
+		{"no commitment", uncommitted, "seed", ErrSeedMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockLotteryRepository)
+			service := NewLotteryService(mockRepo)
+
+			mockRepo.On("Draw", "FLASH25").Return(tt.entrants, nil)
+
+			draw, err := service.DrawLottery(context.Background(), "FLASH25", &models.DrawLotteryRequest{Seed: tt.seed})
+			assert.Nil(t, draw)
+			assert.Equal(t, tt.want, err)
+			assert.Nil(t, mockRepo.losers)
+		})
+	}
+}
+
+func TestDrawLottery_CouponNotFound(t *testing.T) {
+	mockRepo := new(MockLotteryRepository)
+	service := NewLotteryService(mockRepo)
+
+	mockRepo.On("Draw", "NONEXISTENT").Return(nil, repository.ErrCouponNotFound)
+
+	_, err := service.DrawLottery(context.Background(), "NONEXISTENT", &models.DrawLotteryRequest{Seed: "seed"})
+	assert.Equal(t, repository.ErrCouponNotFound, err)
+}
+
+func TestGetDraw_EmptyName(t *testing.T) {
+	mockRepo := new(MockLotteryRepository)
+	service := NewLotteryService(mockRepo)
+
+	_, err := service.GetDraw(context.Background(), "")
+	assert.EqualError(t, err, "name is required")
+	mockRepo.AssertNotCalled(t, "GetDraw", mock.Anything)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wazadio/coupon-system/internal/metrics"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+	"github.com/wazadio/coupon-system/pkg/validation"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrQueueRequired   = errors.New("coupon only accepts claims through its queue")
+	ErrQueueNotEnabled = errors.New("coupon is not in queue mode")
+)
+
+// queueFailureMessage is recorded on tickets that failed for an unexpected reason, whose
+// error is only logged
+const queueFailureMessage = "claim could not be processed"
+
+// claimOutcomes are the claim errors reported to the ticket holder as they are
+var claimOutcomes = []error{
+	repository.ErrCouponNotFound,
+	repository.ErrAlreadyClaimed,
+	repository.ErrNoStockAvailable,
+	repository.ErrCouponExpired,
+	repository.ErrUserDenied,
+	repository.ErrUserNotAllowed,
+}
+
+// QueueConfig controls how the claim queue is drained
+type QueueConfig struct {
+	Workers      int           `yaml:"workers"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// TicketLease is how long a worker holds a ticket before another may take it over,
+	// so tickets of a worker that crashed mid-claim are processed again
+	TicketLease time.Duration `yaml:"ticket_lease"`
+}
+
+// DefaultQueueConfig drains the queue with one worker polling every 500ms
+func DefaultQueueConfig() *QueueConfig {
+	return &QueueConfig{Workers: 1, PollInterval: 500 * time.Millisecond, TicketLease: 30 * time.Second}
+}
+
+// LoadEnv overrides the settings whose QUEUE_* environment variable is set
+func (c *QueueConfig) LoadEnv() error {
+	if workers := os.Getenv("QUEUE_WORKERS"); workers != "" {
+		n, err := strconv.Atoi(workers)
+		if err != nil {
+			return fmt.Errorf("invalid QUEUE_WORKERS: %q", workers)
+		}
+		c.Workers = n
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"QUEUE_POLL_INTERVAL", &c.PollInterval},
+		{"QUEUE_TICKET_LEASE", &c.TicketLease},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %q", setting.name, value)
+			}
+			*setting.value = d
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the queue can be drained with the config. Zero workers leave the queue
+// to other instances.
+func (c *QueueConfig) Validate() error {
+	if c.Workers < 0 {
+		return fmt.Errorf("workers must not be negative, got %d", c.Workers)
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("poll_interval must be positive, got %s", c.PollInterval)
+	}
+	if c.TicketLease <= 0 {
+		return fmt.Errorf("ticket_lease must be positive, got %s", c.TicketLease)
+	}
+	return nil
+}
+
+// QueueService defines the interface for the virtual waiting room of queue-mode coupons
+type QueueService interface {
+	JoinQueue(ctx context.Context, req *models.ClaimCouponRequest) (*models.QueueTicket, error)
+	GetTicket(ctx context.Context, ticket string) (*models.QueueTicket, error)
+	ProcessNext(ctx context.Context, lease time.Duration) (processed bool, err error)
+	Run(ctx context.Context, config QueueConfig)
+}
+
+// queueService handles business logic for the claim queue
+type queueService struct {
+	repo       repository.QueueRepository
+	couponRepo repository.CouponRepository
+}
+
+// NewQueueService creates a new QueueService with injected repositories
+func NewQueueService(repo repository.QueueRepository, couponRepo repository.CouponRepository) QueueService {
+	return &queueService{
+		repo:       repo,
+		couponRepo: couponRepo,
+	}
+}
+
+// JoinQueue hands the user a ticket for a queue-mode coupon. Eligibility rules are checked
+// here, against the attributes sent when joining, so ineligible users never wait in line.
+func (s *queueService) JoinQueue(ctx context.Context, req *models.ClaimCouponRequest) (*models.QueueTicket, error) {
+	if err := validation.Struct(req).Err(); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.couponRepo.GetClaimPolicy(ctx, req.CouponName)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.QueueMode {
+		return nil, ErrQueueNotEnabled
+	}
+	if err := evaluateEligibility(policy.EligibilityRules, req.Attributes); err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.repo.Enqueue(ctx, req.CouponName, req.UserID, uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetTicket(ctx, ticket)
+}
+
+// GetTicket returns a ticket's status and, while it is waiting, its position
+func (s *queueService) GetTicket(ctx context.Context, ticket string) (*models.QueueTicket, error) {
+	// Tickets are UUIDs, anything else cannot exist
+	if _, err := uuid.Parse(ticket); err != nil {
+		return nil, repository.ErrTicketNotFound
+	}
+
+	return s.repo.GetTicket(ctx, ticket)
+}
+
+// ProcessNext claims the coupon for the oldest waiting ticket and records the outcome,
+// holding the ticket for lease. It reports false when there was no ticket to process.
+// A successful claim completes the ticket in its own transaction; should recording a failed
+// claim fail, the ticket is retried once the lease expires.
+func (s *queueService) ProcessNext(ctx context.Context, lease time.Duration) (bool, error) {
+	ticket, err := s.repo.TakeNext(ctx, lease)
+	if err != nil {
+		return false, err
+	}
+	if ticket == nil {
+		return false, nil
+	}
+
+	ctx, span := tracer.Start(ctx, "QueueService.ProcessNext")
+	defer span.End()
+
+	claim, err := s.couponRepo.ClaimQueuedCoupon(ctx, ticket.ID, ticket.UserID, ticket.CouponName)
+	metrics.ClaimOutcomes.WithLabelValues(claimOutcome(claim, err)).Inc()
+	if err == nil {
+		return true, nil
+	}
+
+	errMsg := err.Error()
+	if !isClaimOutcome(err) {
+		logger.Log.Error("Queued claim failed", zap.String("ticket", ticket.Ticket), zap.Error(err))
+		errMsg = queueFailureMessage
+	}
+	if err := s.repo.FailTicket(ctx, ticket.ID, errMsg); err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// isClaimOutcome reports whether err is an expected reason for a claim to fail
+func isClaimOutcome(err error) bool {
+	for _, outcome := range claimOutcomes {
+		if err == outcome {
+			return true
+		}
+	}
+	return false
+}
+
+// Run drains the queue until ctx is cancelled, waiting config.PollInterval whenever it is
+// empty or a ticket could not be processed
+func (s *queueService) Run(ctx context.Context, config QueueConfig) {
+	// A claim under way is finished even if ctx is cancelled meanwhile
+	claimCtx := context.WithoutCancel(ctx)
+	for {
+		processed, err := s.ProcessNext(claimCtx, config.TicketLease)
+		if err != nil {
+			logger.Log.Error("Queue worker error", zap.Error(err))
+		}
+
+		if processed && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(config.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wazadio/coupon-system/internal/models"
+	"github.com/wazadio/coupon-system/internal/repository"
+	"github.com/wazadio/coupon-system/pkg/logger"
+)
+
+const testTicket = "5f1d7c1e-7a51-4d7b-9d55-0c7bb7f0a9b1"
+
+// MockQueueRepository is a mock implementation of QueueRepository
+type MockQueueRepository struct {
+	mock.Mock
+}
+
+func (m *MockQueueRepository) Enqueue(ctx context.Context, couponName, userID, ticket string) (string, error) {
+	args := m.Called(couponName, userID, ticket)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockQueueRepository) GetTicket(ctx context.Context, ticket string) (*models.QueueTicket, error) {
+	args := m.Called(ticket)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.QueueTicket), args.Error(1)
+}
+
+func (m *MockQueueRepository) TakeNext(ctx context.Context, lease time.Duration) (*models.QueueTicket, error) {
+	args := m.Called(lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.QueueTicket), args.Error(1)
+}
+
+func (m *MockQueueRepository) FailTicket(ctx context.Context, id int64, errMsg string) error {
+	args := m.Called(id, errMsg)
+	return args.Error(0)
+}
+
+func TestJoinQueue_Success(t *testing.T) {
+	mockRepo := new(MockQueueRepository)
+	mockCouponRepo := new(MockCouponRepository)
+	service := NewQueueService(mockRepo, mockCouponRepo)
+
+	expected := &models.QueueTicket{Ticket: testTicket, Status: models.QueueStatusWaiting, Position: 1}
+	mockCouponRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{QueueMode: true}, nil)
+	mockRepo.On("Enqueue", "FLASH25", "user1", mock.AnythingOfType("string")).Return(testTicket, nil)
+	mockRepo.On("GetTicket", testTicket).Return(expected, nil)
+
+	ticket, err := service.JoinQueue(context.Background(), &models.ClaimCouponRequest{UserID: "user1", CouponName: "FLASH25"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, ticket)
+	mockRepo.AssertExpectations(t)
+	mockCouponRepo.AssertExpectations(t)
+}
+
+func TestJoinQueue_NotQueueMode(t *testing.T) {
+	mockRepo := new(MockQueueRepository)
+	mockCouponRepo := new(MockCouponRepository)
+	service := NewQueueService(mockRepo, mockCouponRepo)
+
+	mockCouponRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{}, nil)
+
+	ticket, err := service.JoinQueue(context.Background(), &models.ClaimCouponRequest{UserID: "user1", CouponName: "FLASH25"})
+	assert.Nil(t, ticket)
+	assert.Equal(t, ErrQueueNotEnabled, err)
+	mockRepo.AssertNotCalled(t, "Enqueue", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestJoinQueue_NotEligible(t *testing.T) {
+	mockRepo := new(MockQueueRepository)
+	mockCouponRepo := new(MockCouponRepository)
+	service := NewQueueService(mockRepo, mockCouponRepo)
+
+	rules := models.EligibilityRules{
+		{Name: "new_users_only", Attribute: "is_new_user", Operator: models.OperatorEq, Value: true},
+	}
+	mockCouponRepo.On("GetClaimPolicy", "FLASH25").Return(&models.ClaimPolicy{EligibilityRules: rules, QueueMode: true}, nil)
+
+	_, err := service.JoinQueue(context.Background(), &models.ClaimCouponRequest{UserID: "user1", CouponName: "FLASH25"})
+	assert.True(t, errors.Is(err, ErrNotEligible))
+	mockRepo.AssertNotCalled(t, "Enqueue", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestJoinQueue_Validation(t *testing.T) {
+	service := NewQueueService(new(MockQueueRepository), new(MockCouponRepository))
+
+	_, err := service.JoinQueue(context.Background(), &models.ClaimCouponRequest{CouponName: "FLASH25"})
+	assert.EqualError(t, err, "user_id is required")
+
+	_, err = service.JoinQueue(context.Background(), &models.ClaimCouponRequest{UserID: "user1"})
+	assert.EqualError(t, err, "coupon_name is required")
+}
+
+func TestGetTicket_MalformedTicket(t *testing.T) {
+	mockRepo := new(MockQueueRepository)
+	service := NewQueueService(mockRepo, new(MockCouponRepository))
+
+	ticket, err := service.GetTicket(context.Background(), "not-a-uuid")
+	assert.Nil(t, ticket)
+	assert.Equal(t, repository.ErrTicketNotFound, err)
+	mockRepo.AssertNotCalled(t, "GetTicket", mock.Anything)
+}
+
+func TestProcessNext_EmptyQueue(t *testing.T) {
+	mockRepo := new(MockQueueRepository)
+	mockCouponRepo := new(MockCouponRepository)
+	service := NewQueueService(mockRepo, mockCouponRepo)
+
+	mockRepo.On("TakeNext", time.Minute).Return(nil, nil)
+
+	processed, err := service.ProcessNext(context.Background(), time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, processed)
+	mockCouponRepo.AssertNotCalled(t, "ClaimQueuedCoupon", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessNext_Outcomes(t *testing.T) {
+	tests := []struct {
+		name          string
+		claim         *models.Claim
+		err           error
+		expectedError string
+	}{
+		{"claimed", &models.Claim{Code: "FLASH25-7KQ2-M9XD-H4TC"}, nil, ""},
+		{"sold out", nil, repository.ErrNoStockAvailable, "no stock available"},
+		{"already claimed", nil, repository.ErrAlreadyClaimed, "user already claimed this coupon"},
+		{"unexpected error", nil, errors.New("connection reset"), "claim could not be processed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger.Init()
+
+			mockRepo := new(MockQueueRepository)
+			mockCouponRepo := new(MockCouponRepository)
+			service := NewQueueService(mockRepo, mockCouponRepo)
+
+			mockRepo.On("TakeNext", time.Minute).Return(&models.QueueTicket{ID: 7, Ticket: testTicket, CouponName: "FLASH25", UserID: "user1"}, nil)
+			if tt.claim != nil {
+				// The claim completes the ticket itself
+				mockCouponRepo.On("ClaimQueuedCoupon", int64(7), "user1", "FLASH25").Return(tt.claim, nil)
+			} else {
+				mockCouponRepo.On("ClaimQueuedCoupon", int64(7), "user1", "FLASH25").Return(nil, tt.err)
+				mockRepo.On("FailTicket", int64(7), tt.expectedError).Return(nil)
+			}
+
+			processed, err := service.ProcessNext(context.Background(), time.Minute)
+			assert.NoError(t, err)
+			assert.True(t, processed)
+			mockRepo.AssertExpectations(t)
+			mockCouponRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRun_StopsOnCancel(t *testing.T) {
+	mockRepo := new(MockQueueRepository)
+	service := NewQueueService(mockRepo, new(MockCouponRepository))
+
+	mockRepo.On("TakeNext", time.Minute).Return(nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Run(ctx, QueueConfig{PollInterval: time.Millisecond, TicketLease: time.Minute})
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop after cancel")
+	}
+	mockRepo.AssertCalled(t, "TakeNext", time.Minute)
+}
+
+func TestQueueConfig_LoadEnv(t *testing.T) {
+	load := func() (*QueueConfig, error) {
+		config := DefaultQueueConfig()
+		if err := config.LoadEnv(); err != nil {
+			return nil, err
+		}
+		return config, config.Validate()
+	}
+
+	t.Setenv("QUEUE_WORKERS", "")
+	t.Setenv("QUEUE_POLL_INTERVAL", "")
+	t.Setenv("QUEUE_TICKET_LEASE", "")
+	config, err := load()
+	assert.NoError(t, err)
+	assert.Equal(t, &QueueConfig{Workers: 1, PollInterval: 500 * time.Millisecond, TicketLease: 30 * time.Second}, config)
+
+	t.Setenv("QUEUE_WORKERS", "4")
+	t.Setenv("QUEUE_POLL_INTERVAL", "2s")
+	t.Setenv("QUEUE_TICKET_LEASE", "1m")
+	config, err = load()
+	assert.NoError(t, err)
+	assert.Equal(t, &QueueConfig{Workers: 4, PollInterval: 2 * time.Second, TicketLease: time.Minute}, config)
+
+	t.Setenv("QUEUE_WORKERS", "many")
+	_, err = load()
+	assert.EqualError(t, err, `invalid QUEUE_WORKERS: "many"`)
+
+	t.Setenv("QUEUE_WORKERS", "-1")
+	_, err = load()
+	assert.EqualError(t, err, "workers must not be negative, got -1")
+}
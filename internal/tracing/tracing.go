@@ -0,0 +1,160 @@
+// Package tracing sets up OpenTelemetry tracing for the coupon system. Spans are exported
+// over OTLP to a collector, or written to a file for local use, and incoming W3C
+// traceparent headers are honored so traces continue across services.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters accepted in Config.Exporter
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+const (
+	defaultServiceName = "coupon-system"
+	defaultTracesFile  = "logs/traces.json"
+)
+
+// Config holds tracing configuration
+type Config struct {
+	// Exporter is one of ExporterNone, ExporterOTLP or ExporterFile
+	Exporter string `yaml:"exporter"`
+	// ServiceName identifies this process in the exported spans
+	ServiceName string `yaml:"service_name"`
+	// File is where ExporterFile writes spans, one JSON object per span
+	File string `yaml:"file"`
+}
+
+// DefaultConfig creates spans without exporting them. The OTLP exporter reads its endpoint
+// and headers from the standard OTEL_EXPORTER_OTLP_* variables.
+func DefaultConfig() *Config {
+	return &Config{
+		Exporter:    ExporterNone,
+		ServiceName: defaultServiceName,
+		File:        defaultTracesFile,
+	}
+}
+
+// LoadEnv overrides the settings whose OTEL_* environment variable is set
+func (c *Config) LoadEnv() error {
+	for _, setting := range []struct {
+		name  string
+		value *string
+	}{
+		{"OTEL_TRACES_EXPORTER", &c.Exporter},
+		{"OTEL_SERVICE_NAME", &c.ServiceName},
+		{"OTEL_TRACES_FILE", &c.File},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			*setting.value = value
+		}
+	}
+	return nil
+}
+
+// Validate checks the config names a known exporter
+func (c *Config) Validate() error {
+	switch c.Exporter {
+	case ExporterNone, ExporterOTLP, ExporterFile:
+	default:
+		return fmt.Errorf("invalid exporter %q: must be %s, %s or %s", c.Exporter, ExporterNone, ExporterOTLP, ExporterFile)
+	}
+	if c.Exporter == ExporterFile && c.File == "" {
+		return fmt.Errorf("file is required with the %s exporter", ExporterFile)
+	}
+	return nil
+}
+
+// Init installs the global tracer provider and the W3C trace context and baggage
+// propagators. The returned function flushes buffered spans and must be called on exit.
+// With ExporterNone spans are still created, so trace IDs propagate and reach the logs,
+// but nothing is exported.
+func Init(ctx context.Context, config *Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace resource: %v", err)
+	}
+
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	closeFile := func() error { return nil }
+
+	switch config.Exporter {
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error creating OTLP trace exporter: %v", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case ExporterFile:
+		if err := os.MkdirAll(filepath.Dir(config.File), 0755); err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(config.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("error creating file trace exporter: %v", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+		closeFile = file.Close
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeErr := closeFile(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
+
+// End records err, if any, on span and ends it. Deferred with a named error result, it
+// closes a span whatever path the function returns by.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// IDs returns the trace and span IDs of the span in ctx, in their W3C hex form. ok is false
+// when ctx holds no valid span, as when tracing was never initialized.
+func IDs(ctx context.Context) (traceID, spanID string, ok bool) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return "", "", false
+	}
+	return spanContext.TraceID().String(), spanContext.SpanID().String(), true
+}
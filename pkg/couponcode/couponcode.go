@@ -0,0 +1,151 @@
+package couponcode
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	// Alphabet is the set of characters random code groups are drawn from.
+	// It leaves out 0/O and 1/I, which are easily confused when codes are typed by hand.
+	Alphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+	// GroupSize is the number of characters in each group
+	GroupSize = 4
+
+	// Groups is the number of groups appended to the prefix. The last character
+	// of the last group is a check character over the other group characters.
+	Groups = 3
+
+	separator = "-"
+)
+
+var (
+	ErrInvalidFormat   = errors.New("malformed code")
+	ErrInvalidChecksum = errors.New("code checksum mismatch")
+)
+
+// maxAttemptsFactor bounds GenerateN to n*maxAttemptsFactor draws before it gives up
+const maxAttemptsFactor = 10
+
+// Generate returns a code made of prefix followed by random groups, e.g. FLASH25-7KQ2-M9XD-H4TC.
+// Random characters are drawn from crypto/rand so codes cannot be guessed from each other.
+func Generate(prefix string) (string, error) {
+	body := make([]byte, 0, Groups*GroupSize)
+	for i := 0; i < Groups*GroupSize-1; i++ {
+		c, err := randomChar()
+		if err != nil {
+			return "", err
+		}
+		body = append(body, c)
+	}
+	body = append(body, checkChar(string(body)))
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	for g := 0; g < Groups; g++ {
+		b.WriteString(separator)
+		b.Write(body[g*GroupSize : (g+1)*GroupSize])
+	}
+
+	return b.String(), nil
+}
+
+// GenerateN returns n distinct codes sharing prefix
+func GenerateN(prefix string, n int) ([]string, error) {
+	seen := make(map[string]struct{}, n)
+	codes := make([]string, 0, n)
+
+	for attempts := 0; len(codes) < n; attempts++ {
+		if attempts >= n*maxAttemptsFactor {
+			return nil, errors.New("unable to generate enough distinct codes")
+		}
+
+		code, err := Generate(prefix)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[code]; ok {
+			continue
+		}
+		seen[code] = struct{}{}
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// Normalize cleans up a hand-typed code: surrounding whitespace is dropped and the
+// random groups are upper-cased. The prefix is kept as is since coupon names are case-sensitive.
+// It returns ErrInvalidFormat or ErrInvalidChecksum without touching any storage.
+func Normalize(code string) (string, error) {
+	code = strings.TrimSpace(code)
+
+	parts := strings.Split(code, separator)
+	if len(parts) < Groups+1 {
+		return "", ErrInvalidFormat
+	}
+
+	prefix := strings.Join(parts[:len(parts)-Groups], separator)
+	if prefix == "" {
+		return "", ErrInvalidFormat
+	}
+
+	groups := parts[len(parts)-Groups:]
+	for i, group := range groups {
+		group = strings.ToUpper(group)
+		if len(group) != GroupSize {
+			return "", ErrInvalidFormat
+		}
+		for j := 0; j < len(group); j++ {
+			if strings.IndexByte(Alphabet, group[j]) < 0 {
+				return "", ErrInvalidFormat
+			}
+		}
+		groups[i] = group
+	}
+
+	if !validChecksum(strings.Join(groups, "")) {
+		return "", ErrInvalidChecksum
+	}
+
+	return prefix + separator + strings.Join(groups, separator), nil
+}
+
+// Prefix returns the prefix of a code, which is the name of the coupon it was generated for
+func Prefix(code string) (string, error) {
+	normalized, err := Normalize(code)
+	if err != nil {
+		return "", err
+	}
+	return normalized[:len(normalized)-Groups*(GroupSize+len(separator))], nil
+}
+
+// checkChar computes the Luhn mod N check character for s, which catches any
+// single mistyped character and most swaps of adjacent characters
+func checkChar(s string) byte {
+	n := len(Alphabet)
+	factor := 2
+	sum := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(Alphabet, s[i])
+		factor = 3 - factor
+		sum += addend/n + addend%n
+	}
+	return Alphabet[(n-sum%n)%n]
+}
+
+// validChecksum reports whether the last character of s is the check character of the rest
+func validChecksum(s string) bool {
+	return checkChar(s[:len(s)-1]) == s[len(s)-1]
+}
+
+func randomChar() (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(Alphabet))))
+	if err != nil {
+		return 0, err
+	}
+	return Alphabet[n.Int64()], nil
+}
@@ -0,0 +1,91 @@
+package couponcode
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate_Format(t *testing.T) {
+	code, err := Generate("FLASH25")
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^FLASH25-[2-9A-HJ-NP-Z]{4}-[2-9A-HJ-NP-Z]{4}-[2-9A-HJ-NP-Z]{4}$`), code)
+}
+
+func TestGenerateN_Distinct(t *testing.T) {
+	codes, err := GenerateN("FLASH25", 500)
+	assert.NoError(t, err)
+	assert.Len(t, codes, 500)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		assert.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+	}
+}
+
+func TestNormalize_AcceptsGeneratedCodes(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := Generate("FLASH-25")
+		assert.NoError(t, err)
+
+		normalized, err := Normalize("  " + strings.ToLower(code[len("FLASH-25"):]) + " ")
+		assert.Equal(t, ErrInvalidFormat, err, "prefix is required")
+		assert.Empty(t, normalized)
+
+		prefix, groups := code[:len("FLASH-25")], code[len("FLASH-25"):]
+		normalized, err = Normalize("  " + prefix + strings.ToLower(groups) + "\n")
+		assert.NoError(t, err)
+		assert.Equal(t, code, normalized)
+	}
+}
+
+func TestNormalize_Malformed(t *testing.T) {
+	cases := []string{
+		"",
+		"FLASH25",
+		"FLASH25-ABCD-EFGH",
+		"FLASH25-ABCD-EFGH-JKL",
+		"FLASH25-ABCD-EFGH-JK0M",
+		"FLASH25-ABCD-EFGH-JKIM",
+		"-ABCD-EFGH-JKLM",
+	}
+	for _, code := range cases {
+		_, err := Normalize(code)
+		assert.Equal(t, ErrInvalidFormat, err, code)
+	}
+}
+
+func TestNormalize_DetectsSingleCharacterTypos(t *testing.T) {
+	code, err := Generate("FLASH25")
+	assert.NoError(t, err)
+
+	for i := len("FLASH25"); i < len(code); i++ {
+		if code[i] == '-' {
+			continue
+		}
+		for j := 0; j < len(Alphabet); j++ {
+			if Alphabet[j] == code[i] {
+				continue
+			}
+			typo := code[:i] + string(Alphabet[j]) + code[i+1:]
+			_, err := Normalize(typo)
+			assert.Equal(t, ErrInvalidChecksum, err, typo)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	code, err := Generate("SPRING-SALE")
+	assert.NoError(t, err)
+
+	prefix, err := Prefix(strings.ToLower(code[len("SPRING-SALE"):]))
+	assert.Equal(t, ErrInvalidFormat, err)
+	assert.Empty(t, prefix)
+
+	prefix, err = Prefix(code)
+	assert.NoError(t, err)
+	assert.Equal(t, "SPRING-SALE", prefix)
+}
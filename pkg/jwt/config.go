@@ -0,0 +1,93 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config lists where verification keys are loaded from and which claims are required.
+// Any combination of key files may be set.
+type Config struct {
+	HMACKeyFile      string        `yaml:"hmac_key_file"`
+	RSAPublicKeyFile string        `yaml:"rsa_public_key_file"`
+	JWKSFile         string        `yaml:"jwks_file"`
+	Issuer           string        `yaml:"issuer"`
+	Audience         string        `yaml:"audience"`
+	Leeway           time.Duration `yaml:"leeway"`
+}
+
+// LoadEnv overrides the settings whose JWT_* environment variable is set
+func (c *Config) LoadEnv() error {
+	for _, setting := range []struct {
+		name  string
+		value *string
+	}{
+		{"JWT_HMAC_KEY_FILE", &c.HMACKeyFile},
+		{"JWT_RSA_PUBLIC_KEY_FILE", &c.RSAPublicKeyFile},
+		{"JWT_JWKS_FILE", &c.JWKSFile},
+		{"JWT_ISSUER", &c.Issuer},
+		{"JWT_AUDIENCE", &c.Audience},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			*setting.value = value
+		}
+	}
+
+	if leeway := os.Getenv("JWT_LEEWAY"); leeway != "" {
+		d, err := time.ParseDuration(leeway)
+		if err != nil {
+			return fmt.Errorf("invalid JWT_LEEWAY: %v", err)
+		}
+		c.Leeway = d
+	}
+
+	return nil
+}
+
+// Validate checks the config can be used to verify tokens
+func (c *Config) Validate() error {
+	if c.Leeway < 0 {
+		return fmt.Errorf("leeway must not be negative, got %s", c.Leeway)
+	}
+	return nil
+}
+
+// NewVerifier loads every configured key file and returns a Verifier using them
+func NewVerifier(config *Config) (*Verifier, error) {
+	var keys []Key
+
+	if config.HMACKeyFile != "" {
+		key, err := LoadHMACKeyFile(config.HMACKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if config.RSAPublicKeyFile != "" {
+		key, err := LoadRSAPublicKeyFile(config.RSAPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if config.JWKSFile != "" {
+		set, err := LoadJWKSFile(config.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, set...)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no JWT verification keys configured")
+	}
+
+	return &Verifier{
+		Keys:     keys,
+		Issuer:   config.Issuer,
+		Audience: config.Audience,
+		Leeway:   config.Leeway,
+	}, nil
+}
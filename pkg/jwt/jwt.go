@@ -0,0 +1,220 @@
+// Package jwt verifies compact JSON Web Tokens signed with HS256 or RS256.
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Supported signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+var (
+	ErrMalformed            = errors.New("malformed token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrUnknownKey           = errors.New("no key found for token")
+	ErrInvalidSignature     = errors.New("invalid token signature")
+	ErrExpired              = errors.New("token has expired")
+	ErrNotYetValid          = errors.New("token is not valid yet")
+	ErrMissingExpiry        = errors.New("token has no expiry")
+	ErrMissingSubject       = errors.New("token has no subject")
+	ErrInvalidIssuer        = errors.New("token issuer is not accepted")
+	ErrInvalidAudience      = errors.New("token audience is not accepted")
+)
+
+// Key is a verification key. Exactly one of HMACSecret and RSAPublicKey is set,
+// matching Algorithm. ID is the JWKS key ID and is empty for keys loaded from a single file.
+type Key struct {
+	ID           string
+	Algorithm    string
+	HMACSecret   []byte
+	RSAPublicKey *rsa.PublicKey
+}
+
+// Claims are the registered claims checked by Verify. Raw holds every claim in the payload.
+type Claims struct {
+	Subject   string                 `json:"sub"`
+	Issuer    string                 `json:"iss,omitempty"`
+	Audience  Audience               `json:"aud,omitempty"`
+	ExpiresAt float64                `json:"exp,omitempty"`
+	NotBefore float64                `json:"nbf,omitempty"`
+	IssuedAt  float64                `json:"iat,omitempty"`
+	Raw       map[string]interface{} `json:"-"`
+}
+
+// Audience is the aud claim, which may be a single string or a list of strings
+type Audience []string
+
+// UnmarshalJSON accepts both forms of the aud claim
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Verifier checks token signatures against Keys and validates the registered claims.
+// Issuer and Audience are only checked when set.
+type Verifier struct {
+	Keys     []Key
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew between the token issuer and this service
+	Leeway time.Duration
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// Verify parses token, checks its signature and claims and returns the claims
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrMalformed
+	}
+	if h.Algorithm != AlgHS256 && h.Algorithm != AlgRS256 {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	keys := v.findKeys(h)
+	if len(keys) == 0 {
+		return nil, ErrUnknownKey
+	}
+	if !verifyAny(keys, parts[0]+"."+parts[1], signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformed
+	}
+	if err := decodeSegment(parts[1], &claims.Raw); err != nil {
+		return nil, ErrMalformed
+	}
+
+	if err := v.validateClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// findKeys returns the keys for the token's algorithm. When the token has a key ID, keys
+// with a different ID are skipped; otherwise every key of the algorithm is a candidate, as
+// during a rotation where the old and new secrets are both configured.
+func (v *Verifier) findKeys(h header) []Key {
+	var keys []Key
+	for _, key := range v.Keys {
+		if key.Algorithm != h.Algorithm {
+			continue
+		}
+		if h.KeyID != "" && key.ID != "" && key.ID != h.KeyID {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// verifyAny reports whether any of keys verifies signature
+func verifyAny(keys []Key, input string, signature []byte) bool {
+	for _, key := range keys {
+		if verifySignature(key, input, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Verifier) validateClaims(c *Claims) error {
+	now := time.Now()
+
+	if c.ExpiresAt == 0 {
+		return ErrMissingExpiry
+	}
+	if now.Add(-v.Leeway).After(unixTime(c.ExpiresAt)) {
+		return ErrExpired
+	}
+	if c.NotBefore != 0 && now.Add(v.Leeway).Before(unixTime(c.NotBefore)) {
+		return ErrNotYetValid
+	}
+	if c.Subject == "" {
+		return ErrMissingSubject
+	}
+	if v.Issuer != "" && c.Issuer != v.Issuer {
+		return ErrInvalidIssuer
+	}
+	if v.Audience != "" && !c.Audience.contains(v.Audience) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+func (a Audience) contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+func verifySignature(key Key, signingInput string, signature []byte) bool {
+	switch key.Algorithm {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, key.HMACSecret)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(signature, mac.Sum(nil))
+	case AlgRS256:
+		if key.RSAPublicKey == nil {
+			return false
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		return rsa.VerifyPKCS1v15(key.RSAPublicKey, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
@@ -0,0 +1,220 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, h map[string]interface{}, claims map[string]interface{}, secret []byte) string {
+	input := encodeSegment(t, h) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, h map[string]interface{}, claims map[string]interface{}, key *rsa.PrivateKey) string {
+	input := encodeSegment(t, h) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub": "user1",
+		"iss": "auth.example.com",
+		"aud": "coupon-api",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerify_HS256(t *testing.T) {
+	v := &Verifier{Keys: []Key{{Algorithm: AlgHS256, HMACSecret: testSecret}}, Issuer: "auth.example.com", Audience: "coupon-api"}
+
+	token := signHS256(t, map[string]interface{}{"alg": "HS256", "typ": "JWT"}, validClaims(), testSecret)
+
+	claims, err := v.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", claims.Subject)
+	assert.Equal(t, Audience{"coupon-api"}, claims.Audience)
+	assert.Equal(t, "user1", claims.Raw["sub"])
+}
+
+func TestVerify_RS256WithKeyID(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	v := &Verifier{Keys: []Key{
+		{ID: "old", Algorithm: AlgRS256, RSAPublicKey: &other.PublicKey},
+		{ID: "current", Algorithm: AlgRS256, RSAPublicKey: &priv.PublicKey},
+	}}
+
+	token := signRS256(t, map[string]interface{}{"alg": "RS256", "kid": "current"}, validClaims(), priv)
+	claims, err := v.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", claims.Subject)
+
+	token = signRS256(t, map[string]interface{}{"alg": "RS256", "kid": "old"}, validClaims(), priv)
+	_, err = v.Verify(token)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestVerify_HS256TriesEveryKey(t *testing.T) {
+	oldSecret := []byte("old-secret-old-secret-old-secret")
+	verifier := &Verifier{Keys: []Key{
+		{Algorithm: AlgHS256, HMACSecret: testSecret},
+		{Algorithm: AlgHS256, HMACSecret: oldSecret},
+	}}
+	hs := map[string]interface{}{"alg": "HS256"}
+
+	for _, secret := range [][]byte{testSecret, oldSecret} {
+		claims, err := verifier.Verify(signHS256(t, hs, validClaims(), secret))
+		require.NoError(t, err)
+		assert.Equal(t, "user1", claims.Subject)
+	}
+
+	_, err := verifier.Verify(signHS256(t, hs, validClaims(), []byte("another-secret-another-secret-xx")))
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestVerify_Rejections(t *testing.T) {
+	v := &Verifier{Keys: []Key{{Algorithm: AlgHS256, HMACSecret: testSecret}}, Issuer: "auth.example.com", Audience: "coupon-api"}
+	hs := map[string]interface{}{"alg": "HS256"}
+
+	with := func(key string, value interface{}) map[string]interface{} {
+		c := validClaims()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"not a jwt", "abc", ErrMalformed},
+		{"alg none", encodeSegment(t, map[string]interface{}{"alg": "none"}) + "." + encodeSegment(t, validClaims()) + ".", ErrUnsupportedAlgorithm},
+		{"no RS256 key", signHS256(t, map[string]interface{}{"alg": "RS256"}, validClaims(), testSecret), ErrUnknownKey},
+		{"wrong secret", signHS256(t, hs, validClaims(), []byte("another-secret-another-secret-xx")), ErrInvalidSignature},
+		{"expired", signHS256(t, hs, with("exp", time.Now().Add(-time.Minute).Unix()), testSecret), ErrExpired},
+		{"no expiry", signHS256(t, hs, with("exp", nil), testSecret), ErrMissingExpiry},
+		{"not yet valid", signHS256(t, hs, with("nbf", time.Now().Add(time.Hour).Unix()), testSecret), ErrNotYetValid},
+		{"no subject", signHS256(t, hs, with("sub", nil), testSecret), ErrMissingSubject},
+		{"wrong issuer", signHS256(t, hs, with("iss", "evil.example.com"), testSecret), ErrInvalidIssuer},
+		{"wrong audience", signHS256(t, hs, with("aud", []string{"other-api"}), testSecret), ErrInvalidAudience},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(tt.token)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestVerify_Leeway(t *testing.T) {
+	v := &Verifier{Keys: []Key{{Algorithm: AlgHS256, HMACSecret: testSecret}}, Leeway: time.Minute}
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	token := signHS256(t, map[string]interface{}{"alg": "HS256"}, claims, testSecret)
+
+	_, err := v.Verify(token)
+	assert.NoError(t, err)
+}
+
+func TestLoadHMACKeyFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "secret")
+	require.NoError(t, os.WriteFile(path, append(testSecret, '\n'), 0600))
+	key, err := LoadHMACKeyFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, testSecret, key.HMACSecret)
+
+	short := filepath.Join(dir, "short")
+	require.NoError(t, os.WriteFile(short, []byte("too-short"), 0600))
+	_, err = LoadHMACKeyFile(short)
+	assert.Error(t, err)
+}
+
+func TestLoadRSAPublicKeyFile(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "public.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	key, err := LoadRSAPublicKeyFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, AlgRS256, key.Algorithm)
+	assert.True(t, priv.PublicKey.Equal(key.RSAPublicKey))
+}
+
+func TestLoadJWKSFile(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	set := map[string]interface{}{
+		"keys": []map[string]interface{}{
+			{
+				"kty": "RSA", "kid": "rsa-1", "alg": "RS256", "use": "sig",
+				"n": base64.RawURLEncoding.EncodeToString(priv.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(priv.E)).Bytes()),
+			},
+			{"kty": "oct", "kid": "hmac-1", "k": base64.RawURLEncoding.EncodeToString(testSecret)},
+			{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		},
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	keys, err := LoadJWKSFile(path)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	v := &Verifier{Keys: keys}
+	token := signRS256(t, map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, validClaims(), priv)
+	_, err = v.Verify(token)
+	assert.NoError(t, err)
+
+	token = signHS256(t, map[string]interface{}{"alg": "HS256", "kid": "hmac-1"}, validClaims(), testSecret)
+	_, err = v.Verify(token)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,153 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// minHMACSecretLength is the shortest HS256 secret accepted, matching the SHA-256 output size
+const minHMACSecretLength = 32
+
+// LoadHMACKeyFile reads an HS256 shared secret from path. Surrounding whitespace is ignored.
+func LoadHMACKeyFile(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("error reading HMAC key file: %v", err)
+	}
+
+	secret := bytes.TrimSpace(data)
+	if len(secret) < minHMACSecretLength {
+		return Key{}, fmt.Errorf("HMAC key in %s must be at least %d bytes", path, minHMACSecretLength)
+	}
+
+	return Key{Algorithm: AlgHS256, HMACSecret: secret}, nil
+}
+
+// LoadRSAPublicKeyFile reads an RS256 public key from a PEM file holding a PKIX public key,
+// a PKCS #1 public key or a certificate
+func LoadRSAPublicKeyFile(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("error reading RSA key file: %v", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	var pub interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			pub = cert.PublicKey
+		}
+	default:
+		return Key{}, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("error parsing RSA key in %s: %v", path, err)
+	}
+
+	rsaKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return Key{}, fmt.Errorf("key in %s is not an RSA public key", path)
+	}
+
+	return Key{Algorithm: AlgRS256, RSAPublicKey: rsaKey}, nil
+}
+
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	K         string `json:"k"`
+}
+
+// LoadJWKSFile reads a JSON Web Key Set from path. RSA keys are used for RS256 and
+// symmetric (oct) keys for HS256. Keys marked for encryption are skipped.
+func LoadJWKSFile(path string) ([]Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading JWKS file: %v", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("error parsing JWKS file: %v", err)
+	}
+
+	keys := make([]Key, 0, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.toKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d: %v", i, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %s", path)
+	}
+
+	return keys, nil
+}
+
+func (k jwk) toKey() (Key, error) {
+	switch k.KeyType {
+	case "RSA":
+		if k.Algorithm != "" && k.Algorithm != AlgRS256 {
+			return Key{}, fmt.Errorf("unsupported algorithm %q", k.Algorithm)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil || len(n) == 0 {
+			return Key{}, errors.New("invalid RSA modulus")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 {
+			return Key{}, errors.New("invalid RSA exponent")
+		}
+		return Key{
+			ID:        k.KeyID,
+			Algorithm: AlgRS256,
+			RSAPublicKey: &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			},
+		}, nil
+	case "oct":
+		if k.Algorithm != "" && k.Algorithm != AlgHS256 {
+			return Key{}, fmt.Errorf("unsupported algorithm %q", k.Algorithm)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) < minHMACSecretLength {
+			return Key{}, fmt.Errorf("symmetric key must be at least %d bytes", minHMACSecretLength)
+		}
+		return Key{ID: k.KeyID, Algorithm: AlgHS256, HMACSecret: secret}, nil
+	default:
+		return Key{}, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
@@ -2,10 +2,15 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type LoggerContext struct{}
@@ -19,23 +24,212 @@ const (
 	LevelPanic = zapcore.PanicLevel
 )
 
+// Formats accepted in LOG_FORMAT
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Outputs accepted in LOG_OUTPUTS
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+// Config holds logger configuration
+type Config struct {
+	// Level is the minimum level logged until changed with SetLevel
+	Level zapcore.Level `yaml:"level"`
+	// Format is FormatJSON or FormatConsole
+	Format string `yaml:"format"`
+	// Outputs lists where logs are written. Leaving OutputFile out writes no file at all,
+	// for read-only container filesystems.
+	Outputs []string `yaml:"outputs"`
+	// File configures OutputFile
+	File FileConfig `yaml:"file"`
+	// Redaction lists the fields hashed or masked in every log entry
+	Redaction RedactionConfig `yaml:"redaction"`
+}
+
+// FileConfig holds the path and rotation of the log file. The file is rotated once it
+// reaches MaxSizeMB; rotated files are removed after MaxAgeDays or beyond MaxBackups,
+// zero keeping them regardless.
+type FileConfig struct {
+	Path       string `yaml:"path"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxAgeDays int    `yaml:"max_age_days"`
+	MaxBackups int    `yaml:"max_backups"`
+	Compress   bool   `yaml:"compress"`
+}
+
+// DefaultConfig logs JSON at info level to stdout and logs/app.log
+func DefaultConfig() *Config {
+	return &Config{
+		Level:   LevelInfo,
+		Format:  FormatJSON,
+		Outputs: []string{OutputStdout, OutputFile},
+		File: FileConfig{
+			Path:       "logs/app.log",
+			MaxSizeMB:  100,
+			MaxAgeDays: 7,
+			MaxBackups: 10,
+		},
+		Redaction: DefaultRedactionConfig(),
+	}
+}
+
+// NewConfigFromEnv creates a logger config from environment variables, starting from DefaultConfig
+func NewConfigFromEnv() (*Config, error) {
+	config := DefaultConfig()
+	if err := config.LoadEnv(); err != nil {
+		return nil, err
+	}
+	return config, config.Validate()
+}
+
+// LoadEnv overrides the settings whose LOG_* environment variable is set
+func (c *Config) LoadEnv() error {
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		l, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL: %q", level)
+		}
+		c.Level = l
+	}
+	if format := os.Getenv("LOG_FORMAT"); format != "" {
+		c.Format = format
+	}
+	if outputs, ok := os.LookupEnv("LOG_OUTPUTS"); ok {
+		c.Outputs = splitList(outputs)
+	}
+	if path := os.Getenv("LOG_FILE"); path != "" {
+		c.File.Path = path
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{
+		{"LOG_FILE_MAX_SIZE_MB", &c.File.MaxSizeMB},
+		{"LOG_FILE_MAX_AGE_DAYS", &c.File.MaxAgeDays},
+		{"LOG_FILE_MAX_BACKUPS", &c.File.MaxBackups},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid %s: %q", setting.name, value)
+			}
+			*setting.value = n
+		}
+	}
+	if compress := os.Getenv("LOG_FILE_COMPRESS"); compress != "" {
+		b, err := strconv.ParseBool(compress)
+		if err != nil {
+			return fmt.Errorf("invalid LOG_FILE_COMPRESS: %q", compress)
+		}
+		c.File.Compress = b
+	}
+
+	if key := os.Getenv("LOG_REDACTION_KEY"); key != "" {
+		c.Redaction.Key = key
+	}
+	if fields, ok := os.LookupEnv("LOG_REDACT_HASH_FIELDS"); ok {
+		c.Redaction.HashFields = splitList(fields)
+	}
+	if fields, ok := os.LookupEnv("LOG_REDACT_MASK_FIELDS"); ok {
+		c.Redaction.MaskFields = splitList(fields)
+	}
+
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(list string) []string {
+	var entries []string
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Validate checks the config names known formats and outputs. Settings are named by their
+// YAML key, as they may come from a config file or the environment.
+func (c *Config) Validate() error {
+	if c.Format != FormatJSON && c.Format != FormatConsole {
+		return fmt.Errorf("invalid format %q: must be %s or %s", c.Format, FormatJSON, FormatConsole)
+	}
+	if c.File.MaxSizeMB < 0 || c.File.MaxAgeDays < 0 || c.File.MaxBackups < 0 {
+		return fmt.Errorf("file rotation settings must not be negative")
+	}
+	if len(c.Outputs) == 0 {
+		return fmt.Errorf("outputs must name at least one of %s, %s or %s", OutputStdout, OutputStderr, OutputFile)
+	}
+	for _, output := range c.Outputs {
+		switch output {
+		case OutputStdout, OutputStderr:
+		case OutputFile:
+			if c.File.Path == "" {
+				return fmt.Errorf("file.path is required when logging to a file")
+			}
+		default:
+			return fmt.Errorf("invalid outputs entry %q: must be %s, %s or %s", output, OutputStdout, OutputStderr, OutputFile)
+		}
+	}
+	return nil
+}
+
 var Log *zap.Logger
 
+// level is shared by every logger built by Init, so SetLevel applies to loggers already
+// handed out
+var level = zap.NewAtomicLevel()
+
+// file is the rotating log file of the current logger, closed when Init replaces it
+var file *lumberjack.Logger
+
+// Init initializes the logger from the environment, see NewConfigFromEnv
 func Init() error {
-	return InitWithFile("logs/app.log")
+	config, err := NewConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	return InitWithConfig(config)
 }
 
-// InitWithFile initializes logger with both console and file output
-func InitWithFile(logFile string) error {
-	// Create logs directory if it doesn't exist
-	if err := os.MkdirAll("logs", 0755); err != nil {
+// InitWithConfig initializes the logger writing to the outputs of config. Every logger
+// derived from Log, such as the request loggers Print uses, redacts config.Redaction.
+func InitWithConfig(config *Config) error {
+	if err := config.Validate(); err != nil {
 		return err
 	}
 
-	// Open log file
-	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
+	var (
+		writers []zapcore.WriteSyncer
+		rotated *lumberjack.Logger
+	)
+	for _, output := range config.Outputs {
+		switch output {
+		case OutputStdout:
+			writers = append(writers, zapcore.Lock(os.Stdout))
+		case OutputStderr:
+			writers = append(writers, zapcore.Lock(os.Stderr))
+		case OutputFile:
+			// Create the directory now, so an unwritable path fails at startup
+			if err := os.MkdirAll(filepath.Dir(config.File.Path), 0755); err != nil {
+				return err
+			}
+			rotated = &lumberjack.Logger{
+				Filename:   config.File.Path,
+				MaxSize:    config.File.MaxSizeMB,
+				MaxAge:     config.File.MaxAgeDays,
+				MaxBackups: config.File.MaxBackups,
+				Compress:   config.File.Compress,
+			}
+			writers = append(writers, zapcore.AddSync(rotated))
+		}
 	}
 
 	// Configure encoder
@@ -43,20 +237,38 @@ func InitWithFile(logFile string) error {
 	encoderConfig.TimeKey = "timestamp"
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
-	// Create cores for console and file
-	consoleEncoder := zapcore.NewJSONEncoder(encoderConfig)
-	fileEncoder := zapcore.NewJSONEncoder(encoderConfig)
+	encoder := zapcore.NewJSONEncoder(encoderConfig)
+	if config.Format == FormatConsole {
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
+
+	level.SetLevel(config.Level)
+	redaction = newRedactor(config.Redaction)
+	core := &redactingCore{
+		Core:     zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(writers...), level),
+		redactor: redaction,
+	}
 
-	// Write to both console and file
-	core := zapcore.NewTee(
-		zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), zapcore.InfoLevel),
-		zapcore.NewCore(fileEncoder, zapcore.AddSync(file), zapcore.InfoLevel),
-	)
+	if file != nil {
+		_ = file.Close()
+	}
+	file = rotated
 
 	Log = zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
 	return nil
 }
 
+// Level returns the minimum level currently logged
+func Level() zapcore.Level {
+	return level.Level()
+}
+
+// SetLevel changes the minimum level logged, at once for every logger
+func SetLevel(l zapcore.Level) {
+	level.SetLevel(l)
+}
+
 func Sync() {
 	if Log != nil {
 		_ = Log.Sync()
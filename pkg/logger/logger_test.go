@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewConfigFromEnv(t *testing.T) {
+	for _, name := range []string{"LOG_LEVEL", "LOG_FORMAT", "LOG_FILE", "LOG_FILE_MAX_SIZE_MB", "LOG_FILE_MAX_AGE_DAYS", "LOG_FILE_MAX_BACKUPS", "LOG_FILE_COMPRESS", "LOG_REDACTION_KEY"} {
+		t.Setenv(name, "")
+	}
+	// Unset rather than empty, which would ask for no outputs or redact no fields
+	for _, name := range []string{"LOG_OUTPUTS", "LOG_REDACT_HASH_FIELDS", "LOG_REDACT_MASK_FIELDS"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	config, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), config)
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_FORMAT", "console")
+	t.Setenv("LOG_OUTPUTS", "stderr, file")
+	t.Setenv("LOG_FILE", "/var/log/coupon/api.log")
+	t.Setenv("LOG_FILE_MAX_SIZE_MB", "10")
+	t.Setenv("LOG_FILE_MAX_AGE_DAYS", "0")
+	t.Setenv("LOG_FILE_COMPRESS", "true")
+	t.Setenv("LOG_REDACTION_KEY", "support-correlation-key")
+	t.Setenv("LOG_REDACT_HASH_FIELDS", "user_id, subject")
+	t.Setenv("LOG_REDACT_MASK_FIELDS", "")
+
+	config, err = NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, zapcore.DebugLevel, config.Level)
+	assert.Equal(t, FormatConsole, config.Format)
+	assert.Equal(t, []string{OutputStderr, OutputFile}, config.Outputs)
+	assert.Equal(t, FileConfig{Path: "/var/log/coupon/api.log", MaxSizeMB: 10, MaxAgeDays: 0, MaxBackups: 10, Compress: true}, config.File)
+	assert.Equal(t, RedactionConfig{Key: "support-correlation-key", HashFields: []string{"user_id", "subject"}}, config.Redaction)
+}
+
+func TestNewConfigFromEnv_Invalid(t *testing.T) {
+	tests := []struct {
+		name, value string
+	}{
+		{"LOG_LEVEL", "verbose"},
+		{"LOG_FORMAT", "xml"},
+		{"LOG_OUTPUTS", ""},
+		{"LOG_OUTPUTS", "stdout,syslog"},
+		{"LOG_FILE_MAX_SIZE_MB", "-1"},
+		{"LOG_FILE_MAX_BACKUPS", "many"},
+		{"LOG_FILE_COMPRESS", "yes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			_, err := NewConfigFromEnv()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInitWithConfig_WithoutFile(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultConfig()
+	config.Outputs = []string{OutputStdout}
+	config.File.Path = filepath.Join(dir, "logs", "app.log")
+
+	require.NoError(t, InitWithConfig(config))
+	Log.Info("not written to a file")
+
+	// No directory is created when file output is disabled
+	_, err := os.Stat(filepath.Join(dir, "logs"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestInitWithConfig_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	config := DefaultConfig()
+	config.Outputs = []string{OutputFile}
+	config.File.Path = path
+
+	require.NoError(t, InitWithConfig(config))
+	t.Cleanup(func() { file.Close() })
+
+	Log.Info("kept")
+	Log.Debug("dropped")
+	SetLevel(LevelDebug)
+	defer SetLevel(LevelInfo)
+	Log.Debug("kept after the level changed")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"msg":"kept"`)
+	assert.NotContains(t, string(data), `"msg":"dropped"`)
+	assert.Contains(t, string(data), `"msg":"kept after the level changed"`)
+}
@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Masked replaces the value of every masked field, and of hashed fields when no key is set
+const Masked = "[REDACTED]"
+
+// hashPrefix marks a hashed value, so it cannot be mistaken for the value itself
+const hashPrefix = "hmac:"
+
+// RedactionConfig lists the log fields kept out of the logs in the clear. Field names are
+// matched without regard to case. Only fields are redacted: messages, including error
+// strings, are written as they are, so identifiers belong in fields, never in messages.
+type RedactionConfig struct {
+	// Key keys the hash of HashFields. The same key gives the same hash, so support can
+	// search the logs for a user without the logs holding their ID. Without a key the
+	// fields are masked instead.
+	Key string `yaml:"key"`
+	// HashFields are replaced by a keyed hash of their value
+	HashFields []string `yaml:"hash_fields"`
+	// MaskFields are replaced by Masked
+	MaskFields []string `yaml:"mask_fields"`
+}
+
+// DefaultRedactionConfig hashes user identifiers and client addresses and masks credentials
+func DefaultRedactionConfig() RedactionConfig {
+	return RedactionConfig{
+		HashFields: []string{"user_id", "remote_ip", "forwarded_for"},
+		MaskFields: []string{"authorization", "x-api-key", "cookie", "password"},
+	}
+}
+
+// redactor rewrites the fields named by a RedactionConfig
+type redactor struct {
+	key    []byte
+	hashed map[string]bool
+	masked map[string]bool
+}
+
+func newRedactor(config RedactionConfig) *redactor {
+	r := &redactor{
+		key:    []byte(config.Key),
+		hashed: make(map[string]bool),
+		masked: make(map[string]bool),
+	}
+	for _, field := range config.HashFields {
+		r.hashed[strings.ToLower(field)] = true
+	}
+	for _, field := range config.MaskFields {
+		r.masked[strings.ToLower(field)] = true
+	}
+	return r
+}
+
+// redacts reports whether the field named key is redacted
+func (r *redactor) redacts(key string) bool {
+	key = strings.ToLower(key)
+	return r.hashed[key] || r.masked[key]
+}
+
+// value returns what is logged in place of value for the field named key
+func (r *redactor) value(key, value string) string {
+	key = strings.ToLower(key)
+	switch {
+	case r.masked[key]:
+		return Masked
+	case r.hashed[key]:
+		if len(r.key) == 0 {
+			return Masked
+		}
+		mac := hmac.New(sha256.New, r.key)
+		mac.Write([]byte(value))
+		return hashPrefix + hex.EncodeToString(mac.Sum(nil))[:16]
+	default:
+		return value
+	}
+}
+
+// fields returns fields with the redacted ones rewritten, copying them only if needed
+func (r *redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, field := range fields {
+		if !r.redacts(field.Key) {
+			continue
+		}
+		if redacted == nil {
+			redacted = make([]zapcore.Field, len(fields))
+			copy(redacted, fields)
+		}
+		redacted[i] = zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: r.value(field.Key, fieldString(field))}
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// fieldString renders the value of field whatever its type
+func fieldString(field zapcore.Field) string {
+	if field.Type == zapcore.StringType {
+		return field.String
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	return fmt.Sprint(enc.Fields[field.Key])
+}
+
+// redactingCore redacts the fields of every entry, including those added with With,
+// before they reach the wrapped core
+type redactingCore struct {
+	zapcore.Core
+	redactor *redactor
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redactor.fields(fields)), redactor: c.redactor}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.redactor.fields(fields))
+}
+
+// redaction is the redactor of the current logger. Until Init it masks the default fields.
+var redaction = newRedactor(DefaultRedactionConfig())
+
+// Redact returns what the logger writes in place of value for a field named key: value
+// itself unless key is redacted. It lets values logged inside other fields, such as IDs in
+// a request path, be redacted the same way.
+func Redact(key, value string) string {
+	return redaction.value(key, value)
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observeRedacted returns a logger redacting with config and the entries it writes
+func observeRedacted(config RedactionConfig) (*zap.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(&redactingCore{Core: core, redactor: newRedactor(config)}), logs
+}
+
+func TestRedactingCore(t *testing.T) {
+	config := DefaultRedactionConfig()
+	config.Key = "support-correlation-key"
+	log, logs := observeRedacted(config)
+
+	log.With(zap.String("user_id", "user-42")).Info("claimed",
+		zap.String("Authorization", "Bearer secret"),
+		zap.String("remote_ip", "203.0.113.9"),
+		zap.String("coupon_name", "SUMMER"),
+	)
+	ctx := context.WithValue(context.Background(), LoggerContext{}, log)
+	Print(ctx, LevelWarn, "denied", zap.String("user_id", "user-42"))
+
+	entries := logs.All()
+	if assert.Len(t, entries, 2) {
+		fields := entries[0].ContextMap()
+		assert.Regexp(t, `^hmac:[0-9a-f]{16}$`, fields["user_id"])
+		assert.Regexp(t, `^hmac:[0-9a-f]{16}$`, fields["remote_ip"])
+		assert.Equal(t, Masked, fields["Authorization"])
+		assert.Equal(t, "SUMMER", fields["coupon_name"])
+
+		// The same user hashes the same way, so their entries can be found together
+		assert.Equal(t, fields["user_id"], entries[1].ContextMap()["user_id"])
+	}
+}
+
+func TestRedactingCore_KeyedHash(t *testing.T) {
+	hash := func(key string) interface{} {
+		config := DefaultRedactionConfig()
+		config.Key = key
+		log, logs := observeRedacted(config)
+		log.Info("claimed", zap.String("user_id", "user-42"))
+		return logs.All()[0].ContextMap()["user_id"]
+	}
+
+	assert.Equal(t, hash("key-a"), hash("key-a"))
+	assert.NotEqual(t, hash("key-a"), hash("key-b"))
+	// Without a key the value is masked, as an unkeyed hash of an ID is easily reversed
+	assert.Equal(t, Masked, hash(""))
+}
+
+func TestRedactingCore_NonStringFields(t *testing.T) {
+	config := DefaultRedactionConfig()
+	config.Key = "support-correlation-key"
+	log, logs := observeRedacted(config)
+
+	log.Info("claimed", zap.Int("user_id", 42))
+	log.Info("claimed", zap.String("user_id", "42"))
+
+	entries := logs.All()
+	assert.Equal(t, entries[1].ContextMap()["user_id"], entries[0].ContextMap()["user_id"])
+}
+
+func TestRedact(t *testing.T) {
+	previous := redaction
+	t.Cleanup(func() { redaction = previous })
+	redaction = newRedactor(RedactionConfig{MaskFields: []string{"user_id"}})
+
+	assert.Equal(t, Masked, Redact("user_id", "user-42"))
+	assert.Equal(t, Masked, Redact("USER_ID", "user-42"))
+	assert.Equal(t, "SUMMER", Redact("name", "SUMMER"))
+}
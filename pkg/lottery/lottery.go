@@ -0,0 +1,96 @@
+// Package lottery draws winners reproducibly from a seed. Publishing the seed together with
+// the entrants lets anyone re-run a draw and check its result.
+//
+// The seed is committed to before entries open by publishing Commit(seed), its SHA-256 hash,
+// and only revealed to draw. A seed that does not match the commitment is refused, so the
+// seed cannot be chosen once the entrants are known to favour some of them.
+//
+// The draw sorts the entrants, then runs a partial Fisher-Yates shuffle whose random numbers
+// come from SHA-256(seed + ":" + counter), counter counting up from 0. Each number is the
+// first 8 bytes of a hash read as a big-endian uint64, reduced to a range by rejection sampling.
+package lottery
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// seedBytes is the amount of randomness in a generated seed
+const seedBytes = 32
+
+// NewSeed returns a random hex seed
+func NewSeed() (string, error) {
+	b := make([]byte, seedBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Commit returns the commitment to seed published when a lottery is created: the hex
+// SHA-256 hash of the seed
+func Commit(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports whether seed is the one commitment was made to
+func Verify(seed, commitment string) bool {
+	return subtle.ConstantTimeCompare([]byte(Commit(seed)), []byte(strings.ToLower(commitment))) == 1
+}
+
+// Draw picks n winners from entrants, in draw order, and returns the remaining entrants as
+// losers in sorted order. The result only depends on seed and the set of entrants, not on
+// their order. Everyone wins when n is at least the number of entrants.
+func Draw(seed string, entrants []string, n int) (winners, losers []string) {
+	pool := append([]string(nil), entrants...)
+	sort.Strings(pool)
+
+	if n > len(pool) {
+		n = len(pool)
+	}
+	if n < 0 {
+		n = 0
+	}
+
+	stream := &stream{seed: seed}
+	for i := 0; i < n; i++ {
+		j := i + int(stream.uniform(uint64(len(pool)-i)))
+		pool[i], pool[j] = pool[j], pool[i]
+	}
+
+	winners = pool[:n:n]
+	losers = append([]string(nil), pool[n:]...)
+	sort.Strings(losers)
+	return winners, losers
+}
+
+// stream is a deterministic source of random numbers derived from a seed
+type stream struct {
+	seed    string
+	counter uint64
+}
+
+// next returns the next 64 random bits
+func (s *stream) next() uint64 {
+	sum := sha256.Sum256([]byte(s.seed + ":" + strconv.FormatUint(s.counter, 10)))
+	s.counter++
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// uniform returns a number in [0, n) without modulo bias
+func (s *stream) uniform(n uint64) uint64 {
+	limit := math.MaxUint64 - math.MaxUint64%n
+	for {
+		if v := s.next(); v < limit {
+			return v % n
+		}
+	}
+}
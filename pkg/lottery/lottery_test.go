@@ -0,0 +1,107 @@
+package lottery
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func entrants(n int) []string {
+	users := make([]string, n)
+	for i := range users {
+		users[i] = fmt.Sprintf("user%03d", i)
+	}
+	return users
+}
+
+func TestDraw_Reproducible(t *testing.T) {
+	users := entrants(50)
+
+	winners, losers := Draw("audit-seed", users, 5)
+	assert.Len(t, winners, 5)
+	assert.Len(t, losers, 45)
+
+	// Same seed and entrants in any order give the same result
+	reversed := make([]string, len(users))
+	for i, u := range users {
+		reversed[len(users)-1-i] = u
+	}
+	again, _ := Draw("audit-seed", reversed, 5)
+	assert.Equal(t, winners, again)
+
+	other, _ := Draw("other-seed", users, 5)
+	assert.NotEqual(t, winners, other)
+}
+
+func TestDraw_KnownResult(t *testing.T) {
+	// Pins the algorithm: changing it would make past draws impossible to verify
+	winners, losers := Draw("seed", []string{"alice", "bob", "carol", "dave"}, 2)
+	assert.Equal(t, []string{"carol", "alice"}, winners)
+	assert.Equal(t, []string{"bob", "dave"}, losers)
+}
+
+func TestDraw_WinnersAndLosersPartitionEntrants(t *testing.T) {
+	users := entrants(20)
+	winners, losers := Draw("seed", users, 7)
+
+	seen := map[string]bool{}
+	for _, u := range append(append([]string{}, winners...), losers...) {
+		assert.False(t, seen[u], "%s drawn twice", u)
+		seen[u] = true
+	}
+	assert.Len(t, seen, 20)
+	assert.True(t, isSorted(losers))
+}
+
+func TestDraw_Oversubscription(t *testing.T) {
+	winners, losers := Draw("seed", []string{"bob", "alice"}, 5)
+	assert.ElementsMatch(t, []string{"alice", "bob"}, winners)
+	assert.Empty(t, losers)
+
+	winners, losers = Draw("seed", nil, 3)
+	assert.Empty(t, winners)
+	assert.Empty(t, losers)
+}
+
+func TestDraw_Fair(t *testing.T) {
+	// Every entrant should win roughly equally often over many seeds
+	users := entrants(10)
+	wins := map[string]int{}
+	for i := 0; i < 5000; i++ {
+		winners, _ := Draw(fmt.Sprintf("seed-%d", i), users, 1)
+		wins[winners[0]]++
+	}
+	for _, u := range users {
+		assert.InDelta(t, 500, wins[u], 100, "%s won %d times", u, wins[u])
+	}
+}
+
+func TestNewSeed(t *testing.T) {
+	a, err := NewSeed()
+	assert.NoError(t, err)
+	b, _ := NewSeed()
+	assert.Len(t, a, 64)
+	assert.NotEqual(t, a, b)
+}
+
+func isSorted(values []string) bool {
+	for i := 1; i < len(values); i++ {
+		if values[i-1] > values[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCommit(t *testing.T) {
+	// echo -n seed | sha256sum
+	commitment := "19b25856e1c150ca834cffc8b59b23adbd0ec0389e58eb22b3b64768098d002b"
+	assert.Equal(t, commitment, Commit("seed"))
+
+	assert.True(t, Verify("seed", commitment))
+	assert.True(t, Verify("seed", strings.ToUpper(commitment)))
+	assert.False(t, Verify("seed2", commitment))
+	assert.False(t, Verify("seed", ""))
+}
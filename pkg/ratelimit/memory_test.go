@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.now
+	store.lastSweep = clock.t
+	return store, clock
+}
+
+func TestMemoryStore_Burst(t *testing.T) {
+	store, _ := newTestStore()
+	limit := Per(3, time.Minute)
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(context.Background(), "user1", limit)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, _ := store.Take(context.Background(), "user1", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 20*time.Second, result.RetryAfter)
+	assert.Equal(t, time.Minute, result.ResetAfter)
+
+	// Other keys have their own bucket
+	result, _ = store.Take(context.Background(), "user2", limit)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryStore_Refill(t *testing.T) {
+	store, clock := newTestStore()
+	limit := Per(2, time.Second)
+
+	store.Take(context.Background(), "ip", limit)
+	store.Take(context.Background(), "ip", limit)
+	result, _ := store.Take(context.Background(), "ip", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	clock.t = clock.t.Add(500 * time.Millisecond)
+	result, _ = store.Take(context.Background(), "ip", limit)
+	assert.True(t, result.Allowed)
+
+	// Refills never exceed the burst
+	clock.t = clock.t.Add(time.Hour)
+	result, _ = store.Take(context.Background(), "ip", limit)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	store, clock := newTestStore()
+	limit := Per(10, time.Second)
+
+	store.Take(context.Background(), "a", limit)
+	store.Take(context.Background(), "b", limit)
+	assert.Equal(t, 2, store.Len())
+
+	clock.t = clock.t.Add(sweepInterval)
+	store.Take(context.Background(), "c", limit)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestMemoryStore_EvictsIdleBuckets(t *testing.T) {
+	store, clock := newTestStore()
+	limit := Per(500, time.Second)
+	slow := Per(1, time.Hour)
+
+	// Many keys seen once, such as a client cycling through made-up coupon names
+	for i := 0; i < 1000; i++ {
+		store.Take(context.Background(), "coupon|"+strconv.Itoa(i), limit)
+	}
+	store.Take(context.Background(), "user1", slow)
+	assert.Equal(t, 1001, store.Len())
+
+	// Once idle long enough to refill, they are dropped; a drained bucket is kept
+	clock.t = clock.t.Add(sweepInterval)
+	store.Take(context.Background(), "user2", limit)
+	assert.Equal(t, 2, store.Len())
+
+	result, _ := store.Take(context.Background(), "user1", slow)
+	assert.False(t, result.Allowed)
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	store, _ := newTestStore()
+	limit := Per(50, time.Hour)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, _ := store.Take(context.Background(), "coupon", limit)
+			if result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, allowed)
+}
@@ -0,0 +1,78 @@
+// Package ratelimit implements token bucket rate limiting over a pluggable bucket store.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows Burst requests at once, refilled at Rate tokens per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Per returns a limit of n requests per period, all of which may be used at once
+func Per(n int, period time.Duration) Limit {
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}
+}
+
+// Result describes a bucket after a Take
+type Result struct {
+	Allowed bool
+	// Limit is the bucket size
+	Limit int
+	// Remaining is the number of whole tokens left
+	Remaining int
+	// RetryAfter is how long until the next token is available, zero when Allowed
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again
+	ResetAfter time.Duration
+}
+
+// Store holds token buckets. Implementations must make Take atomic per key so that
+// limits hold across concurrent requests, e.g. an in-process map or a shared Redis.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket is a token bucket as of last
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take refills b up to now and removes one token if available
+func (b *bucket) take(limit Limit, now time.Time) Result {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = durationFor(1-b.tokens, limit.Rate)
+	}
+
+	result.Remaining = int(math.Floor(b.tokens))
+	result.ResetAfter = durationFor(float64(limit.Burst)-b.tokens, limit.Rate)
+	return result
+}
+
+// full reports whether b would have refilled completely by now
+func (b *bucket) full(limit Limit, now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*limit.Rate >= float64(limit.Burst)
+}
+
+// durationFor returns how long it takes to refill tokens at rate
+func durationFor(tokens, rate float64) time.Duration {
+	if tokens <= 0 || rate <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / rate * float64(time.Second)))
+}
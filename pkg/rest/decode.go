@@ -0,0 +1,167 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ContentTypeJSON is the media type DecodeJSON accepts
+const ContentTypeJSON = "application/json"
+
+// MaxBodyBytes caps the size of JSON request bodies. Every request body this API takes
+// is a few hundred bytes; bulk uploads use CSV or NDJSON and are streamed instead.
+const MaxBodyBytes = 1 << 20
+
+// DecodeError reports why a request body was rejected. Status is 400, 413 or 415 and
+// Detail is safe to show to the client.
+type DecodeError struct {
+	Status int
+	Detail string
+}
+
+func (e *DecodeError) Error() string {
+	return e.Detail
+}
+
+// ErrEmptyBody is returned by DecodeJSON when the request has no body. Handlers whose
+// body is optional treat it as an empty request.
+var ErrEmptyBody = &DecodeError{Status: http.StatusBadRequest, Detail: "Request body must not be empty"}
+
+// DecodeJSON decodes the request body into dst. It rejects bodies larger than MaxBodyBytes,
+// bodies not sent as application/json, fields dst does not have and anything after the
+// first JSON value, so a typo such as "ammount" is reported instead of silently ignored.
+// Errors are *DecodeError.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return ErrEmptyBody
+	}
+
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if _, err := body.Peek(1); err == io.EOF {
+		return ErrEmptyBody
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != ContentTypeJSON {
+		return &DecodeError{
+			Status: http.StatusUnsupportedMediaType,
+			Detail: "Content-Type must be " + ContentTypeJSON,
+		}
+	}
+
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return decodeError(err)
+		}
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "Request body must contain a single JSON value"}
+	}
+
+	return nil
+}
+
+// Unmarshal decodes a single JSON value from data into dst as strictly as DecodeJSON decodes
+// a request body, for JSON read from elsewhere such as the lines of an NDJSON upload.
+// Errors are *DecodeError.
+func Unmarshal(data []byte, dst interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return &DecodeError{Status: http.StatusBadRequest, Detail: "Request body must contain a single JSON value"}
+	}
+	return nil
+}
+
+// decodeError turns a json.Decoder error into a message naming what was wrong and where
+func decodeError(err error) *DecodeError {
+	var (
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		maxBytesErr  *http.MaxBytesError
+		detail       string
+		unknownField = "json: unknown field "
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &DecodeError{
+			Status: http.StatusRequestEntityTooLarge,
+			Detail: fmt.Sprintf("Request body must not be larger than %d bytes", maxBytesErr.Limit),
+		}
+	case errors.As(err, &syntaxErr):
+		detail = fmt.Sprintf("Request body contains malformed JSON at position %d", syntaxErr.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		detail = "Request body contains malformed JSON"
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			detail = fmt.Sprintf("Request body must be a JSON %s, got %s", jsonKind(typeErr.Type.Kind()), typeErr.Value)
+		} else {
+			detail = fmt.Sprintf("Field %q must be %s, got %s", fieldPath(typeErr.Field), article(jsonKind(typeErr.Type.Kind())), typeErr.Value)
+		}
+	case strings.HasPrefix(err.Error(), unknownField):
+		detail = fmt.Sprintf("Request body contains unknown field %s", strings.TrimPrefix(err.Error(), unknownField))
+	default:
+		detail = "Invalid request body: " + err.Error()
+	}
+
+	return &DecodeError{Status: http.StatusBadRequest, Detail: detail}
+}
+
+// fieldPath writes the dotted path of a json type error, e.g. rules.0.value, with slice
+// indexes in brackets as rules[0].value, the form validation errors use
+func fieldPath(field string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(part); err == nil && i > 0 {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// jsonKind names a Go kind the way a JSON client would
+func jsonKind(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		return kind.String()
+	}
+}
+
+func article(noun string) string {
+	if strings.ContainsRune("aeiou", rune(noun[0])) {
+		return "an " + noun
+	}
+	return "a " + noun
+}
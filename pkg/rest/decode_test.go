@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type decodeTarget struct {
+	Name  string `json:"name"`
+	Rules []struct {
+		Value int `json:"value"`
+	} `json:"rules"`
+	Limits struct {
+		Max int `json:"max"`
+	} `json:"limits"`
+}
+
+func decode(body string) (*decodeTarget, error) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", ContentTypeJSON)
+
+	var dst decodeTarget
+	err := DecodeJSON(httptest.NewRecorder(), req, &dst)
+	return &dst, err
+}
+
+func TestDecodeJSON_Valid(t *testing.T) {
+	dst, err := decode(" {\"name\":\"FLASH25\",\"rules\":[{\"value\":1}]}\n ")
+	assert.NoError(t, err)
+	assert.Equal(t, "FLASH25", dst.Name)
+}
+
+func TestDecodeJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		detail string
+	}{
+		{"malformed", `{"name":}`, http.StatusBadRequest, "Request body contains malformed JSON at position 9"},
+		{"truncated", `{"name":"FLASH25"`, http.StatusBadRequest, "Request body contains malformed JSON"},
+		{"unknown field", `{"nmae":"FLASH25"}`, http.StatusBadRequest, `Request body contains unknown field "nmae"`},
+		{"nested wrong type", `{"limits":{"max":true}}`, http.StatusBadRequest, `Field "limits.max" must be a number, got bool`},
+		{"not an object", `["FLASH25"]`, http.StatusBadRequest, "Request body must be a JSON object, got array"},
+		{"trailing value", `{"name":"A"} {"name":"B"}`, http.StatusBadRequest, "Request body must contain a single JSON value"},
+		{"trailing garbage", `{"name":"A"}x`, http.StatusBadRequest, "Request body must contain a single JSON value"},
+		{"empty", "", http.StatusBadRequest, "Request body must not be empty"},
+		{"too large", `{"name":"` + strings.Repeat("A", MaxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, "Request body must not be larger than 1048576 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decode(tt.body)
+
+			var decodeErr *DecodeError
+			assert.True(t, errors.As(err, &decodeErr))
+			assert.Equal(t, tt.status, decodeErr.Status)
+			assert.Equal(t, tt.detail, decodeErr.Detail)
+		})
+	}
+}
+
+func TestDecodeJSON_EmptyBodyIsDetectable(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	var dst decodeTarget
+	assert.Equal(t, ErrEmptyBody, DecodeJSON(httptest.NewRecorder(), req, &dst))
+}
+
+func TestDecodeJSON_ContentType(t *testing.T) {
+	for _, contentType := range []string{"", "text/plain", "application/jsonp", "text/csv"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"FLASH25"}`))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		var dst decodeTarget
+		err := DecodeJSON(httptest.NewRecorder(), req, &dst)
+
+		var decodeErr *DecodeError
+		assert.True(t, errors.As(err, &decodeErr), contentType)
+		assert.Equal(t, http.StatusUnsupportedMediaType, decodeErr.Status, contentType)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"FLASH25"}`))
+	req.Header.Set("Content-Type", "Application/JSON; charset=utf-8")
+	var dst decodeTarget
+	assert.NoError(t, DecodeJSON(httptest.NewRecorder(), req, &dst))
+}
@@ -5,17 +5,64 @@ import (
 	"net/http"
 )
 
-// respondWithError sends an error response
-func RespondWithError(w http.ResponseWriter, code int, message string) {
-	RespondWithJSON(w, code, map[string]string{"error": message})
+// ContentTypeProblem is the media type of error responses, from RFC 7807
+const ContentTypeProblem = "application/problem+json"
+
+// TraceIDHeader carries the request's trace ID, and is echoed in error responses
+const TraceIDHeader = "X-Trace-ID"
+
+// Problem is an RFC 7807 problem details error response. Code is a stable machine-readable
+// identifier clients can switch on; Detail is meant for people and may change wording.
+type Problem struct {
+	Type    string       `json:"type"`
+	Title   string       `json:"title"`
+	Status  int          `json:"status"`
+	Detail  string       `json:"detail,omitempty"`
+	Code    string       `json:"code"`
+	TraceID string       `json:"trace_id,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// RespondWithError sends a problem response with a stable machine-readable code
+func RespondWithError(w http.ResponseWriter, status int, code, detail string) {
+	RespondWithProblem(w, &Problem{Status: status, Code: code, Detail: detail})
+}
+
+// RespondWithProblem sends a problem response. Type and Title default to about:blank and
+// the status text, and the trace ID is taken from the X-Trace-ID response header.
+func RespondWithProblem(w http.ResponseWriter, problem *Problem) {
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	if problem.TraceID == "" {
+		problem.TraceID = w.Header().Get(TraceIDHeader)
+	}
+
+	response, err := json.Marshal(problem)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(problem.Status)
+	w.Write(response)
 }
 
 // respondWithJSON sends a JSON response
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"error":"Internal server error"}`))
+		RespondWithError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Response could not be encoded")
 		return
 	}
 
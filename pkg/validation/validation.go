@@ -0,0 +1,273 @@
+// Package validation checks request structs against rules declared in `validate` struct tags
+// and reports every violation with the path of the offending field, e.g.
+//
+//	type CreateCouponRequest struct {
+//		Name   string `json:"name" validate:"required,trimmed,max=255,name"`
+//		Amount int    `json:"amount" validate:"min=1"`
+//	}
+//
+// Field paths use the json names, so they match what the client sent. Nested structs and
+// slices of structs are checked too, as eligibility_rules[0].attribute. Rules are checked in
+// the order written and only the first failing rule of a field is reported.
+//
+// Supported rules:
+//
+//	required  strings must not be blank, slices and maps must not be empty
+//	trimmed   strings must not start or end with whitespace
+//	min=N     ints must be at least N, strings at least N characters, slices at least N items
+//	max=N     ints must be at most N, strings at most N characters, slices at most N items
+//	name      strings may only contain letters, digits, '_', '-' and '.', starting with a letter or digit
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxVarchar is the length of the VARCHAR(255) columns request fields are stored in
+const MaxVarchar = 255
+
+// Violation is one field that failed a rule. Message is a full sentence naming the field.
+type Violation struct {
+	Field   string
+	Message string
+}
+
+// Errors lists every violation found in a request
+type Errors []Violation
+
+// Error joins the violation messages
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, v := range e {
+		messages[i] = v.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add records a violation of field with a formatted message
+func (e *Errors) Add(field, format string, args ...interface{}) {
+	*e = append(*e, Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns e as an error, or nil when there are no violations
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Invalid returns an error for a single violation, for checks that need no struct
+func Invalid(field, format string, args ...interface{}) error {
+	var errs Errors
+	errs.Add(field, format, args...)
+	return errs
+}
+
+// Struct checks the validate tags of v, a struct or a pointer to one. Callers can Add
+// violations of cross-field rules to the result before returning its Err.
+func Struct(v interface{}) Errors {
+	var errs Errors
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return errs
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Struct {
+		checkStruct(&errs, "", value)
+	}
+	return errs
+}
+
+// rule is one parsed entry of a validate tag
+type rule struct {
+	name string
+	arg  int
+}
+
+// field is a struct field with its json path name and rules
+type field struct {
+	index int
+	name  string
+	rules []rule
+}
+
+// fieldCache holds the parsed fields of each struct type, as tags never change at runtime
+var fieldCache sync.Map
+
+func checkStruct(errs *Errors, prefix string, value reflect.Value) {
+	for _, f := range fieldsOf(value.Type()) {
+		path := f.name
+		if prefix != "" {
+			path = prefix + "." + f.name
+		}
+		fv := value.Field(f.index)
+
+		for _, r := range f.rules {
+			if msg, ok := check(r, fv); !ok {
+				errs.Add(path, "%s %s", path, msg)
+				break
+			}
+		}
+
+		checkNested(errs, path, fv)
+	}
+}
+
+// checkNested descends into struct fields and slices of structs
+func checkNested(errs *Errors, path string, value reflect.Value) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if len(fieldsOf(value.Type())) > 0 {
+			checkStruct(errs, path, value)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			checkNested(errs, fmt.Sprintf("%s[%d]", path, i), value.Index(i))
+		}
+	}
+}
+
+// fieldsOf returns the fields of t that have rules or may contain fields that do
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := sf.Name
+		if tag := sf.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if jsonName, _, _ := strings.Cut(tag, ","); jsonName != "" {
+				name = jsonName
+			}
+		}
+
+		rules := parseRules(t, sf)
+		if len(rules) == 0 && !mayNest(sf.Type) {
+			continue
+		}
+		fields = append(fields, field{index: i, name: name, rules: rules})
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// mayNest reports whether values of t can hold structs to descend into
+func mayNest(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t.PkgPath() != "time"
+}
+
+// parseRules reads the validate tag of sf. Unknown rules are programming errors and panic,
+// so a typo in a tag fails the first test that touches the type instead of passing silently.
+func parseRules(t reflect.Type, sf reflect.StructField) []rule {
+	tag := sf.Tag.Get("validate")
+	if tag == "" {
+		return nil
+	}
+
+	var rules []rule
+	for _, entry := range strings.Split(tag, ",") {
+		name, arg, hasArg := strings.Cut(strings.TrimSpace(entry), "=")
+		r := rule{name: name}
+		switch name {
+		case "required", "trimmed", "name":
+			if hasArg {
+				panic(fmt.Sprintf("validation: rule %q on %s.%s takes no argument", name, t.Name(), sf.Name))
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				panic(fmt.Sprintf("validation: rule %q on %s.%s needs an integer argument", name, t.Name(), sf.Name))
+			}
+			r.arg = n
+		default:
+			panic(fmt.Sprintf("validation: unknown rule %q on %s.%s", name, t.Name(), sf.Name))
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// check applies r to value and returns the violation message when it fails
+func check(r rule, value reflect.Value) (string, bool) {
+	switch r.name {
+	case "required":
+		switch value.Kind() {
+		case reflect.String:
+			return "is required", strings.TrimSpace(value.String()) != ""
+		case reflect.Slice, reflect.Map:
+			return "is required", value.Len() > 0
+		case reflect.Ptr, reflect.Interface:
+			return "is required", !value.IsNil()
+		}
+	case "trimmed":
+		if value.Kind() == reflect.String {
+			s := value.String()
+			return "must not start or end with whitespace", strings.TrimSpace(s) == s
+		}
+	case "min":
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return fmt.Sprintf("must be at least %d", r.arg), value.Int() >= int64(r.arg)
+		case reflect.String:
+			return fmt.Sprintf("must be at least %d characters", r.arg), utf8.RuneCountInString(value.String()) >= r.arg
+		case reflect.Slice, reflect.Map:
+			return fmt.Sprintf("must have at least %d items", r.arg), value.Len() >= r.arg
+		}
+	case "max":
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return fmt.Sprintf("must be at most %d", r.arg), value.Int() <= int64(r.arg)
+		case reflect.String:
+			return fmt.Sprintf("must be at most %d characters", r.arg), utf8.RuneCountInString(value.String()) <= r.arg
+		case reflect.Slice, reflect.Map:
+			return fmt.Sprintf("must have at most %d items", r.arg), value.Len() <= r.arg
+		}
+	case "name":
+		if value.Kind() == reflect.String {
+			return "may only contain letters, digits, '_', '-' and '.', starting with a letter or digit", IsName(value.String())
+		}
+	}
+	return "", true
+}
+
+// IsName reports whether s is usable as a coupon name, which appears in URL paths and codes
+func IsName(s string) bool {
+	for i, c := range s {
+		switch {
+		case c < utf8.RuneSelf && (unicode.IsLetter(c) || unicode.IsDigit(c)):
+		case i > 0 && (c == '_' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
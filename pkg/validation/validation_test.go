@@ -0,0 +1,134 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	Label string `json:"label" validate:"required"`
+}
+
+type request struct {
+	Name     string            `json:"name" validate:"required,trimmed,max=255,name"`
+	Count    int               `json:"count" validate:"min=1,max=10"`
+	Note     string            `json:"note,omitempty" validate:"min=2"`
+	Tags     []string          `json:"tags" validate:"required,max=2"`
+	Items    []item            `json:"items"`
+	Main     *item             `json:"main"`
+	Ignored  string            `json:"-" validate:"required"`
+	NoJSON   string            `validate:"trimmed"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+func validRequest() *request {
+	return &request{Name: "FLASH25", Count: 1, Note: "ok", Tags: []string{"a"}}
+}
+
+func TestStruct_Valid(t *testing.T) {
+	assert.Empty(t, Struct(validRequest()))
+	assert.NoError(t, Struct(validRequest()).Err())
+}
+
+func TestStruct_ReportsEveryViolation(t *testing.T) {
+	req := &request{
+		Name:   "",
+		Count:  0,
+		Tags:   []string{"a", "b", "c"},
+		Items:  []item{{Label: "x"}, {}},
+		Main:   &item{},
+		NoJSON: " padded",
+	}
+
+	assert.Equal(t, Errors{
+		{Field: "name", Message: "name is required"},
+		{Field: "count", Message: "count must be at least 1"},
+		{Field: "note", Message: "note must be at least 2 characters"},
+		{Field: "tags", Message: "tags must have at most 2 items"},
+		{Field: "items[1].label", Message: "items[1].label is required"},
+		{Field: "main.label", Message: "main.label is required"},
+		{Field: "NoJSON", Message: "NoJSON must not start or end with whitespace"},
+	}, Struct(req))
+}
+
+func TestStruct_FirstFailingRulePerField(t *testing.T) {
+	req := validRequest()
+	req.Name = " " + strings.Repeat("A", 300)
+
+	assert.Equal(t, Errors{{Field: "name", Message: "name must not start or end with whitespace"}}, Struct(req))
+}
+
+func TestStruct_MaxLengthCountsCharacters(t *testing.T) {
+	req := validRequest()
+	req.Name = strings.Repeat("é", MaxVarchar)
+	assert.Equal(t, "name may only contain letters, digits, '_', '-' and '.', starting with a letter or digit", Struct(req).Error())
+
+	req.Name = strings.Repeat("A", MaxVarchar)
+	assert.Empty(t, Struct(req))
+
+	req.Name = strings.Repeat("A", MaxVarchar+1)
+	assert.Equal(t, "name must be at most 255 characters", Struct(req).Error())
+}
+
+func TestStruct_Names(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"FLASH25", true},
+		{"flash_25.v2-b", true},
+		{"9LIVES", true},
+		{"_FLASH", false},
+		{".FLASH", false},
+		{"FLASH 25", false},
+		{"FLASH/25", false},
+		{"FLASH%25", false},
+		{"КУПОН", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			req.Name = tt.name
+			assert.Equal(t, tt.valid, len(Struct(req)) == 0)
+		})
+	}
+}
+
+func TestStruct_NilAndNonStruct(t *testing.T) {
+	var req *request
+	assert.Empty(t, Struct(req))
+	assert.Empty(t, Struct("not a struct"))
+}
+
+func TestStruct_UnknownRulePanics(t *testing.T) {
+	type bad struct {
+		Name string `json:"name" validate:"requird"`
+	}
+	assert.PanicsWithValue(t, `validation: unknown rule "requird" on bad.Name`, func() { Struct(bad{}) })
+}
+
+func TestErrors(t *testing.T) {
+	var errs Errors
+	assert.NoError(t, errs.Err())
+
+	errs.Add("amount", "amount must be at most %d when unique_codes is enabled", 100000)
+	errs.Add("expires_at", "expires_at must be in the future")
+	assert.EqualError(t, errs.Err(), "amount must be at most 100000 when unique_codes is enabled; expires_at must be in the future")
+
+	var found Errors
+	assert.True(t, errors.As(fmt.Errorf("wrapped: %w", errs), &found))
+	assert.Len(t, found, 2)
+}
+
+func TestInvalid(t *testing.T) {
+	err := Invalid("name", "name is required")
+
+	var found Errors
+	assert.True(t, errors.As(err, &found))
+	assert.Equal(t, Errors{{Field: "name", Message: "name is required"}}, found)
+}
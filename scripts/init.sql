@@ -4,6 +4,13 @@ CREATE TABLE IF NOT EXISTS coupons (
     name VARCHAR(255) UNIQUE NOT NULL,
     amount INTEGER NOT NULL,
     remaining_amount INTEGER NOT NULL,
+    unique_codes BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP,
+    eligibility_rules JSONB,
+    allowlist_only BOOLEAN NOT NULL DEFAULT FALSE,
+    queue_mode BOOLEAN NOT NULL DEFAULT FALSE,
+    lottery_closes_at TIMESTAMP,
+    lottery_seed_hash VARCHAR(64) NOT NULL DEFAULT '',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -18,7 +25,142 @@ CREATE TABLE IF NOT EXISTS claims (
     FOREIGN KEY (coupon_name) REFERENCES coupons(name) ON DELETE CASCADE
 );
 
+-- Add the coupon settings introduced after the first release. CREATE TABLE IF NOT EXISTS
+-- leaves an existing table as it is, so databases created before a column existed get it
+-- here; every statement is a no-op once applied, so the script can be re-run to upgrade.
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS unique_codes BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS eligibility_rules JSONB;
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS allowlist_only BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS queue_mode BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS lottery_closes_at TIMESTAMP;
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS lottery_seed_hash VARCHAR(64) NOT NULL DEFAULT '';
+
 -- Create indexes for better performance
 CREATE INDEX IF NOT EXISTS idx_claims_coupon_name ON claims(coupon_name);
 CREATE INDEX IF NOT EXISTS idx_claims_user_id ON claims(user_id);
 CREATE INDEX IF NOT EXISTS idx_claims_user_coupon ON claims(user_id, coupon_name);
+
+-- Create coupon codes table for coupons that hand out a distinct code per claim
+CREATE TABLE IF NOT EXISTS coupon_codes (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(300) UNIQUE NOT NULL,
+    coupon_name VARCHAR(255) NOT NULL,
+    claim_id INTEGER UNIQUE,
+    assigned_at TIMESTAMP,
+    redeemed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name) ON DELETE CASCADE,
+    FOREIGN KEY (claim_id) REFERENCES claims(id) ON DELETE SET NULL
+);
+
+ALTER TABLE coupon_codes ADD COLUMN IF NOT EXISTS redeemed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_coupon_codes_unassigned ON coupon_codes(coupon_name, id) WHERE claim_id IS NULL;
+
+-- Create allowlist table of users permitted to claim allowlist-only coupons
+CREATE TABLE IF NOT EXISTS coupon_allowlist (
+    coupon_name VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (coupon_name, user_id),
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name) ON DELETE CASCADE
+);
+
+-- Create denylist table of users blocked from claiming any coupon
+CREATE TABLE IF NOT EXISTS user_denylist (
+    user_id VARCHAR(255) PRIMARY KEY,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create API keys table for service-to-service callers. Only a SHA-256 hash of each key
+-- is stored; prefix is the public part of the key used to look it up.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) UNIQUE NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL,
+    coupon_names TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- Create claim queue table for coupons in queue mode. Tickets are processed in id order;
+-- each user holds at most one ticket per coupon.
+CREATE TABLE IF NOT EXISTS claim_queue (
+    id BIGSERIAL PRIMARY KEY,
+    ticket UUID UNIQUE NOT NULL,
+    coupon_name VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'waiting',
+    error TEXT NOT NULL DEFAULT '',
+    code VARCHAR(300) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    taken_at TIMESTAMP,
+    processed_at TIMESTAMP,
+    UNIQUE(coupon_name, user_id),
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name) ON DELETE CASCADE
+);
+
+-- taken_at starts the lease of a ticket being processed; tickets still processing once it
+-- expires are taken again
+ALTER TABLE claim_queue ADD COLUMN IF NOT EXISTS taken_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_claim_queue_waiting ON claim_queue(coupon_name, id) WHERE status = 'waiting';
+CREATE INDEX IF NOT EXISTS idx_claim_queue_processing ON claim_queue(taken_at) WHERE status = 'processing';
+
+-- Create lottery tables. Coupons with lottery_closes_at take entries until then; a single
+-- draw per coupon turns the winning entries into claims.
+CREATE TABLE IF NOT EXISTS lottery_entries (
+    id SERIAL PRIMARY KEY,
+    coupon_name VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    result VARCHAR(8),
+    entered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(coupon_name, user_id),
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS lottery_draws (
+    coupon_name VARCHAR(255) PRIMARY KEY,
+    seed TEXT NOT NULL,
+    seed_hash VARCHAR(64) NOT NULL DEFAULT '',
+    entries INTEGER NOT NULL,
+    entrants TEXT[] NOT NULL DEFAULT '{}',
+    winners TEXT[] NOT NULL,
+    drawn_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (coupon_name) REFERENCES coupons(name) ON DELETE CASCADE
+);
+
+-- A draw records the commitment it was checked against and the exact entrants it was run
+-- over, so it can be re-run from the record alone
+ALTER TABLE lottery_draws ADD COLUMN IF NOT EXISTS seed_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE lottery_draws ADD COLUMN IF NOT EXISTS entrants TEXT[] NOT NULL DEFAULT '{}';
+
+-- Create coupon events outbox. Events are written in the same transaction as the change
+-- they describe; the event relay posts them to the events webhook and sets delivered_at.
+CREATE TABLE IF NOT EXISTS coupon_events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    coupon_name VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+ALTER TABLE coupon_events ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_coupon_events_undelivered ON coupon_events(id) WHERE delivered_at IS NULL;
+
+-- Record the schema version. The API is not ready until the database reaches the version
+-- it expects (database.SchemaVersion), so bump both with every schema change, including
+-- columns added with ALTER TABLE.
+CREATE TABLE IF NOT EXISTS schema_version (
+    version INTEGER PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO schema_version (version) VALUES (4) ON CONFLICT DO NOTHING;